@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"github.com/amartya2002/uptime-checker-core/uptime"
 )
@@ -55,7 +54,6 @@ func main() {
 		}
 
 		ep := uptime.Endpoint{
-			ID:             uuid.NewString(), // generate ID here
 			Name:           site.Name,
 			URL:            site.URL,
 			Method:         "GET",
@@ -63,7 +61,13 @@ func main() {
 			ExpectedStatus: site.ExpectedStatus,
 		}
 
-		checker.AddSite(ep)
+		// The checker assigns the ID: DefaultID derives it from the method
+		// and URL, so it is the same when the site is added again.
+		ep, err := checker.AddSite(ep)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		// TODO: save ep to DB here
 
@@ -84,7 +88,6 @@ func main() {
 		var eps []uptime.Endpoint
 		for _, site := range sites {
 			ep := uptime.Endpoint{
-				ID:             uuid.NewString(),
 				Name:           site.Name,
 				URL:            site.URL,
 				Method:         "GET",
@@ -100,7 +103,12 @@ func main() {
 			eps = append(eps, ep)
 		}
 
-		checker.AddSitesBulk(eps)
+		// All or nothing: one bad site rejects the whole batch.
+		eps, err := checker.AddSitesBulkChecked(eps)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		// TODO: persist eps to DB here
 
@@ -140,6 +148,11 @@ func main() {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Live status transitions as Server-Sent Events
+	r.GET("/events", func(c *gin.Context) {
+		checker.ServeSSE(c.Writer, c.Request)
+	})
+
 	// Health check for API
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
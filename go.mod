@@ -2,6 +2,12 @@ module github.com/amartya2002/uptime-checker-core
 
 go 1.23.4
 
-require go.uber.org/zap v1.27.0
+require (
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.3
+	go.uber.org/zap v1.27.0
+	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,99 @@
+package uptime
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Failure Actions =====
+
+const (
+    defaultFailureActionTimeout  = 30 * time.Second
+    defaultFailureActionDebounce = 5 * time.Minute
+    maxConcurrentFailureActions  = 4
+)
+
+// failureAction is the remediation hook of WithFailureAction.
+type failureAction struct {
+    fn       func(context.Context, Result) error
+    timeout  time.Duration
+    debounce time.Duration
+    sem      chan struct{}
+
+    mu   sync.Mutex
+    last map[string]time.Time // last run per endpoint, for debouncing
+}
+
+// actionNotifier is the failure action registered as a notifier, so its
+// runs are gated by quarantine and the alert grace period and queued like
+// any notification. Unlike other notifiers it also gets transitions from
+// UNKNOWN: an endpoint found DOWN by its first check is remediated too.
+type actionNotifier struct{ c *Checker }
+
+// Notify starts the failure action for a transition to DOWN; other events,
+// such as recoveries and escalations, are ignored.
+func (a actionNotifier) Notify(ctx context.Context, ev TransitionEvent) error {
+    if ev.To == StatusDown && ev.From != StatusDown && ev.cause != nil {
+        a.c.runFailureAction(*ev.cause)
+    }
+    return nil
+}
+
+// runFailureAction starts the failure action for the result that took its
+// endpoint DOWN, unless it ran for the endpoint within the debounce window
+// or too many actions are running. The action's context is cancelled after
+// the timeout or when Shutdown gives up; an action that doesn't return then
+// is logged as timed out and keeps its slot until it does. The outcome is
+// logged.
+func (c *Checker) runFailureAction(res Result) {
+    a := c.failureAction
+    ep := res.Endpoint
+    now := time.Now()
+    a.mu.Lock()
+    if last, ok := a.last[ep.ID]; ok && now.Sub(last) < a.debounce {
+        a.mu.Unlock()
+        c.ilog(LogInfo, "failure_action_debounced", endpointFields(ep, zap.Time("last_run", last))...)
+        return
+    }
+    select {
+    case a.sem <- struct{}{}:
+    default:
+        a.mu.Unlock()
+        c.ilog(LogError, "failure_action_skipped", endpointFields(ep, zap.String("reason", "too many actions running"))...)
+        return
+    }
+    a.last[ep.ID] = now
+    a.mu.Unlock()
+
+    c.wg.Add(1)
+    go func() {
+        defer c.wg.Done()
+        defer func() { <-a.sem }()
+        ctx, cancel := context.WithTimeout(c.notifyCtx, a.timeout)
+        defer cancel()
+        done := make(chan error, 1)
+        go func() {
+            defer func() {
+                if p := recover(); p != nil {
+                    done <- fmt.Errorf("panic: %v", p)
+                }
+            }()
+            done <- a.fn(ctx, res)
+        }()
+        select {
+        case err := <-done:
+            if err != nil {
+                c.ilog(LogError, "failure_action_failed", endpointFields(ep, zap.Error(err), zap.Duration("took", time.Since(now)))...)
+                return
+            }
+            c.ilog(LogInfo, "failure_action_succeeded", endpointFields(ep, zap.Duration("took", time.Since(now)))...)
+        case <-ctx.Done():
+            c.ilog(LogError, "failure_action_timed_out", endpointFields(ep, zap.Duration("timeout", a.timeout), zap.Error(ctx.Err()))...)
+            <-done
+        }
+    }()
+}
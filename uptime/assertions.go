@@ -0,0 +1,174 @@
+package uptime
+
+import (
+    "fmt"
+    "net/http"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// ===== Response Assertions =====
+
+// expectsRedirect reports whether ep asserts on a redirect target, in which
+// case redirects are not followed so the 3xx response can be inspected.
+func expectsRedirect(ep Endpoint) bool {
+    return ep.ExpectedLocation != "" || ep.ExpectedLocationRegex != ""
+}
+
+// checkLocation validates the Location header of a 3xx response against
+// ExpectedLocation and ExpectedLocationRegex. It returns "" on match and a
+// descriptive error otherwise. Non-redirect responses are not inspected.
+func (c *Checker) checkLocation(ep Endpoint, resp *http.Response) string {
+    if !expectsRedirect(ep) || resp.StatusCode < 300 || resp.StatusCode > 399 {
+        return ""
+    }
+    raw := resp.Header.Get("Location")
+    if raw == "" {
+        return fmt.Sprintf("redirect %d without Location header", resp.StatusCode)
+    }
+    got := raw
+    if u, err := resp.Location(); err == nil {
+        got = u.String()
+    }
+    if ep.ExpectedLocation != "" && got != ep.ExpectedLocation && raw != ep.ExpectedLocation {
+        return fmt.Sprintf("unexpected redirect location: got %q, want %q", got, ep.ExpectedLocation)
+    }
+    if ep.ExpectedLocationRegex != "" {
+        re, err := c.pattern(ep.ExpectedLocationRegex)
+        if err != nil {
+            return err.Error()
+        }
+        if !re.MatchString(got) {
+            return fmt.Sprintf("unexpected redirect location: got %q, want match for %q", got, ep.ExpectedLocationRegex)
+        }
+    }
+    return ""
+}
+
+// redirectChain returns the URLs requested to get resp, in order: the
+// endpoint URL and each redirect target followed.
+func redirectChain(resp *http.Response) []string {
+    var chain []string
+    for req := resp.Request; req != nil; {
+        chain = append(chain, req.URL.String())
+        if req.Response == nil {
+            break
+        }
+        req = req.Response.Request
+    }
+    for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+        chain[i], chain[j] = chain[j], chain[i]
+    }
+    return chain
+}
+
+// checkHTTPSRedirect validates that the redirects followed for ep ended on
+// an https:// URL, with RequireHTTPSRedirect.
+func checkHTTPSRedirect(ep Endpoint, resp *http.Response) string {
+    if !ep.RequireHTTPSRedirect || resp.Request == nil {
+        return ""
+    }
+    if final := resp.Request.URL; final.Scheme != "https" {
+        return fmt.Sprintf("redirect chain ended on %s, expected an https:// URL", final)
+    }
+    return ""
+}
+
+// pattern returns the compiled form of expr, caching it for later checks.
+func (c *Checker) pattern(expr string) (*regexp.Regexp, error) {
+    if re, ok := c.patterns.Load(expr); ok {
+        return re.(*regexp.Regexp), nil
+    }
+    re, err := regexp.Compile(expr)
+    if err != nil {
+        return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+    }
+    c.patterns.Store(expr, re)
+    return re, nil
+}
+
+// cacheStatusHeaders are the CDN headers reporting whether a response was
+// served from cache.
+var cacheStatusHeaders = []string{"X-Cache", "CF-Cache-Status", "X-Cache-Status", "X-Proxy-Cache"}
+
+func expectsCache(ep Endpoint) bool {
+    return ep.ExpectCacheHit || len(ep.CacheDirectives) > 0 || ep.MinMaxAge > 0 || ep.MaxCacheAge > 0
+}
+
+// checkCache validates the caching headers of resp against ep's cache
+// expectations. It returns "" when they hold and otherwise a descriptive
+// error, with the headers inspected recorded in res.CacheHeaders.
+func checkCache(ep Endpoint, resp *http.Response, res *Result) string {
+    msg := cacheMismatch(ep, resp.Header)
+    if msg == "" {
+        return ""
+    }
+    res.CacheHeaders = make(map[string]string)
+    for _, k := range append([]string{"Cache-Control", "Age"}, cacheStatusHeaders...) {
+        if v := resp.Header.Values(k); len(v) > 0 {
+            res.CacheHeaders[k] = strings.Join(v, ", ")
+        }
+    }
+    return msg
+}
+
+func cacheMismatch(ep Endpoint, h http.Header) string {
+    if ep.ExpectCacheHit {
+        var status []string
+        for _, k := range cacheStatusHeaders {
+            status = append(status, h.Values(k)...)
+        }
+        if len(status) == 0 {
+            return "expected a cache hit, response has no cache status header"
+        }
+        if !strings.Contains(strings.ToUpper(strings.Join(status, ",")), "HIT") {
+            return fmt.Sprintf("expected a cache hit, got %q", strings.Join(status, ", "))
+        }
+    }
+    directives := parseCacheControl(h.Values("Cache-Control"))
+    for _, want := range ep.CacheDirectives {
+        if _, ok := directives[strings.ToLower(want)]; !ok {
+            return fmt.Sprintf("Cache-Control %q lacks %q", h.Get("Cache-Control"), want)
+        }
+    }
+    if ep.MinMaxAge > 0 {
+        v, ok := directives["s-maxage"]
+        if !ok {
+            v, ok = directives["max-age"]
+        }
+        age, err := strconv.Atoi(v)
+        if !ok || err != nil {
+            return fmt.Sprintf("Cache-Control %q has no max-age", h.Get("Cache-Control"))
+        }
+        if age < ep.MinMaxAge {
+            return fmt.Sprintf("max-age %d below %d", age, ep.MinMaxAge)
+        }
+    }
+    if ep.MaxCacheAge > 0 && h.Get("Age") != "" {
+        age, err := strconv.Atoi(strings.TrimSpace(h.Get("Age")))
+        if err != nil {
+            return fmt.Sprintf("invalid Age header %q", h.Get("Age"))
+        }
+        if age > ep.MaxCacheAge {
+            return fmt.Sprintf("cached copy is %ds old, above %ds", age, ep.MaxCacheAge)
+        }
+    }
+    return ""
+}
+
+// parseCacheControl returns Cache-Control directives keyed by lower-case
+// name, with their unquoted values.
+func parseCacheControl(values []string) map[string]string {
+    out := make(map[string]string)
+    for _, v := range values {
+        for _, d := range strings.Split(v, ",") {
+            name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+            if name == "" {
+                continue
+            }
+            out[strings.ToLower(name)] = strings.Trim(val, `"`)
+        }
+    }
+    return out
+}
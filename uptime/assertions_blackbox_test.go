@@ -0,0 +1,963 @@
+package uptime_test
+
+import (
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "crypto/hmac"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "hash"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    up "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// Redirect targets are checked against the Location header without following the redirect.
+func TestExpectedLocation(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        http.Redirect(w, r, "https://canonical.example/home", http.StatusMovedPermanently)
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "exact", URL: ts.URL, ExpectedStatus: 301, ExpectedLocation: "https://canonical.example/home"})
+    if !res.Success {
+        t.Fatalf("expected success, got error=%s", res.Error)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "regex", URL: ts.URL, ExpectedStatus: 301, ExpectedLocationRegex: `^https://`})
+    if !res.Success {
+        t.Fatalf("expected regex success, got error=%s", res.Error)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "wrong", URL: ts.URL, ExpectedStatus: 301, ExpectedLocation: "https://other.example/"})
+    if res.Success || !strings.Contains(res.Error, "unexpected redirect location") {
+        t.Fatalf("expected location mismatch, got success=%v error=%s", res.Success, res.Error)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, ExpectedLocationRegex: "("}); err == nil {
+        t.Fatalf("expected invalid regex to be rejected")
+    }
+}
+
+// Default headers are sent on every check and per-endpoint headers override them.
+func TestDefaultHeadersPrecedence(t *testing.T) {
+    got := make(chan http.Header, 1)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case got <- r.Header.Clone():
+        default:
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t,
+        up.Endpoint{ID: "h", URL: ts.URL, Headers: map[string]string{"Authorization": "Bearer endpoint"}},
+        up.WithDefaultHeaders(map[string]string{"X-Monitor": "uptime-checker", "Authorization": "Bearer global"}),
+    )
+    if !res.Success {
+        t.Fatalf("expected success, got error=%s", res.Error)
+    }
+    h := <-got
+    if h.Get("X-Monitor") != "uptime-checker" {
+        t.Fatalf("expected default header, got %q", h.Get("X-Monitor"))
+    }
+    if h.Get("Authorization") != "Bearer endpoint" {
+        t.Fatalf("expected endpoint header to win, got %q", h.Get("Authorization"))
+    }
+}
+
+// ExpectUnreachable passes when the connection is refused or the response is
+// 403, but not when the request times out.
+func TestExpectUnreachable(t *testing.T) {
+    closed := httptest.NewServer(http.NotFoundHandler())
+    refusedURL := closed.URL
+    closed.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "refused", URL: refusedURL, ExpectUnreachable: true})
+    if !res.Success || !res.Inverted || res.Error != "" {
+        t.Fatalf("expected inverted success on connection refused, got %+v", res)
+    }
+
+    forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusForbidden)
+    }))
+    defer forbidden.Close()
+    res = checkOnce(t, up.Endpoint{ID: "403", URL: forbidden.URL, ExpectUnreachable: true})
+    if !res.Success || !res.Inverted || res.StatusCode != http.StatusForbidden {
+        t.Fatalf("expected inverted success on 403, got %+v", res)
+    }
+
+    open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer open.Close()
+    res = checkOnce(t, up.Endpoint{ID: "exposed", URL: open.URL, ExpectUnreachable: true})
+    if res.Success || !res.Inverted || !strings.Contains(res.Error, "expected endpoint to be unreachable") {
+        t.Fatalf("expected inverted failure when publicly reachable, got %+v", res)
+    }
+
+    slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        <-r.Context().Done()
+    }))
+    defer slow.Close()
+    res = checkOnce(t, up.Endpoint{ID: "slow", URL: slow.URL, ExpectUnreachable: true}, up.WithTimeout(50*time.Millisecond))
+    if res.Success || res.FailureKind != up.FailureTimeout {
+        t.Fatalf("expected a timeout to fail, got %+v", res)
+    }
+}
+
+// BodyFile is streamed as the request body with the configured Content-Type,
+// and reopened to follow a 307 redirect.
+func TestBodyFile(t *testing.T) {
+    type req struct{ body, ctype string }
+    got := make(chan req, 1)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/old" {
+            http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+            return
+        }
+        b, _ := io.ReadAll(r.Body)
+        select {
+        case got <- req{string(b), r.Header.Get("Content-Type")}:
+        default:
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    path := filepath.Join(t.TempDir(), "payload.json")
+    if err := os.WriteFile(path, []byte(`{"ping":true}`), 0o600); err != nil {
+        t.Fatalf("write payload: %v", err)
+    }
+    res := checkOnce(t, up.Endpoint{ID: "post", URL: ts.URL, Method: "POST", BodyFile: path, ContentType: "application/json"})
+    if !res.Success {
+        t.Fatalf("expected success, got error=%s", res.Error)
+    }
+    if r := <-got; r.body != `{"ping":true}` || r.ctype != "application/json" {
+        t.Fatalf("unexpected request: %+v", r)
+    }
+    for len(got) > 0 {
+        <-got // later checks of the same endpoint
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "moved", URL: ts.URL + "/old", Method: "POST", BodyFile: path})
+    if !res.Success {
+        t.Fatalf("expected the redirect to be followed, got %+v", res)
+    }
+    if r := <-got; r.body != `{"ping":true}` {
+        t.Fatalf("unexpected request after redirect: %+v", r)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "missing", URL: ts.URL, BodyFile: filepath.Join(t.TempDir(), "nope")}); err == nil {
+        t.Fatalf("expected missing body file to be rejected")
+    }
+}
+
+// An inline Body is sent on every check, including after a 307 redirect,
+// and endpoints without one still send no body.
+func TestInlineBody(t *testing.T) {
+    type req struct {
+        body, ctype string
+        length      int64
+    }
+    got := make(chan req, 10)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/old" {
+            http.Redirect(w, r, "/hook", http.StatusTemporaryRedirect)
+            return
+        }
+        b, _ := io.ReadAll(r.Body)
+        got <- req{string(b), r.Header.Get("Content-Type"), r.ContentLength}
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "hook", URL: ts.URL + "/old", Method: "POST", Body: `{"event":"ping"}`, ContentType: "application/json"})
+    if !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    if r := <-got; r.body != `{"event":"ping"}` || r.ctype != "application/json" {
+        t.Fatalf("unexpected request after redirect: %+v", r)
+    }
+    for len(got) > 0 {
+        <-got // later checks of the same endpoint
+    }
+
+    if res := checkOnce(t, up.Endpoint{ID: "get", URL: ts.URL}); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    if r := <-got; r.body != "" || r.length != 0 || r.ctype != "" {
+        t.Fatalf("expected no body on a plain GET, got %+v", r)
+    }
+
+    c := up.New(up.DisableLogs())
+    path := filepath.Join(t.TempDir(), "payload.json")
+    os.WriteFile(path, []byte("{}"), 0o600)
+    if _, err := c.AddSite(up.Endpoint{ID: "both", URL: ts.URL, Body: "{}", BodyFile: path}); err == nil {
+        t.Fatalf("expected body and body_file together to be rejected")
+    }
+}
+
+// AcceptableStatusCodes passes any listed status and takes precedence over
+// ExpectedStatus, which still applies alone when the list is empty.
+func TestAcceptableStatusCodes(t *testing.T) {
+    var status atomic.Int64
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(int(status.Load()))
+    }))
+    defer ts.Close()
+    ep := up.Endpoint{ID: "s", URL: ts.URL, AcceptableStatusCodes: []int{200, 201, 204}}
+
+    for code, want := range map[int]bool{200: true, 201: true, 204: true, 202: false, 500: false} {
+        status.Store(int64(code))
+        if res := checkOnce(t, ep); res.Success != want {
+            t.Fatalf("status %d: expected success=%v, got %+v", code, want, res)
+        }
+    }
+    status.Store(204)
+    if res := checkOnce(t, up.Endpoint{ID: "e", URL: ts.URL, ExpectedStatus: 204}); !res.Success {
+        t.Fatalf("expected ExpectedStatus to apply alone, got %+v", res)
+    }
+    if res := checkOnce(t, up.Endpoint{ID: "p", URL: ts.URL, ExpectedStatus: 200, AcceptableStatusCodes: []int{204}}); !res.Success {
+        t.Fatalf("expected AcceptableStatusCodes to take precedence, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    c.AddSite(ep)
+    if got := c.ListSites()[0].ExpectedStatus; got != 0 {
+        t.Fatalf("expected no default ExpectedStatus with a code list, got %d", got)
+    }
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, AcceptableStatusCodes: []int{2000}}); err == nil {
+        t.Fatalf("expected an invalid status code to be rejected")
+    }
+}
+
+// ExpectedBodyContains fails a 200 response whose body, searched up to its
+// first MiB, lacks the keyword.
+func TestExpectedBodyContains(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/ok":
+            io.WriteString(w, "<p>status: healthy</p>")
+        case "/big":
+            io.WriteString(w, strings.Repeat("x", 2<<20)+"healthy")
+        default:
+            io.WriteString(w, "<h1>Something went wrong</h1>")
+        }
+    }))
+    defer ts.Close()
+
+    if res := checkOnce(t, up.Endpoint{ID: "ok", URL: ts.URL + "/ok", ExpectedBodyContains: "healthy"}); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    res := checkOnce(t, up.Endpoint{ID: "err", URL: ts.URL + "/error", ExpectedBodyContains: "healthy"})
+    if res.Success || res.StatusCode != 200 || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, `does not contain "healthy"`) {
+        t.Fatalf("expected an assertion failure for the error page, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "big", URL: ts.URL + "/big", ExpectedBodyContains: "healthy"})
+    if res.Success || !strings.Contains(res.Error, "first 1048576 bytes") {
+        t.Fatalf("expected the search to stop at 1 MiB, got %+v", res)
+    }
+    if res := checkOnce(t, up.Endpoint{ID: "plain", URL: ts.URL + "/error"}); !res.Success {
+        t.Fatalf("expected a status-only check to pass, got %+v", res)
+    }
+}
+
+// HMAC-signed endpoints send the signature of the body the server
+// recomputes, and the secret never appears in serialized endpoints.
+func TestHMACSigning(t *testing.T) {
+    verify := func(header string, h func() hash.Hash) http.HandlerFunc {
+        return func(w http.ResponseWriter, r *http.Request) {
+            body, _ := io.ReadAll(r.Body)
+            mac := hmac.New(h, []byte("s3cret"))
+            mac.Write(body)
+            if !hmac.Equal([]byte(r.Header.Get(header)), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+                w.WriteHeader(http.StatusUnauthorized)
+            }
+        }
+    }
+    ts := httptest.NewServer(verify("X-Signature", sha256.New))
+    defer ts.Close()
+    ep := up.Endpoint{ID: "hook", URL: ts.URL, Method: "POST", Body: `{"event":"ping"}`, HMACSecret: "s3cret"}
+
+    if res := checkOnce(t, ep); !res.Success {
+        t.Fatalf("expected the signature to verify, got %+v", res)
+    }
+    wrong := ep
+    wrong.HMACSecret = "other"
+    if res := checkOnce(t, wrong); res.Success || res.StatusCode != http.StatusUnauthorized {
+        t.Fatalf("expected a wrong secret to be rejected, got %+v", res)
+    }
+
+    ts512 := httptest.NewServer(verify("X-Hub-Signature", sha512.New))
+    defer ts512.Close()
+    ep512 := up.Endpoint{ID: "hub", URL: ts512.URL, Method: "POST", BodyFile: writeTemp(t, "payload"),
+        HMACSecret: "s3cret", HMACHeader: "X-Hub-Signature", HMACAlgo: "sha512"}
+    if res := checkOnce(t, ep512); !res.Success {
+        t.Fatalf("expected the sha512 signature to verify, got %+v", res)
+    }
+
+    b, _ := json.Marshal(ep)
+    if strings.Contains(string(b), "s3cret") || !strings.Contains(string(b), `"hmac_secret":"REDACTED"`) {
+        t.Fatalf("expected the secret redacted, got %s", b)
+    }
+    if s := fmt.Sprint(ep.HMACSecret); s != "REDACTED" {
+        t.Fatalf("expected the secret redacted when formatted, got %s", s)
+    }
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, HMACSecret: "k", HMACAlgo: "md5"}); err == nil {
+        t.Fatalf("expected an unsupported algorithm to be rejected")
+    }
+
+    // A saved file carries the placeholder, which must not become the key.
+    c.AddSite(ep)
+    path := filepath.Join(t.TempDir(), "sites.json")
+    if err := c.SaveToFile(path); err != nil {
+        t.Fatalf("SaveToFile: %v", err)
+    }
+    if err := up.New(up.DisableLogs()).LoadFromFile(path); err == nil {
+        t.Fatalf("expected the redacted secret to be rejected on load")
+    }
+}
+
+func writeTemp(t *testing.T, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "body")
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+// Response bodies are validated against a JSON Schema compiled at registration.
+func TestJSONSchema(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/ok":
+            io.WriteString(w, `{"status":"ok","count":3}`)
+        case "/bad":
+            io.WriteString(w, `{"status":"ok","count":"three"}`)
+        default:
+            io.WriteString(w, "<html></html>")
+        }
+    }))
+    defer ts.Close()
+
+    schema := filepath.Join(t.TempDir(), "schema.json")
+    os.WriteFile(schema, []byte(`{"type":"object","required":["status","count"],"properties":{"count":{"type":"integer"}}}`), 0o644)
+
+    if res := checkOnce(t, up.Endpoint{ID: "ok", URL: ts.URL + "/ok", JSONSchemaFile: schema}); !res.Success {
+        t.Fatalf("expected conforming body to pass, got %+v", res)
+    }
+    res := checkOnce(t, up.Endpoint{ID: "bad", URL: ts.URL + "/bad", JSONSchemaFile: schema})
+    if res.Success || !strings.Contains(res.Error, "/count") {
+        t.Fatalf("expected schema error at /count, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "html", URL: ts.URL + "/html", JSONSchema: []byte(`{"type":"object"}`)})
+    if res.Success || !strings.Contains(res.Error, "not valid JSON") {
+        t.Fatalf("expected non-JSON body to fail, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "x", URL: ts.URL, JSONSchema: []byte(`{"type":12}`)}); err == nil {
+        t.Fatalf("expected invalid schema to be rejected at AddSite")
+    }
+}
+
+// Server-Timing metrics are parsed from the header and announced trailers;
+// the "total" metric splits latency into server and network time.
+func TestParseServerTiming(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Trailer", "Server-Timing")
+        w.Header().Add("Server-Timing", `db;dur=1.5;desc="query, cached", total;dur=2`)
+        io.WriteString(w, "ok")
+        w.Header().Set("Server-Timing", "render;dur=0.5")
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "st", URL: ts.URL, ParseServerTiming: true})
+    want := []up.ServerTimingMetric{
+        {Name: "db", Duration: 1500 * time.Microsecond, Description: "query, cached"},
+        {Name: "total", Duration: 2 * time.Millisecond},
+        {Name: "render", Duration: 500 * time.Microsecond},
+    }
+    if len(res.ServerTiming) != len(want) {
+        t.Fatalf("expected %d metrics, got %+v", len(want), res.ServerTiming)
+    }
+    for i := range want {
+        if res.ServerTiming[i] != want[i] {
+            t.Fatalf("metric %d: got %+v, want %+v", i, res.ServerTiming[i], want[i])
+        }
+    }
+    if res.ServerTime != 2*time.Millisecond || res.NetworkTime != max(res.Latency-res.ServerTime, 0) {
+        t.Fatalf("unexpected split: server %v network %v latency %v", res.ServerTime, res.NetworkTime, res.Latency)
+    }
+
+    plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer plain.Close()
+    res = checkOnce(t, up.Endpoint{ID: "plain", URL: plain.URL, ParseServerTiming: true})
+    if !res.Success || res.ServerTiming != nil || res.ServerTime != 0 || res.NetworkTime != 0 {
+        t.Fatalf("expected no server timing without the header, got %+v", res)
+    }
+}
+
+// DetectChange fails when the body changes, ExpectChange when it stays the
+// same; the first response only sets the reference.
+func TestChangeDetection(t *testing.T) {
+    for _, tc := range []struct {
+        name string
+        ep   up.Endpoint
+        want []bool // success of checks answered v1, v1, v2
+    }{
+        {"detect", up.Endpoint{DetectChange: true}, []bool{true, true, false}},
+        {"expect", up.Endpoint{ExpectChange: true}, []bool{true, false, true}},
+    } {
+        var n atomic.Int32
+        ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if n.Add(1) <= 2 {
+                io.WriteString(w, "v1")
+                return
+            }
+            io.WriteString(w, "v2")
+        }))
+        c := up.New(up.WithWorkers(1), up.DisableLogs())
+        tc.ep.ID, tc.ep.URL, tc.ep.Frequency = tc.name, ts.URL, 10*time.Millisecond
+        if _, err := c.AddSite(tc.ep); err != nil {
+            t.Fatalf("AddSite: %v", err)
+        }
+        c.Start()
+        var got []up.Result
+        for i := range tc.want {
+            got = append(got, waitResult(t, c))
+            if got[i].Success != tc.want[i] {
+                t.Fatalf("%s check %d: expected success=%v, got %+v", tc.name, i, tc.want[i], got[i])
+            }
+        }
+        c.Stop()
+        ts.Close()
+        if got[0].BodyHash != got[1].BodyHash || got[1].BodyHash == got[2].BodyHash || len(got[0].BodyHash) != 64 {
+            t.Fatalf("%s: unexpected hashes %q %q %q", tc.name, got[0].BodyHash, got[1].BodyHash, got[2].BodyHash)
+        }
+        if got[0].Changed || got[1].Changed || !got[2].Changed {
+            t.Fatalf("%s: unexpected change flags %+v", tc.name, got)
+        }
+        for _, r := range got {
+            if !r.Success && r.FailureKind != up.FailureAssertion {
+                t.Fatalf("%s: expected assertion failure, got %+v", tc.name, r)
+            }
+        }
+    }
+
+    // Each URL of a multi-URL endpoint is compared with its own previous body.
+    a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "a") }))
+    defer a.Close()
+    b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "b") }))
+    defer b.Close()
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    c.AddSite(up.Endpoint{ID: "pool", URLs: []string{a.URL, b.URL}, DetectChange: true, Frequency: 10 * time.Millisecond})
+    c.Start()
+    for i := 0; i < 3; i++ {
+        if res := waitResult(t, c); !res.Success {
+            t.Fatalf("multi-URL check %d: expected unchanged bodies to pass, got %+v", i, res)
+        }
+    }
+    c.Stop()
+
+    c = up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "both", URL: "http://example.com", DetectChange: true, ExpectChange: true}); err == nil {
+        t.Fatalf("expected DetectChange with ExpectChange to be rejected")
+    }
+}
+
+// Cache assertions check the cache status, Cache-Control directives and
+// ages, recording the headers seen on failure.
+func TestCacheAssertions(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Cache-Control", "public, s-maxage=600, max-age=60")
+        w.Header().Set("Age", "30")
+        if v := r.URL.Query().Get("x-cache"); v != "" {
+            w.Header().Set("X-Cache", v)
+        }
+    }))
+    defer ts.Close()
+
+    for _, tc := range []struct {
+        name  string
+        query string
+        ep    up.Endpoint
+        err   string // substring of the error; "" when the check passes
+    }{
+        {"all hold", "?x-cache=Hit+from+cloudfront", up.Endpoint{ExpectCacheHit: true, CacheDirectives: []string{"Public"}, MinMaxAge: 600, MaxCacheAge: 60}, ""},
+        {"miss", "?x-cache=MISS", up.Endpoint{ExpectCacheHit: true}, `got "MISS"`},
+        {"no status", "", up.Endpoint{ExpectCacheHit: true}, "no cache status header"},
+        {"directive", "", up.Endpoint{CacheDirectives: []string{"immutable"}}, `lacks "immutable"`},
+        {"max-age", "", up.Endpoint{MinMaxAge: 3600}, "max-age 600 below 3600"},
+        {"stale", "", up.Endpoint{MaxCacheAge: 10}, "30s old"},
+    } {
+        ep := tc.ep
+        ep.ID, ep.URL = "cache", ts.URL+tc.query
+        res := checkOnce(t, ep)
+        if tc.err == "" {
+            if !res.Success || res.CacheHeaders != nil {
+                t.Fatalf("%s: expected success, got %+v", tc.name, res)
+            }
+            continue
+        }
+        if res.Success || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, tc.err) {
+            t.Fatalf("%s: expected assertion failure %q, got %+v", tc.name, tc.err, res)
+        }
+        if res.CacheHeaders["Cache-Control"] != "public, s-maxage=600, max-age=60" || res.CacheHeaders["Age"] != "30" {
+            t.Fatalf("%s: expected recorded cache headers, got %v", tc.name, res.CacheHeaders)
+        }
+    }
+}
+
+// ExpectCompressed requires a Content-Encoding; bodies compressed on an
+// explicit Accept-Encoding are decoded for body assertions.
+func TestExpectCompressed(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+            io.WriteString(w, `{"ok":true}`)
+            return
+        }
+        w.Header().Set("Content-Encoding", "gzip")
+        zw := gzip.NewWriter(w)
+        io.WriteString(zw, `{"ok":true}`)
+        zw.Close()
+    }))
+    defer ts.Close()
+    schema := []byte(`{"type":"object","required":["ok"]}`)
+
+    res := checkOnce(t, up.Endpoint{ID: "default", URL: ts.URL, ExpectCompressed: true, JSONSchema: schema})
+    if !res.Success || res.ContentEncoding != "gzip" {
+        t.Fatalf("expected transparent gzip to pass, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "explicit", URL: ts.URL, AcceptEncoding: "gzip, br", ExpectCompressed: true, JSONSchema: schema})
+    if !res.Success || res.ContentEncoding != "gzip" {
+        t.Fatalf("expected the explicitly requested gzip body to be decoded, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "identity", URL: ts.URL, AcceptEncoding: "identity", ExpectCompressed: true})
+    if res.Success || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, "compressed") {
+        t.Fatalf("expected an uncompressed response to fail, got %+v", res)
+    }
+}
+
+// A deflate body is decoded whether or not the server wraps it in zlib.
+func TestDeflateBody(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Encoding", "deflate")
+        var zw io.WriteCloser
+        if r.URL.Path == "/raw" {
+            zw, _ = flate.NewWriter(w, flate.DefaultCompression)
+        } else {
+            zw = zlib.NewWriter(w)
+        }
+        io.WriteString(zw, "service operational")
+        zw.Close()
+    }))
+    defer ts.Close()
+
+    for _, path := range []string{"/zlib", "/raw"} {
+        res := checkOnce(t, up.Endpoint{ID: path, URL: ts.URL + path, AcceptEncoding: "deflate", ExpectedBodyContains: "operational"})
+        if !res.Success || res.ContentEncoding != "deflate" {
+            t.Fatalf("expected the %s deflate body to be decoded, got %+v", path, res)
+        }
+    }
+}
+
+// A dynamic token must differ from the previous check's; both tokens are
+// recorded when it is stale.
+func TestDynamicBodyRegex(t *testing.T) {
+    var n atomic.Int32
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch k := n.Add(1); {
+        case k <= 2:
+            fmt.Fprintf(w, "<p>rendered at nonce-%d</p>", k)
+        case k == 3:
+            io.WriteString(w, "<p>rendered at nonce-2</p>") // frozen
+        default:
+            io.WriteString(w, "<p>maintenance</p>")
+        }
+    }))
+    defer ts.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "dyn", URL: ts.URL, Frequency: 10 * time.Millisecond, DynamicBodyRegex: `nonce-(\d+)`}); err != nil {
+        t.Fatalf("AddSite: %v", err)
+    }
+    c.Start()
+    defer c.Stop()
+    var got []up.Result
+    for len(got) < 4 {
+        got = append(got, waitResult(t, c))
+    }
+    if !got[0].Success || !got[1].Success || got[1].DynamicToken != "2" {
+        t.Fatalf("expected changing tokens to pass, got %+v", got[:2])
+    }
+    if got[2].Success || got[2].DynamicToken != "2" || got[2].PreviousDynamicToken != "2" || got[2].FailureKind != up.FailureAssertion {
+        t.Fatalf("expected a stale token failure, got %+v", got[2])
+    }
+    if got[3].Success || !strings.Contains(got[3].Error, "not found") {
+        t.Fatalf("expected a missing token failure, got %+v", got[3])
+    }
+
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, DynamicBodyRegex: "("}); err == nil {
+        t.Fatalf("expected an invalid regex to be rejected")
+    }
+
+    // Each URL of a multi-URL endpoint is compared with its own previous
+    // token, so a frozen page is caught next to a dynamic one.
+    var k atomic.Int32
+    pool := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/frozen" {
+            io.WriteString(w, "nonce-x")
+            return
+        }
+        fmt.Fprintf(w, "nonce-%d", k.Add(1))
+    }))
+    defer pool.Close()
+    c2 := up.New(up.WithWorkers(1), up.DisableLogs())
+    c2.AddSite(up.Endpoint{ID: "pool", URLs: []string{pool.URL + "/frozen", pool.URL + "/live"}, Frequency: 10 * time.Millisecond, DynamicBodyRegex: `nonce-(\w+)`})
+    c2.Start()
+    defer c2.Stop()
+    if res := waitResult(t, c2); !res.Success {
+        t.Fatalf("expected the first multi-URL check to pass, got %+v", res)
+    }
+    if res := waitResult(t, c2); res.Success {
+        t.Fatalf("expected the frozen URL to fail the second check, got %+v", res)
+    }
+}
+
+// A body far smaller than the recent norm is flagged as a size anomaly.
+func TestSizeDeviation(t *testing.T) {
+    var n atomic.Int32
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        size := 1000 + int(n.Add(1))%3
+        if n.Load() == 6 {
+            size = 40 // error stub
+        }
+        io.WriteString(w, strings.Repeat("x", size))
+    }))
+    defer ts.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    c.AddSite(up.Endpoint{ID: "page", URL: ts.URL, Frequency: 10 * time.Millisecond, SizeDeviation: 3, SizeSamples: 4})
+    c.Start()
+    defer c.Stop()
+    var got []up.Result
+    for len(got) < 6 {
+        got = append(got, waitResult(t, c))
+    }
+    for i, r := range got[:5] {
+        if r.SizeAnomaly || r.Degraded || r.BodySize < 1000 {
+            t.Fatalf("check %d: unexpected %+v", i, r)
+        }
+    }
+    if r := got[5]; !r.Success || !r.SizeAnomaly || !r.Degraded || r.BodySize != 40 {
+        t.Fatalf("expected a size anomaly, got %+v", r)
+    }
+
+    // A static page may change by a few bytes without being flagged.
+    c2 := up.New(up.DisableLogs())
+    c2.AddSite(up.Endpoint{ID: "static", URL: ts.URL, Frequency: time.Minute, SizeDeviation: 3, SizeSamples: 4})
+    sized := func(n int64) up.Result { return up.Result{Success: true, BodySize: n} }
+    c2.Replay("static", []up.Result{sized(1000), sized(1000), sized(1000), sized(1000), sized(1003), sized(500)})
+    logs := c2.GetLogs("static", 10)
+    if logs[4].SizeAnomaly || !logs[5].SizeAnomaly {
+        t.Fatalf("expected only the halved page flagged, got %v and %v", logs[4].SizeAnomaly, logs[5].SizeAnomaly)
+    }
+}
+
+// RequireHTTPSRedirect passes when the chain ends on HTTPS, fails when it
+// stays on HTTP, and records the URLs followed.
+func TestRequireHTTPSRedirect(t *testing.T) {
+    secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer secure.Close()
+    plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/secure":
+            http.Redirect(w, r, secure.URL+"/home", http.StatusMovedPermanently)
+        case "/plain":
+            http.Redirect(w, r, "/home", http.StatusFound)
+        }
+    }))
+    defer plain.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "s", URL: plain.URL + "/secure", RequireHTTPSRedirect: true, InsecureSkipVerify: true})
+    if !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    if want := []string{plain.URL + "/secure", secure.URL + "/home"}; fmt.Sprint(res.RedirectChain) != fmt.Sprint(want) {
+        t.Fatalf("redirect chain %v, want %v", res.RedirectChain, want)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "p", URL: plain.URL + "/plain", RequireHTTPSRedirect: true})
+    if res.Success || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, plain.URL+"/home") {
+        t.Fatalf("expected an HTTPS redirect failure, got %+v", res)
+    }
+    if len(res.RedirectChain) != 2 {
+        t.Fatalf("expected the chain recorded on failure, got %v", res.RedirectChain)
+    }
+}
+
+// A CORS preflight passes when the response allows the origin, method and
+// headers, records the Access-Control headers, and names what is missing.
+func TestCORSPreflight(t *testing.T) {
+    var got *http.Request
+    var gotBody []byte
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got = r
+        gotBody, _ = io.ReadAll(r.Body)
+        w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+        w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+        w.Header().Set("Access-Control-Allow-Headers", "content-type")
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer srv.Close()
+
+    ep := up.Endpoint{ID: "c", URL: srv.URL, Method: http.MethodPut, CORSOrigin: "https://app.example.com", CORSRequestHeaders: []string{"Content-Type"}}
+    res := checkOnce(t, ep)
+    if !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    if got.Method != http.MethodOptions || got.Header.Get("Access-Control-Request-Method") != http.MethodPut ||
+        got.Header.Get("Access-Control-Request-Headers") != "Content-Type" {
+        t.Fatalf("unexpected preflight %s %v", got.Method, got.Header)
+    }
+    if res.CORSHeaders["Access-Control-Allow-Methods"] != "GET, PUT" {
+        t.Fatalf("CORS headers not recorded: %v", res.CORSHeaders)
+    }
+
+    ep.CORSRequestHeaders = []string{"Content-Type", "Authorization"}
+    res = checkOnce(t, ep)
+    if res.Success || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, "Authorization") {
+        t.Fatalf("expected a missing header failure, got %+v", res)
+    }
+
+    ep.CORSRequestHeaders, ep.Method = nil, http.MethodDelete
+    res = checkOnce(t, ep)
+    if res.Success || !strings.Contains(res.Error, "DELETE") {
+        t.Fatalf("expected a missing method failure, got %+v", res)
+    }
+
+    ep.Method, ep.CORSOrigin = http.MethodPut, "https://evil.example.com"
+    res = checkOnce(t, ep)
+    if res.Success || !strings.Contains(res.Error, "Access-Control-Allow-Origin") {
+        t.Fatalf("expected an origin failure, got %+v", res)
+    }
+
+    // The preflight goes without the request's body, Content-Type and signature.
+    ep.CORSOrigin, ep.Body, ep.ContentType, ep.HMACSecret = "https://app.example.com", `{"a":1}`, "application/json", "s3cret"
+    if res = checkOnce(t, ep); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    if len(gotBody) != 0 || got.ContentLength != 0 || got.Header.Get("Content-Type") != "" || got.Header.Get("X-Signature") != "" {
+        t.Fatalf("expected a bare preflight, got body %q and headers %v", gotBody, got.Header)
+    }
+}
+
+// JSON assertions fail on an empty feed or a missing field and name the
+// assertion that failed.
+func TestJSONAssertions(t *testing.T) {
+    var body atomic.Value
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        io.WriteString(w, body.Load().(string))
+    }))
+    defer srv.Close()
+    ep := up.Endpoint{ID: "j", URL: srv.URL, JSONAssertions: []up.JSONAssertion{
+        {Path: "$.items", MinLen: 1},
+        {Path: "$.items[0].id"},
+    }}
+
+    body.Store(`{"items": [{"id": 7}]}`)
+    if res := checkOnce(t, ep); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    for doc, want := range map[string]string{
+        `{"items": []}`:            `"$.items": expected at least 1 elements, got 0`,
+        `{"items": [{"name": 1}]}`: `"$.items[0].id": path not found`,
+        `{"items": {}}`:            `"$.items": expected an array, got object`,
+        `{}`:                       `"$.items": path not found`,
+    } {
+        body.Store(doc)
+        res := checkOnce(t, ep)
+        if res.Success || res.FailureKind != up.FailureAssertion || !strings.Contains(res.Error, want) {
+            t.Fatalf("%s: expected %q, got %+v", doc, want, res)
+        }
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: srv.URL, JSONAssertions: []up.JSONAssertion{{Path: "$.items[x]"}}}); err == nil {
+        t.Fatalf("expected an invalid path to be rejected")
+    }
+}
+
+// CaptureHeadersOnFailure records the response headers of failed checks
+// only, limited to CaptureHeaderNames when set and capped in size.
+func TestCaptureHeadersOnFailure(t *testing.T) {
+    var status atomic.Int64
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Server", "edge-7")
+        w.Header().Set("X-Cache", "MISS")
+        w.Header().Set("X-Padding", strings.Repeat("p", 10<<10))
+        w.WriteHeader(int(status.Load()))
+    }))
+    defer srv.Close()
+    ep := up.Endpoint{ID: "h", URL: srv.URL, CaptureHeadersOnFailure: true}
+
+    status.Store(http.StatusOK)
+    if res := checkOnce(t, ep); !res.Success || res.Headers != nil {
+        t.Fatalf("expected no headers on success, got %+v", res.Headers)
+    }
+
+    status.Store(http.StatusServiceUnavailable)
+    res := checkOnce(t, ep)
+    if res.Success || res.Headers["Server"][0] != "edge-7" || res.Headers["X-Cache"][0] != "MISS" {
+        t.Fatalf("expected failure headers, got %v", res.Headers)
+    }
+    if _, ok := res.Headers["X-Padding"]; ok {
+        t.Fatalf("expected the oversized header to be left out")
+    }
+
+    ep.CaptureHeaderNames = []string{"x-cache"}
+    res = checkOnce(t, ep)
+    if len(res.Headers) != 1 || res.Headers["X-Cache"][0] != "MISS" {
+        t.Fatalf("expected only X-Cache, got %v", res.Headers)
+    }
+}
+
+// Cookie assertions check every Set-Cookie header, including those set
+// by redirects, and name the first cookie that falls short.
+func TestCookieAssertions(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/login" {
+            http.SetCookie(w, &http.Cookie{Name: "visit", Value: "1", Secure: true})
+            http.Redirect(w, r, "/home", http.StatusFound)
+            return
+        }
+        http.SetCookie(w, &http.Cookie{Name: "session", Value: "s", Secure: true, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+        http.SetCookie(w, &http.Cookie{Name: "prefs", Value: "p", Secure: true, SameSite: http.SameSiteLaxMode})
+    }))
+    defer srv.Close()
+    ep := up.Endpoint{ID: "ck", URL: srv.URL + "/home", ExpectCookieSecure: true, ExpectCookieSameSite: "lax"}
+
+    if res := checkOnce(t, ep); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+
+    ep.ExpectCookieHttpOnly = true
+    res := checkOnce(t, ep)
+    if res.Success || res.FailureKind != up.FailureAssertion || res.InsecureCookie != "prefs" || !strings.Contains(res.Error, "HttpOnly") {
+        t.Fatalf("expected prefs to fail HttpOnly, got %+v", res)
+    }
+
+    ep.CookieNames = []string{"session"}
+    if res := checkOnce(t, ep); !res.Success {
+        t.Fatalf("expected only session to be checked, got %+v", res)
+    }
+
+    ep = up.Endpoint{ID: "ck", URL: srv.URL + "/login", ExpectCookieSameSite: "strict"}
+    res = checkOnce(t, ep)
+    if res.Success || res.InsecureCookie != "visit" || !strings.Contains(res.Error, "SameSite=strict") {
+        t.Fatalf("expected the redirect cookie to fail SameSite, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: srv.URL, ExpectCookieSameSite: "none"}); err == nil {
+        t.Fatalf("expected an invalid SameSite mode to be rejected")
+    }
+}
+
+// ExpectedCharset checks the declared charset of text responses and that
+// the body decodes in it.
+func TestExpectedCharset(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", r.URL.Query().Get("ct"))
+        switch r.URL.Path {
+        case "/latin1":
+            w.Write([]byte("caf\xe9"))
+        case "/replacement":
+            w.Write([]byte("\ufffd"))
+        case "/binary":
+            w.Write([]byte{0xff, 0xfe, 0x00})
+        default:
+            w.Write([]byte("café"))
+        }
+    }))
+    defer srv.Close()
+
+    check := func(path, ct string) up.Result {
+        t.Helper()
+        return checkOnce(t, up.Endpoint{ID: "cs", URL: srv.URL + path + "?ct=" + url.QueryEscape(ct), ExpectedCharset: "utf-8"})
+    }
+    if res := check("/", "text/html; charset=UTF-8"); !res.Success || res.DeclaredCharset != "utf-8" || res.DetectedCharset != "utf-8" {
+        t.Fatalf("expected a valid utf-8 page, got %+v", res)
+    }
+    if res := check("/", "text/html"); res.Success || !strings.Contains(res.Error, "declares no charset") {
+        t.Fatalf("expected a missing charset to fail, got %+v", res)
+    }
+    if res := check("/latin1", "text/plain; charset=iso-8859-1"); res.Success || res.DeclaredCharset != "iso-8859-1" || !strings.Contains(res.Error, "expected utf-8") {
+        t.Fatalf("expected a charset mismatch, got %+v", res)
+    }
+    if res := check("/latin1", "text/plain; charset=utf-8"); res.Success || res.FailureKind != up.FailureAssertion || res.DetectedCharset != "windows-1252" || !strings.Contains(res.Error, "not valid utf-8") {
+        t.Fatalf("expected invalid bytes to fail, got %+v", res)
+    }
+    if res := check("/", "application/json"); !res.Success || res.DeclaredCharset != "" {
+        t.Fatalf("expected JSON without a charset to count as utf-8, got %+v", res)
+    }
+    if res := check("/latin1", "application/json"); res.Success || !strings.Contains(res.Error, "not valid utf-8") {
+        t.Fatalf("expected invalid bytes in JSON to fail, got %+v", res)
+    }
+    if res := check("/replacement", "text/plain; charset=utf-8"); !res.Success {
+        t.Fatalf("expected a literal U+FFFD to be valid, got %+v", res)
+    }
+    if res := check("/binary", "application/octet-stream"); !res.Success || res.DeclaredCharset != "" {
+        t.Fatalf("expected non-text content to be skipped, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{URL: srv.URL, ExpectedCharset: "klingon"}); err == nil {
+        t.Fatalf("expected an unknown charset to be rejected")
+    }
+}
+
+// ExpectedSHA256 fails a body with another digest and records the one seen.
+func TestExpectedSHA256(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("app.js v1"))
+    }))
+    defer srv.Close()
+
+    sum := sha256.Sum256([]byte("app.js v1"))
+    good := hex.EncodeToString(sum[:])
+    if res := checkOnce(t, up.Endpoint{ID: "d", URL: srv.URL, ExpectedSHA256: strings.ToUpper(good)}); !res.Success || res.BodyHash != good {
+        t.Fatalf("expected a matching digest, got %+v", res)
+    }
+    other := strings.Repeat("0", 64)
+    res := checkOnce(t, up.Endpoint{ID: "d", URL: srv.URL, ExpectedSHA256: other})
+    if res.Success || res.FailureKind != up.FailureAssertion || res.BodyHash != good || !strings.Contains(res.Error, "expected "+other) {
+        t.Fatalf("expected a digest mismatch, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{URL: srv.URL, ExpectedSHA256: "abc"}); err == nil {
+        t.Fatalf("expected a malformed digest to be rejected")
+    }
+}
@@ -0,0 +1,82 @@
+package uptime
+
+import (
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Timeout Backoff =====
+
+const defaultBackoffMax = 30 * time.Minute
+
+// timeoutBackoff configures WithTimeoutBackoff.
+type timeoutBackoff struct {
+    after int           // consecutive timeouts before backing off
+    max   time.Duration // longest interval between checks
+}
+
+// backoffState tracks an endpoint's consecutive timeouts.
+type backoffState struct {
+    timeouts int
+    interval time.Duration // current spacing of checks; 0: not backed off
+    next     time.Time     // earliest time of the next scheduled check
+}
+
+// observeTimeout counts consecutive timeouts of the result's endpoint and
+// puts it into, or takes it out of, backoff.
+func (c *Checker) observeTimeout(res Result) {
+    if c.backoff == nil || res.FailureKind == FailureCancelled {
+        return
+    }
+    ep := res.Endpoint
+    c.mu.Lock()
+    st := c.backoffs[ep.ID]
+    if res.FailureKind != FailureTimeout {
+        delete(c.backoffs, ep.ID)
+        c.mu.Unlock()
+        if st != nil && st.interval > 0 {
+            c.ilog(LogInfo, "timeout_backoff_exited", endpointFields(ep)...)
+        }
+        return
+    }
+    if st == nil {
+        st = &backoffState{}
+        c.backoffs[ep.ID] = st
+    }
+    st.timeouts++
+    entered := false
+    if st.timeouts >= c.backoff.after && ep.Frequency > 0 {
+        entered = st.interval == 0
+        if st.interval == 0 {
+            st.interval = 2 * ep.Frequency
+        } else {
+            st.interval *= 2
+        }
+        st.interval = min(st.interval, max(c.backoff.max, ep.Frequency))
+        // Allow for the delay between a tick and the check starting.
+        st.next = res.Timestamp.Add(st.interval - ep.Frequency/2)
+    }
+    interval, timeouts := st.interval, st.timeouts
+    c.mu.Unlock()
+    if entered {
+        c.ilog(LogInfo, "timeout_backoff_entered", endpointFields(ep,
+            zap.Int("timeouts", timeouts), zap.Duration("interval", interval))...)
+    }
+}
+
+// backedOff reports whether the scheduled check of e due now is skipped
+// because the endpoint is in timeout backoff.
+func (c *Checker) backedOff(e Endpoint) bool {
+    if c.backoff == nil {
+        return false
+    }
+    c.mu.RLock()
+    st := c.backoffs[e.ID]
+    skip := st != nil && st.interval > 0 && time.Now().Before(st.next)
+    c.mu.RUnlock()
+    if skip && c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "job_skipped_backoff", endpointFields(e)...)
+    }
+    return skip
+}
@@ -0,0 +1,484 @@
+// Package binlog is an append-only uptime.Storage that keeps results in a
+// compact length-prefixed binary file, for retaining long history on disk.
+//
+// Each record is a uvarint payload length, a CRC-32 of the payload and the
+// payload itself. Every checkpointEvery records the offset of the next
+// record is appended to a sidecar index file (<path>.idx), so Recent reads
+// only the tail segments of the log instead of the whole file. A torn record
+// at the end of the file (e.g. after a crash) is discarded on Open.
+// Incidents and their notes are kept in a JSON sidecar (<path>.incidents).
+//
+//	store, err := binlog.Open("results.binlog")
+//	if err != nil { ... }
+//	defer store.Close()
+//	checker := uptime.New(uptime.WithStorage(store))
+package binlog
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "hash/crc32"
+    "io"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+const (
+    magic           = "UPBLOG1\n"
+    checkpointEvery = 256
+    maxRecord       = 1 << 20
+)
+
+var errCorrupt = errors.New("binlog: corrupt record")
+
+// Store is a binary result log. It is safe for concurrent use.
+type Store struct {
+    mu      sync.Mutex
+    path    string
+    f       *os.File
+    idx     *os.File
+    size    int64   // end of the last complete record
+    records int     // records since the last checkpoint
+    points  []int64 // record offsets at checkpoints, ascending
+}
+
+// Open opens or creates the log at path and its index.
+func Open(path string) (*Store, error) {
+    s := &Store{path: path}
+    if err := s.open(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+func (s *Store) open() error {
+    f, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE, 0o644)
+    if err != nil {
+        return err
+    }
+    info, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return err
+    }
+    if info.Size() == 0 {
+        if _, err := f.Write([]byte(magic)); err != nil {
+            f.Close()
+            return err
+        }
+    } else {
+        head := make([]byte, len(magic))
+        if _, err := io.ReadFull(f, head); err != nil || string(head) != magic {
+            f.Close()
+            return fmt.Errorf("binlog: %s is not a result log", s.path)
+        }
+    }
+    s.f = f
+
+    end, ok := s.loadIndex(info.Size())
+    if !ok {
+        // Rebuild the checkpoints with a full scan, which also finds the end
+        // of the last complete record.
+        s.points = s.points[:0]
+        s.records = 0
+        end, _ = scan(f, int64(len(magic)), s.count)
+    }
+    s.size = end
+    if err := f.Truncate(end); err != nil {
+        f.Close()
+        return err
+    }
+    if ok {
+        return nil
+    }
+    return s.writeIndex()
+}
+
+// count advances the checkpoint state past the record at off.
+func (s *Store) count(off int64, _ []byte) {
+    if s.records == 0 {
+        s.points = append(s.points, off)
+    }
+    s.records = (s.records + 1) % checkpointEvery
+}
+
+// loadIndex reads the checkpoints from the index file and scans only the
+// last segment. It reports false when the index is missing or does not
+// match the log, e.g. after a crash between the two writes.
+func (s *Store) loadIndex(size int64) (int64, bool) {
+    idx, err := os.OpenFile(s.path+".idx", os.O_RDWR|os.O_CREATE, 0o644)
+    if err != nil {
+        return 0, false
+    }
+    buf, err := io.ReadAll(idx)
+    if err != nil || len(buf)%8 != 0 {
+        idx.Close()
+        return 0, false
+    }
+    points := make([]int64, 0, len(buf)/8)
+    prev := int64(len(magic)) - 1
+    for i := 0; i < len(buf); i += 8 {
+        p := int64(binary.LittleEndian.Uint64(buf[i:]))
+        if p <= prev || p >= size {
+            idx.Close()
+            return 0, false
+        }
+        points, prev = append(points, p), p
+    }
+    if len(points) == 0 {
+        if size != int64(len(magic)) {
+            idx.Close()
+            return 0, false
+        }
+        s.idx = idx
+        return size, true
+    }
+    s.points = points
+    s.records = 0
+    last := points[len(points)-1]
+    end, _ := scan(s.f, last, func(int64, []byte) { s.records++ })
+    if s.records == 0 || s.records > checkpointEvery {
+        idx.Close()
+        return 0, false
+    }
+    s.records %= checkpointEvery
+    s.idx = idx
+    return end, true
+}
+
+// writeIndex rewrites the index file from s.points.
+func (s *Store) writeIndex() error {
+    if s.idx != nil {
+        s.idx.Close()
+    }
+    idx, err := os.OpenFile(s.path+".idx", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+    if err != nil {
+        return err
+    }
+    buf := make([]byte, 0, 8*len(s.points))
+    for _, p := range s.points {
+        buf = binary.LittleEndian.AppendUint64(buf, uint64(p))
+    }
+    if _, err := idx.Write(buf); err != nil {
+        idx.Close()
+        return err
+    }
+    s.idx = idx
+    return nil
+}
+
+// Append implements uptime.Storage.
+func (s *Store) Append(res uptime.Result) error {
+    payload := encodeResult(res)
+    rec := binary.AppendUvarint(nil, uint64(len(payload)))
+    rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(payload))
+    rec = append(rec, payload...)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.f == nil {
+        return os.ErrClosed
+    }
+    if _, err := s.f.WriteAt(rec, s.size); err != nil {
+        return err
+    }
+    if s.records == 0 {
+        s.points = append(s.points, s.size)
+        if _, err := s.idx.Write(binary.LittleEndian.AppendUint64(nil, uint64(s.size))); err != nil {
+            return err
+        }
+    }
+    s.records = (s.records + 1) % checkpointEvery
+    s.size += int64(len(rec))
+    return nil
+}
+
+// Recent implements uptime.Storage. It reads checkpoint segments backwards
+// from the end of the log until limit results of the endpoint are found.
+func (s *Store) Recent(id string, limit int) ([]uptime.Result, error) {
+    if limit <= 0 {
+        return nil, nil
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.f == nil {
+        return nil, os.ErrClosed
+    }
+    var segments [][]uptime.Result // newest first
+    found := 0
+    end := s.size
+    for i := len(s.points) - 1; i >= 0 && found < limit; i-- {
+        var seg []uptime.Result
+        var decodeErr error
+        _, err := scan(io.NewSectionReader(s.f, 0, end), s.points[i], func(_ int64, payload []byte) {
+            if decodeErr != nil || recordID(payload) != id {
+                return
+            }
+            r, err := decodeResult(payload)
+            if err != nil {
+                decodeErr = err
+                return
+            }
+            seg = append(seg, r)
+        })
+        if err == nil {
+            err = decodeErr
+        }
+        if err != nil {
+            return nil, err
+        }
+        segments = append(segments, seg)
+        found += len(seg)
+        end = s.points[i]
+    }
+
+    out := make([]uptime.Result, 0, found)
+    for i := len(segments) - 1; i >= 0; i-- {
+        out = append(out, segments[i]...)
+    }
+    if len(out) > limit {
+        out = out[len(out)-limit:]
+    }
+    return out, nil
+}
+
+// Compact rewrites the log keeping only the newest retention results of
+// each endpoint.
+func (s *Store) Compact(retention int) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.f == nil {
+        return os.ErrClosed
+    }
+
+    // Count records per endpoint, then copy the ones within retention.
+    counts := make(map[string]int)
+    if _, err := scan(io.NewSectionReader(s.f, 0, s.size), int64(len(magic)), func(_ int64, p []byte) {
+        counts[recordID(p)]++
+    }); err != nil {
+        return err
+    }
+    tmp, err := os.CreateTemp(dirOf(s.path), ".binlog-compact-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    w := bufio.NewWriter(tmp)
+    w.WriteString(magic)
+    seen := make(map[string]int)
+    _, err = scan(io.NewSectionReader(s.f, 0, s.size), int64(len(magic)), func(_ int64, p []byte) {
+        id := recordID(p)
+        seen[id]++
+        if counts[id]-seen[id] >= retention {
+            return
+        }
+        rec := binary.AppendUvarint(nil, uint64(len(p)))
+        rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(p))
+        w.Write(append(rec, p...))
+    })
+    if err == nil {
+        err = w.Flush()
+    }
+    if err == nil {
+        err = tmp.Sync()
+    }
+    if cerr := tmp.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        return err
+    }
+
+    s.f.Close()
+    s.idx.Close()
+    s.f, s.idx = nil, nil
+    os.Remove(s.path + ".idx")
+    if err := os.Rename(tmp.Name(), s.path); err != nil {
+        if oerr := s.open(); oerr != nil {
+            return errors.Join(err, oerr)
+        }
+        return err
+    }
+    return s.open()
+}
+
+// Close closes the log and its index.
+func (s *Store) Close() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.f == nil {
+        return nil
+    }
+    err := s.f.Close()
+    if s.idx != nil {
+        err = errors.Join(err, s.idx.Close())
+    }
+    s.f, s.idx = nil, nil
+    return err
+}
+
+// scan reads records from off until EOF, calling fn with each record's
+// offset and payload. It returns the offset after the last complete, valid
+// record; a torn or corrupt tail ends the scan without error.
+func scan(r io.ReaderAt, off int64, fn func(off int64, payload []byte)) (int64, error) {
+    br := bufio.NewReader(io.NewSectionReader(r, off, 1<<62))
+    for {
+        n, err := binary.ReadUvarint(br)
+        if err != nil {
+            return off, nil
+        }
+        if n > maxRecord {
+            return off, nil
+        }
+        var sum [4]byte
+        if _, err := io.ReadFull(br, sum[:]); err != nil {
+            return off, nil
+        }
+        payload := make([]byte, n)
+        if _, err := io.ReadFull(br, payload); err != nil {
+            return off, nil
+        }
+        if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(sum[:]) {
+            return off, nil
+        }
+        fn(off, payload)
+        off += int64(uvarintLen(n) + 4 + len(payload))
+    }
+}
+
+func uvarintLen(n uint64) int {
+    return len(binary.AppendUvarint(nil, n))
+}
+
+func dirOf(path string) string {
+    for i := len(path) - 1; i >= 0; i-- {
+        if os.IsPathSeparator(path[i]) {
+            return path[:i+1]
+        }
+    }
+    return "."
+}
+
+// ===== Record Encoding =====
+
+const (
+    flagSuccess = 1 << iota
+    flagInverted
+    flagProxyUsed
+    flagDegraded
+)
+
+// encodeResult stores the fields needed to rebuild logs and status; the
+// endpoint is reduced to its ID and name.
+func encodeResult(r uptime.Result) []byte {
+    var b []byte
+    b = appendString(b, r.Endpoint.ID)
+    b = appendString(b, r.Endpoint.Name)
+    b = binary.AppendVarint(b, r.Timestamp.UnixNano())
+    b = binary.AppendUvarint(b, uint64(r.StatusCode))
+    b = binary.AppendVarint(b, int64(r.Latency))
+    var flags byte
+    if r.Success {
+        flags |= flagSuccess
+    }
+    if r.Inverted {
+        flags |= flagInverted
+    }
+    if r.ProxyUsed {
+        flags |= flagProxyUsed
+    }
+    if r.Degraded {
+        flags |= flagDegraded
+    }
+    b = append(b, flags)
+    b = appendString(b, r.Error)
+    b = appendString(b, string(r.FailureKind))
+    b = appendString(b, string(r.Origin))
+    return b
+}
+
+func decodeResult(p []byte) (uptime.Result, error) {
+    d := decoder{buf: bytes.NewReader(p)}
+    var r uptime.Result
+    r.Endpoint.ID = d.string()
+    r.Endpoint.Name = d.string()
+    r.Timestamp = time.Unix(0, d.varint())
+    r.StatusCode = int(d.uvarint())
+    r.Latency = time.Duration(d.varint())
+    flags := d.byte()
+    r.Success = flags&flagSuccess != 0
+    r.Inverted = flags&flagInverted != 0
+    r.ProxyUsed = flags&flagProxyUsed != 0
+    r.Degraded = flags&flagDegraded != 0
+    r.Error = d.string()
+    r.FailureKind = uptime.FailureKind(d.string())
+    r.Origin = uptime.Origin(d.string())
+    if d.err != nil {
+        return uptime.Result{}, errCorrupt
+    }
+    return r, nil
+}
+
+// recordID returns the endpoint ID of an encoded record without decoding
+// the rest.
+func recordID(p []byte) string {
+    d := decoder{buf: bytes.NewReader(p)}
+    return d.string()
+}
+
+func appendString(b []byte, s string) []byte {
+    b = binary.AppendUvarint(b, uint64(len(s)))
+    return append(b, s...)
+}
+
+type decoder struct {
+    buf *bytes.Reader
+    err error
+}
+
+func (d *decoder) uvarint() uint64 {
+    if d.err != nil {
+        return 0
+    }
+    v, err := binary.ReadUvarint(d.buf)
+    d.err = err
+    return v
+}
+
+func (d *decoder) varint() int64 {
+    if d.err != nil {
+        return 0
+    }
+    v, err := binary.ReadVarint(d.buf)
+    d.err = err
+    return v
+}
+
+func (d *decoder) byte() byte {
+    if d.err != nil {
+        return 0
+    }
+    v, err := d.buf.ReadByte()
+    d.err = err
+    return v
+}
+
+func (d *decoder) string() string {
+    n := d.uvarint()
+    if d.err != nil {
+        return ""
+    }
+    if n > uint64(d.buf.Len()) {
+        d.err = errCorrupt
+        return ""
+    }
+    b := make([]byte, n)
+    _, d.err = io.ReadFull(d.buf, b)
+    return string(b)
+}
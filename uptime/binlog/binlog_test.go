@@ -0,0 +1,181 @@
+package binlog_test
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+    "github.com/amartya2002/uptime-checker-core/uptime/binlog"
+)
+
+func result(id string, i int) uptime.Result {
+    return uptime.Result{
+        Endpoint:    uptime.Endpoint{ID: id, Name: "name-" + id},
+        Timestamp:   time.Unix(1700000000, 0).Add(time.Duration(i) * time.Second),
+        StatusCode:  200 + i%2,
+        Latency:     time.Duration(i) * time.Millisecond,
+        Success:     i%2 == 0,
+        Error:       fmt.Sprint("err", i),
+        FailureKind: uptime.FailureStatus,
+        Origin:      uptime.OriginScheduled,
+        Degraded:    i%3 == 0,
+    }
+}
+
+// Records round-trip, Recent returns the tail for one endpoint across
+// checkpoint segments and survives reopening with a torn trailing record.
+func TestStoreAppendRecentReopen(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "results.binlog")
+    s, err := binlog.Open(path)
+    if err != nil {
+        t.Fatalf("Open: %v", err)
+    }
+    for i := 0; i < 1000; i++ {
+        id := "a"
+        if i%4 == 0 {
+            id = "b"
+        }
+        if err := s.Append(result(id, i)); err != nil {
+            t.Fatalf("Append: %v", err)
+        }
+    }
+    check := func(s *binlog.Store) {
+        t.Helper()
+        got, err := s.Recent("b", 300)
+        if err != nil {
+            t.Fatalf("Recent: %v", err)
+        }
+        if len(got) != 250 {
+            t.Fatalf("expected all 250 results of b, got %d", len(got))
+        }
+        got, _ = s.Recent("a", 3)
+        if len(got) != 3 {
+            t.Fatalf("expected 3 results, got %d", len(got))
+        }
+        for k, i := range []int{997, 998, 999} {
+            want := result("a", i)
+            if !got[k].Timestamp.Equal(want.Timestamp) {
+                t.Fatalf("result %d: got %v, want %v", k, got[k].Timestamp, want.Timestamp)
+            }
+            got[k].Timestamp = want.Timestamp
+            if fmt.Sprint(got[k]) != fmt.Sprint(want) {
+                t.Fatalf("result %d: got %+v, want %+v", k, got[k], want)
+            }
+        }
+    }
+    check(s)
+    if err := s.Close(); err != nil {
+        t.Fatalf("Close: %v", err)
+    }
+
+    f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+    f.Write([]byte{0x40, 1, 2})
+    f.Close()
+    s, err = binlog.Open(path)
+    if err != nil {
+        t.Fatalf("reopen: %v", err)
+    }
+    defer s.Close()
+    check(s)
+    if err := s.Append(result("a", 1000)); err != nil {
+        t.Fatalf("Append after reopen: %v", err)
+    }
+    if got, _ := s.Recent("a", 1); len(got) != 1 || got[0].Latency != time.Second {
+        t.Fatalf("expected the appended result after the torn tail, got %+v", got)
+    }
+}
+
+// Compact keeps the newest retention results per endpoint.
+func TestStoreCompact(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "results.binlog")
+    s, _ := binlog.Open(path)
+    defer s.Close()
+    for i := 0; i < 600; i++ {
+        s.Append(result("a", i))
+        if i < 5 {
+            s.Append(result("b", i))
+        }
+    }
+    before, _ := os.Stat(path)
+    if err := s.Compact(10); err != nil {
+        t.Fatalf("Compact: %v", err)
+    }
+    after, _ := os.Stat(path)
+    if after.Size() >= before.Size() {
+        t.Fatalf("expected the log to shrink, %d -> %d bytes", before.Size(), after.Size())
+    }
+    a, _ := s.Recent("a", 100)
+    b, _ := s.Recent("b", 100)
+    if len(a) != 10 || a[0].Latency != 590*time.Millisecond || len(b) != 5 {
+        t.Fatalf("unexpected results after compaction: %d of a, %d of b", len(a), len(b))
+    }
+}
+
+// A checker using the store restores logs when the endpoint is registered
+// again.
+func TestCheckerRestoresFromStore(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer ts.Close()
+    path := filepath.Join(t.TempDir(), "results.binlog")
+    s, _ := binlog.Open(path)
+
+    ep := uptime.Endpoint{ID: "api", URL: ts.URL, Frequency: 10 * time.Millisecond}
+    c := uptime.New(uptime.WithStorage(s), uptime.WithWorkers(1), uptime.DisableLogs())
+    c.AddSite(ep)
+    c.Start()
+    for i := 0; i < 3; i++ {
+        select {
+        case <-c.Results():
+        case <-time.After(2 * time.Second):
+            t.Fatal("timed out waiting for a result")
+        }
+    }
+    c.Stop()
+    s.Close()
+
+    s, err := binlog.Open(path)
+    if err != nil {
+        t.Fatalf("reopen: %v", err)
+    }
+    defer s.Close()
+    c = uptime.New(uptime.WithStorage(s), uptime.DisableLogs())
+    c.AddSite(ep)
+    logs := c.GetLogs("api", 100)
+    if len(logs) < 3 || !logs[0].Success || logs[0].Endpoint.URL != ts.URL {
+        t.Fatalf("expected restored logs, got %+v", logs)
+    }
+}
+
+// Incidents and their notes survive a restart through the store.
+func TestCheckerIncidentsPersist(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "results.binlog")
+    s, _ := binlog.Open(path)
+    ep := uptime.Endpoint{ID: "api", URL: "http://203.0.113.1", Frequency: time.Minute}
+    c := uptime.New(uptime.WithStorage(s), uptime.DisableLogs())
+    c.AddSite(ep)
+    c.Replay("api", []uptime.Result{{Success: true}, {Success: false, Error: "boom", FailureKind: uptime.FailureStatus}, {Success: true}})
+    if err := c.AnnotateIncident("api", 0, "bad deploy, rolled back"); err != nil {
+        t.Fatalf("AnnotateIncident: %v", err)
+    }
+    s.Close()
+
+    s, err := binlog.Open(path)
+    if err != nil {
+        t.Fatalf("reopen: %v", err)
+    }
+    defer s.Close()
+    c = uptime.New(uptime.WithStorage(s), uptime.DisableLogs())
+    c.AddSite(ep)
+    got := c.Incidents("api")
+    if len(got) != 1 || got[0].Error != "boom" || got[0].Ongoing() {
+        t.Fatalf("expected the resolved incident restored, got %+v", got)
+    }
+    if len(got[0].Notes) != 1 || got[0].Notes[0].Text != "bad deploy, rolled back" {
+        t.Fatalf("expected the note restored, got %+v", got[0].Notes)
+    }
+}
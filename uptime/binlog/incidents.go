@@ -0,0 +1,64 @@
+package binlog
+
+import (
+    "encoding/json"
+    "errors"
+    "os"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// Incidents are few and rewritten whole, so they are kept as JSON in a
+// sidecar file (<path>.incidents) rather than in the log itself.
+
+// SaveIncidents replaces the stored incidents of the endpoint, implementing
+// uptime.IncidentStorage.
+func (s *Store) SaveIncidents(id string, incidents []uptime.Incident) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    all, err := s.readIncidents()
+    if err != nil {
+        return err
+    }
+    if len(incidents) == 0 {
+        delete(all, id)
+    } else {
+        all[id] = incidents
+    }
+    data, err := json.Marshal(all)
+    if err != nil {
+        return err
+    }
+    tmp := s.path + ".incidents.tmp"
+    if err := os.WriteFile(tmp, data, 0o644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, s.path+".incidents")
+}
+
+// LoadIncidents returns the stored incidents of the endpoint, implementing
+// uptime.IncidentStorage.
+func (s *Store) LoadIncidents(id string) ([]uptime.Incident, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    all, err := s.readIncidents()
+    if err != nil {
+        return nil, err
+    }
+    return all[id], nil
+}
+
+func (s *Store) readIncidents() (map[string][]uptime.Incident, error) {
+    all := make(map[string][]uptime.Incident)
+    data, err := os.ReadFile(s.path + ".incidents")
+    if errors.Is(err, os.ErrNotExist) {
+        return all, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(data, &all); err != nil {
+        return nil, err
+    }
+    return all, nil
+}
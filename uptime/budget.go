@@ -0,0 +1,191 @@
+package uptime
+
+import (
+    "sort"
+    "sync"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Check Budget =====
+
+// checkBudget caps the checks dispatched per fixed window (WithCheckBudget).
+// Retries and on-demand checks are charged to it but never deferred.
+// Scheduled checks over the cap are deferred, one pending check per endpoint ID, and
+// dispatched by priority, with the endpoint's configuration at that time,
+// when the next window opens.
+type checkBudget struct {
+    limit int
+    per   time.Duration
+
+    mu       sync.Mutex
+    start    time.Time // current window start; zero until first use
+    used     int
+    deferred map[string]struct{} // IDs of endpoints with a deferred check
+    total    int64               // checks deferred so far
+}
+
+// rollLocked moves to the window containing now.
+func (b *checkBudget) rollLocked(now time.Time) {
+    if b.start.IsZero() {
+        b.start = now
+        return
+    }
+    if elapsed := now.Sub(b.start); elapsed >= b.per {
+        b.start = b.start.Add(elapsed / b.per * b.per)
+        b.used = 0
+    }
+}
+
+// take consumes one check of the budget for ep, or defers ep and reports
+// false when the window is used up.
+func (b *checkBudget) take(ep Endpoint, now time.Time) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.rollLocked(now)
+    if b.used < b.limit {
+        b.used++
+        delete(b.deferred, ep.ID)
+        return true
+    }
+    if _, ok := b.deferred[ep.ID]; !ok {
+        b.deferred[ep.ID] = struct{}{}
+        b.total++
+    }
+    return false
+}
+
+// charge consumes one check of the budget for a check that is never
+// deferred, such as a retry or an on-demand check. The window may go over
+// its limit; scheduled checks are deferred until it rolls over.
+func (b *checkBudget) charge(now time.Time) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.rollLocked(now)
+    b.used++
+}
+
+// release takes budget for as many deferred checks as the window allows,
+// highest priority first, and returns them. lookup returns the current
+// configuration of the registered endpoints among ids; it is called
+// without b.mu held. The checks of endpoints removed meanwhile are dropped
+// without using budget.
+func (b *checkBudget) release(now time.Time, lookup func(ids []string) []Endpoint) []Endpoint {
+    b.mu.Lock()
+    ids := make([]string, 0, len(b.deferred))
+    for id := range b.deferred {
+        ids = append(ids, id)
+    }
+    b.mu.Unlock()
+    found := lookup(ids)
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    registered := make(map[string]bool, len(found))
+    pending := make([]Endpoint, 0, len(found))
+    for _, ep := range found {
+        registered[ep.ID] = true
+        if _, ok := b.deferred[ep.ID]; ok { // not dispatched in the meantime
+            pending = append(pending, ep)
+        }
+    }
+    for _, id := range ids {
+        if !registered[id] {
+            delete(b.deferred, id)
+        }
+    }
+    b.rollLocked(now)
+    sort.Slice(pending, func(i, j int) bool {
+        if pending[i].Priority != pending[j].Priority {
+            return pending[i].Priority > pending[j].Priority
+        }
+        return pending[i].ID < pending[j].ID
+    })
+    n := max(min(len(pending), b.limit-b.used), 0)
+    for _, ep := range pending[:n] {
+        delete(b.deferred, ep.ID)
+    }
+    b.used += n
+    return pending[:n]
+}
+
+// pending reports whether a check of id is deferred to a later window.
+func (b *checkBudget) pending(id string) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    _, ok := b.deferred[id]
+    return ok
+}
+
+// untilNextWindow returns the time left in the current window.
+func (b *checkBudget) untilNextWindow(now time.Time) time.Duration {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.rollLocked(now)
+    return b.start.Add(b.per).Sub(now)
+}
+
+func (b *checkBudget) stats(s *Stats) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    s.BudgetLimit = b.limit
+    s.BudgetUsed = b.used
+    s.BudgetDeferred = b.total
+    s.BudgetPending = len(b.deferred)
+}
+
+// withinBudget reports whether the due check of ep may be dispatched now.
+func (c *Checker) withinBudget(ep Endpoint) bool {
+    if c.budget == nil || c.budget.take(ep, time.Now()) {
+        return true
+    }
+    if c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "job_deferred_budget", endpointFields(ep)...)
+    }
+    return false
+}
+
+// chargeBudget counts a retry or an on-demand check against the budget.
+func (c *Checker) chargeBudget() {
+    if c.budget != nil {
+        c.budget.charge(time.Now())
+    }
+}
+
+// endpointsByID returns the registered endpoints among ids.
+func (c *Checker) endpointsByID(ids []string) []Endpoint {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    eps := make([]Endpoint, 0, len(ids))
+    for _, id := range ids {
+        if ep, ok := c.endpointLocked(id); ok {
+            eps = append(eps, ep)
+        }
+    }
+    return eps
+}
+
+// budgetLoop dispatches deferred checks at the start of each budget window
+// until Stop.
+func (c *Checker) budgetLoop() {
+    defer c.wg.Done()
+    for {
+        t := time.NewTimer(c.budget.untilNextWindow(time.Now()))
+        select {
+        case <-c.stopCh:
+            t.Stop()
+            return
+        case <-t.C:
+        }
+        eps := c.budget.release(time.Now(), c.endpointsByID)
+        if len(eps) > 0 {
+            c.ilog(LogInfo, "budget_deferred_dispatched", zap.Int("count", len(eps)))
+        }
+        for _, ep := range eps {
+            if c.claim(ep) {
+                c.enqueue(ep)
+            }
+        }
+    }
+}
@@ -0,0 +1,232 @@
+package uptime
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "hash"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// ===== Change Detection =====
+
+// maxChangeBody caps the response bytes inspected for change detection and
+// ExpectedSHA256.
+const maxChangeBody = 10 << 20
+
+// maxContainsBody caps the response bytes searched for ExpectedBodyContains.
+const maxContainsBody = 1 << 20
+
+func detectsChange(ep Endpoint) bool { return ep.DetectChange || ep.ExpectChange }
+
+// bodyCapture collects the response body for the checks comparing it with
+// the previous response.
+type bodyCapture struct {
+    hash hash.Hash     // DetectChange/ExpectChange
+    buf  *bytes.Buffer // DynamicBodyRegex
+    size *byteCounter  // SizeDeviation
+    text *cappedBuffer // ExpectedBodyContains
+    cset *cappedBuffer // ExpectedCharset
+    read *byteCounter  // ExpectedSHA256, to tell a body cut at the cap
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter struct{ n int64 }
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+    b.n += int64(len(p))
+    return len(p), nil
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the
+// rest.
+type cappedBuffer struct {
+    bytes.Buffer
+    max int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+    if room := b.max - b.Len(); room > 0 {
+        b.Buffer.Write(p[:min(len(p), room)])
+    }
+    return len(p), nil
+}
+
+// captureBody makes resp.Body feed the capture as it is read, so body
+// assertions and the comparisons share one read. It returns nil when ep
+// compares nothing.
+func captureBody(ep Endpoint, resp *http.Response) *bodyCapture {
+    var bc bodyCapture
+    var sinks []io.Writer
+    if detectsChange(ep) || ep.ExpectedSHA256 != "" {
+        bc.hash = sha256.New()
+        sinks = append(sinks, bc.hash)
+    }
+    if ep.ExpectedSHA256 != "" {
+        bc.read = &byteCounter{}
+        sinks = append(sinks, bc.read)
+    }
+    if ep.DynamicBodyRegex != "" {
+        bc.buf = new(bytes.Buffer)
+        sinks = append(sinks, bc.buf)
+    }
+    if ep.SizeDeviation > 0 {
+        bc.size = &byteCounter{}
+        sinks = append(sinks, bc.size)
+    }
+    if ep.ExpectedBodyContains != "" {
+        bc.text = &cappedBuffer{max: maxContainsBody}
+        sinks = append(sinks, bc.text)
+    }
+    if ep.ExpectedCharset != "" {
+        bc.cset = &cappedBuffer{max: maxCharsetBody}
+        sinks = append(sinks, bc.cset)
+    }
+    if len(sinks) == 0 {
+        return nil
+    }
+    resp.Body = struct {
+        io.Reader
+        io.Closer
+    }{io.TeeReader(io.LimitReader(resp.Body, maxChangeBody), io.MultiWriter(sinks...)), resp.Body}
+    return &bc
+}
+
+// checkCaptured reads the rest of the body and runs the body assertions and
+// the comparisons with the previous response over the same path p. It
+// returns the first failure message, or "".
+func (c *Checker) checkCaptured(ep Endpoint, p NetworkPath, res *Result, resp *http.Response, bc *bodyCapture) string {
+    if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+        return "Error reading response body: " + err.Error()
+    }
+    if bc.size != nil {
+        res.BodySize = bc.size.n
+    }
+    if bc.text != nil && !bytes.Contains(bc.text.Bytes(), []byte(ep.ExpectedBodyContains)) {
+        if bc.text.Len() == bc.text.max {
+            return fmt.Sprintf("response body does not contain %q in its first %d bytes", ep.ExpectedBodyContains, bc.text.max)
+        }
+        return fmt.Sprintf("response body does not contain %q", ep.ExpectedBodyContains)
+    }
+    if bc.cset != nil {
+        if msg := checkCharset(ep, res, resp.Header.Get("Content-Type"), bc.cset); msg != "" {
+            return msg
+        }
+    }
+    if bc.hash != nil {
+        sum := hex.EncodeToString(bc.hash.Sum(nil))
+        if bc.read != nil {
+            if msg := checkDigest(ep, res, sum, bc.read.n); msg != "" {
+                return msg
+            }
+        }
+        if detectsChange(ep) {
+            if msg := c.checkChange(ep, p, res, sum); msg != "" {
+                return msg
+            }
+        }
+    }
+    if bc.buf != nil {
+        return c.checkDynamicToken(ep, p, res, bc.buf.Bytes())
+    }
+    return ""
+}
+
+// validateDigest rejects an ExpectedSHA256 that is not a hex SHA-256.
+func validateDigest(ep Endpoint) error {
+    if ep.ExpectedSHA256 == "" {
+        return nil
+    }
+    if b, err := hex.DecodeString(ep.ExpectedSHA256); err != nil || len(b) != sha256.Size {
+        return fmt.Errorf("invalid expected_sha256 %q", ep.ExpectedSHA256)
+    }
+    return nil
+}
+
+// checkDigest records the body hash on res and fails when it differs from
+// ExpectedSHA256, or when the body reached the size cap and could not be
+// hashed whole.
+func checkDigest(ep Endpoint, res *Result, sum string, n int64) string {
+    res.BodyHash = sum
+    if n >= maxChangeBody {
+        return fmt.Sprintf("response body exceeds %d bytes, sha256 not verified", maxChangeBody)
+    }
+    if !strings.EqualFold(sum, ep.ExpectedSHA256) {
+        return "response body sha256 " + sum + ", expected " + strings.ToLower(ep.ExpectedSHA256)
+    }
+    return ""
+}
+
+// checkChange records the body hash on res and compares it with the
+// previous response of the same target (see checkTarget). It returns a
+// failure message when the body changed under DetectChange or did not
+// change under ExpectChange. The first response only sets the reference.
+func (c *Checker) checkChange(ep Endpoint, p NetworkPath, res *Result, sum string) string {
+    res.BodyHash = sum
+    c.mu.Lock()
+    prev, seen := swapTargetLocked(c.bodyHashes, ep, p, sum)
+    c.mu.Unlock()
+    if !seen {
+        return ""
+    }
+    res.Changed = prev != sum
+    switch {
+    case ep.DetectChange && res.Changed:
+        return "response body changed (sha256 " + prev + " -> " + sum + ")"
+    case ep.ExpectChange && !res.Changed:
+        return "response body did not change (sha256 " + sum + ")"
+    }
+    return ""
+}
+
+// checkDynamicToken captures the DynamicBodyRegex token (its first group,
+// else the whole match) and fails when it equals the previous check's of the
+// same target, i.e. a supposedly dynamic page is served stale.
+func (c *Checker) checkDynamicToken(ep Endpoint, p NetworkPath, res *Result, body []byte) string {
+    re, err := c.pattern(ep.DynamicBodyRegex)
+    if err != nil {
+        return err.Error()
+    }
+    m := re.FindSubmatch(body)
+    if m == nil {
+        return fmt.Sprintf("dynamic token %q not found in response body", ep.DynamicBodyRegex)
+    }
+    tok := m[0]
+    if len(m) > 1 {
+        tok = m[1]
+    }
+    res.DynamicToken = string(tok)
+
+    c.mu.Lock()
+    prev, seen := swapTargetLocked(c.dynamicTokens, ep, p, res.DynamicToken)
+    c.mu.Unlock()
+    if seen && prev == res.DynamicToken {
+        res.PreviousDynamicToken = prev
+        return fmt.Sprintf("dynamic token %q did not change since the previous check", prev)
+    }
+    return ""
+}
+
+// checkTarget names what one request of ep checks: its URL and, for a
+// multi-path endpoint, the network path. The sub-checks of a multi-URL or
+// multi-path endpoint each keep their own change-detection state.
+func checkTarget(ep Endpoint, p NetworkPath) string {
+    return p.Name + " " + ep.URL
+}
+
+// swapTargetLocked stores v for ep's target over p in m and returns the
+// previous value. c.mu must be held.
+func swapTargetLocked(m map[string]map[string]string, ep Endpoint, p NetworkPath, v string) (string, bool) {
+    byTarget := m[ep.ID]
+    if byTarget == nil {
+        byTarget = make(map[string]string)
+        m[ep.ID] = byTarget
+    }
+    target := checkTarget(ep, p)
+    prev, seen := byTarget[target]
+    byTarget[target] = v
+    return prev, seen
+}
@@ -0,0 +1,119 @@
+package uptime
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "mime"
+    "strings"
+    "unicode/utf8"
+
+    "golang.org/x/net/html/charset"
+    "golang.org/x/text/encoding"
+    "golang.org/x/text/transform"
+)
+
+// ===== Charset Validation =====
+
+// maxCharsetBody caps the response bytes decoded for ExpectedCharset.
+const maxCharsetBody = 1 << 20
+
+// validateCharset rejects an ExpectedCharset that is not a known encoding
+// label.
+func validateCharset(ep Endpoint) error {
+    if ep.ExpectedCharset == "" {
+        return nil
+    }
+    if e, _ := charset.Lookup(ep.ExpectedCharset); e == nil {
+        return fmt.Errorf("unknown expected charset %q", ep.ExpectedCharset)
+    }
+    return nil
+}
+
+// textMediaType reports whether a response of media type mt is text, the
+// only kind ExpectedCharset applies to.
+func textMediaType(mt string) bool {
+    switch {
+    case strings.HasPrefix(mt, "text/"), strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"):
+        return true
+    }
+    switch mt {
+    case "application/json", "application/xml", "application/javascript", "application/ecmascript":
+        return true
+    }
+    return false
+}
+
+// checkCharset records the declared and detected charsets of a text
+// response on res and fails when the declared one is missing or differs
+// from ExpectedCharset, or when body does not decode cleanly in it. JSON
+// without a charset parameter counts as UTF-8. Non-text responses are
+// skipped.
+func checkCharset(ep Endpoint, res *Result, contentType string, body *cappedBuffer) string {
+    mt, params, err := mime.ParseMediaType(contentType)
+    if err != nil || !textMediaType(mt) {
+        return ""
+    }
+    data := body.Bytes()
+    res.DeclaredCharset = strings.ToLower(params["charset"])
+    res.DetectedCharset = detectCharset(data)
+
+    want, wantName := charset.Lookup(ep.ExpectedCharset)
+    declared := res.DeclaredCharset
+    if declared == "" && jsonMediaType(mt) {
+        // JSON is UTF-8 unless it says otherwise (RFC 8259).
+        declared = "utf-8"
+    }
+    if declared == "" {
+        return fmt.Sprintf("response declares no charset, expected %s", wantName)
+    }
+    if _, name := charset.Lookup(declared); name != wantName {
+        return fmt.Sprintf("response charset %s, expected %s", declared, wantName)
+    }
+    // A capped body may end inside a character; only complete ones count.
+    truncated := body.Len() == body.max
+    dst := make([]byte, 3*len(data)+utf8.UTFMax)
+    n, nSrc, err := want.NewDecoder().Transform(dst, data, !truncated)
+    if (err != nil && !errors.Is(err, transform.ErrShortSrc)) || !decodesCleanly(want, data[:nSrc], dst[:n]) {
+        return fmt.Sprintf("response body is not valid %s", wantName)
+    }
+    return ""
+}
+
+// jsonMediaType reports whether mt is a JSON media type.
+func jsonMediaType(mt string) bool {
+    return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// decodesCleanly reports whether decoded, the output of decoding src in e,
+// has no replacement characters standing in for invalid bytes. The
+// decoders substitute U+FFFD rather than fail, so a decoded U+FFFD only
+// counts as valid when re-encoding gives back src, as a literal one does.
+func decodesCleanly(e encoding.Encoding, src, decoded []byte) bool {
+    if !bytes.ContainsRune(decoded, utf8.RuneError) {
+        return true
+    }
+    enc, err := e.NewEncoder().Bytes(decoded)
+    return err == nil && bytes.Equal(enc, src)
+}
+
+// detectCharset names the charset body appears to be in: "us-ascii" for
+// plain ASCII, "utf-8" for valid UTF-8, otherwise as sniffed from a byte
+// order mark or an HTML meta tag, falling back to "windows-1252".
+func detectCharset(body []byte) string {
+    ascii := true
+    for _, b := range body {
+        if b >= utf8.RuneSelf {
+            ascii = false
+            break
+        }
+    }
+    if ascii {
+        return "us-ascii"
+    }
+    if utf8.Valid(body) {
+        return "utf-8"
+    }
+    _, name, _ := charset.DetermineEncoding(body, "")
+    return name
+}
@@ -2,10 +2,17 @@
 package uptime
 
 import (
+    "context"
     "encoding/json"
+    "errors"
+    "fmt"
+    "maps"
+    "net"
     "net/http"
     "os"
+    "slices"
     "sync"
+    "sync/atomic"
     "time"
 
     "go.uber.org/zap"
@@ -16,8 +23,32 @@ type Checker struct {
     numWorkers int
     logLevel   LogLevel
     logRetention int
+    maxTotalLogs int // WithMaxTotalLogEntries; 0: unlimited
+    totalLogs    int // entries across all logs, guarded by mu
+    logHeads     logHeadHeap // oldest result per endpoint, with maxTotalLogs; guarded by mu
+    proxy        string // global proxy applied to endpoints without their own
+    defaultHeaders map[string]string
+    userAgents     []string      // WithUserAgentRotation
+    uaNext         atomic.Uint64 // next index into userAgents
+    duplicatePolicy DuplicatePolicy
+    urlNormalization *URLNormalization // nil: URLs are used as given
+    resolverAddrs    []string          // DNS servers for WithResolver; empty: system resolver
+    resolver         *net.Resolver     // built from resolverAddrs; nil: system resolver
+    sourceAddrs      []string          // local addresses for WithSourceAddresses, used round-robin
+    invalidSourceAddrs []string        // WithSourceAddresses entries that are not IP addresses
+    networkPaths     []NetworkPath     // paths of multi-path checks, see WithNetworkPaths
+    faultInjector    func(Endpoint) error // WithFaultInjection; nil: disabled
+    invalidNetworkPaths []string       // WithNetworkPaths entries that were dropped
+    nextSource       atomic.Uint32
+    scheduledUptimeOnly bool // uptime counts only OriginScheduled results
+    storage             Storage // WithStorage; nil: in-memory logs only
+    audit               *auditSink // WithTransitionAudit; nil: disabled
+    failureAction       *failureAction // WithFailureAction; nil: disabled
+    requestDecorator    func(*http.Request, Endpoint) // WithRequestDecorator; nil: none
+    idGenerator         func(Endpoint) string // WithIDGenerator; nil: DefaultID
 
     enableInternalLogs bool
+    internalLogLevel   LogLevel
     logger             *zap.Logger
     loggerExplicit     bool // set when WithLogger/WithZapLogger used
 
@@ -26,14 +57,97 @@ type Checker struct {
     logFilesOpt   []string
     logDisableOpt bool
 
-    jobs    chan Job
+    jobs    *jobQueue
     results chan Result
+    // resultsMu guards closing results against late senders such as on-demand checks
+    resultsMu     sync.RWMutex
+    resultsClosed bool
     wg      sync.WaitGroup
 
-    mu        sync.Mutex
-    endpoints []Endpoint
-    logs      map[string][]Result
-    stopCh    chan struct{}
+    mu          sync.RWMutex
+    endpoints   []Endpoint
+    logs        map[string][]Result
+    latency     map[string]*latencyStats // rolling latency of successful checks
+    sizes       map[string]*sizeStats // moving body size of successful checks, with SizeDeviation
+    baselines   map[string]*baselineState // learned latency baselines
+    bodyHashes  map[string]map[string]string // last response body hash per endpoint and checkTarget, for change detection
+    dynamicTokens map[string]map[string]string // last DynamicBodyRegex capture per endpoint and checkTarget
+    statuses    map[string]statusState // last known status, for transition tracking
+    incidents   map[string][]Incident // recorded incidents, oldest first
+    incidentSeq uint64 // numbers incident saves, see incidentSaveLocked
+    maintenance []maintenanceWindow // scheduled maintenance, pruned as windows expire
+    backoffs    map[string]*backoffState // consecutive timeouts, with WithTimeoutBackoff
+    flaps       map[string]*flapState // recent transitions, with WithAutoQuarantine
+    graces      map[string]*graceState // registration times, for AlertGracePeriod
+    scheduledAt map[string]time.Time // when each endpoint's ticker was started
+    tickBase    map[string]time.Time // time each endpoint's ticks are counted from
+    resumeAt    map[string]time.Time // next runs restored by LoadState, not yet scheduled
+    siteStop    map[string]chan struct{} // per-endpoint scheduler stop signals
+    started     bool // scheduling has begun
+    launched    bool // Start has been called
+    deferStart  bool // WithDeferredStart: scheduling waits for BeginScheduling
+    stopCh      chan struct{}
+    stopOnce    sync.Once // Stop and Shutdown
+    stopped     chan struct{} // closed once shutdown has closed Results
+
+    // checkCtx is the context of scheduled checks; Stop cancels it so
+    // in-flight checks end promptly with FailureCancelled.
+    checkCtx     context.Context
+    cancelChecks context.CancelFunc
+
+    transportMu sync.Mutex
+    transports  map[transportKey]*http.Transport
+
+    grpcTransports sync.Map // grpcTransportKey -> *http2.Transport for gRPC health checks
+
+    patterns sync.Map // compiled regexps keyed by expression
+    schemas  sync.Map // compiled JSON Schemas keyed by file path or inline content
+    busy     sync.Map // IDs of endpoints with a scheduled check queued or running
+
+    inFlight atomic.Int64
+
+    shedDepth      int // WithLoadShedding queue depth; 0: disabled
+    budget         *checkBudget // WithCheckBudget; nil: unlimited
+    backoff        *timeoutBackoff // WithTimeoutBackoff; nil: disabled
+    quarantine     *autoQuarantine // WithAutoQuarantine; nil: disabled
+    heartbeat      *heartbeat // WithHeartbeat; nil: disabled
+    dedupWindow    time.Duration // WithResultDedup; 0: disabled
+    retries        int // WithRetries default for endpoints without Retries
+    retryBackoff   time.Duration
+    shedding       atomic.Bool
+    shedCount      atomic.Int64
+    checksDone     atomic.Int64
+    droppedJobs    atomic.Int64
+    evictedLogs    atomic.Int64
+    dedupedResults atomic.Int64
+    droppedResults atomic.Int64
+
+    leaderCheck    func() bool // WithLeaderCheck; nil: always leader
+    leaderInterval time.Duration
+    leader         atomic.Bool
+
+    drops  chan dropEvent
+    onDrop func(ep Endpoint, reason string)
+
+    lastSuccess   map[string]bool // outcome of the latest result, for OnStateChange
+    stateChanges  chan stateChange
+    onStateChange func(ep Endpoint, from, to bool)
+
+    resultHooks []func(Result) // OnResult
+
+    incidentSaveMu sync.Mutex // serializes IncidentStorage saves
+    incidentSaved  map[string]uint64 // seq of the last incident save per endpoint
+
+    notifiers     []*notifierQueue // RegisterNotifier
+    notifyTimeout time.Duration
+    notifyCtx     context.Context // delivery context, cancelled when Shutdown gives up
+    cancelNotify  context.CancelFunc
+    escalation    []time.Duration // WithEscalation; nil: disabled
+    escMu         sync.Mutex
+    escalations   map[string]*escalationState // outages being escalated
+
+    subsMu sync.Mutex
+    subs   map[chan TransitionEvent]struct{} // SubscribeTransitions channels
 }
 
 // ===== Constructor =====
@@ -42,13 +156,41 @@ func New(opts ...Option) *Checker {
         httpClient: &http.Client{Timeout: 10 * time.Second},
         numWorkers: 50,
         logLevel:   LogInfo,
+        internalLogLevel: LogDebug,
         logRetention: 100,
-        jobs:       make(chan Job, 1000),
+        jobs:       newJobQueue(1000),
         results:    make(chan Result, 1000),
         stopCh:     make(chan struct{}),
+        stopped:    make(chan struct{}),
+        drops:      make(chan dropEvent, 100),
+        stateChanges: make(chan stateChange, 100),
+        notifyTimeout: defaultNotifyTimeout,
+        escalations: make(map[string]*escalationState),
+        lastSuccess: make(map[string]bool),
+        subs:       make(map[chan TransitionEvent]struct{}),
         logs:       make(map[string][]Result),
+        latency:    make(map[string]*latencyStats),
+        sizes:      make(map[string]*sizeStats),
+        baselines:  make(map[string]*baselineState),
+        bodyHashes: make(map[string]map[string]string),
+        dynamicTokens: make(map[string]map[string]string),
+        statuses:   make(map[string]statusState),
+        incidents:  make(map[string][]Incident),
+        incidentSaved: make(map[string]uint64),
+        backoffs:   make(map[string]*backoffState),
+        flaps:      make(map[string]*flapState),
+        graces:     make(map[string]*graceState),
+        scheduledAt: make(map[string]time.Time),
+        tickBase:    make(map[string]time.Time),
+        resumeAt:    make(map[string]time.Time),
+        siteStop:   make(map[string]chan struct{}),
+        transports: make(map[transportKey]*http.Transport),
         logger:     nil, // build after applying options
+        leaderInterval: defaultLeaderCheckInterval,
     }
+    c.leader.Store(true)
+    c.checkCtx, c.cancelChecks = context.WithCancel(context.Background())
+    c.notifyCtx, c.cancelNotify = context.WithCancel(context.Background())
     for _, opt := range opts {
         opt(c)
     }
@@ -60,6 +202,19 @@ func New(opts ...Option) *Checker {
     if c.logger == nil {
         c.logger = defaultConsoleLogger()
     }
+    if len(c.resolverAddrs) > 0 {
+        c.useResolver()
+    }
+    c.logInvalidSourceAddrs()
+    c.logInvalidNetworkPaths()
+    if c.faultInjector != nil {
+        // Not an internal log: it must be seen even with those disabled.
+        c.logger.Warn("fault injection enabled: checks may fail with synthetic errors",
+            zap.String("event", "fault_injection_enabled"))
+    }
+    if c.failureAction != nil {
+        c.addNotifier(actionNotifier{c}, true)
+    }
     return c
 }
 
@@ -116,72 +271,595 @@ func (c *Checker) buildLoggerFromConfig() *zap.Logger {
 }
 
 // ===== Public API =====
+
+// Start launches the worker pool and begins scheduling registered endpoints.
+// With WithDeferredStart scheduling waits for BeginScheduling.
 func (c *Checker) Start() {
+    c.StartContext(context.Background())
+}
+
+// StartContext is Start, with the checker stopped as by Stop when ctx is
+// done, e.g. on the application's shutdown signal.
+func (c *Checker) StartContext(ctx context.Context) {
+    if ctx.Done() != nil {
+        go func() {
+            select {
+            case <-ctx.Done():
+                c.Stop()
+            case <-c.stopCh:
+            }
+        }()
+    }
+    c.mu.Lock()
+    c.launched = true
+    c.mu.Unlock()
+    if c.leaderCheck != nil {
+        c.evaluateLeadership()
+        c.wg.Add(1)
+        go c.leaderLoop()
+    }
     for i := 0; i < c.numWorkers; i++ {
         c.wg.Add(1)
         go c.worker(i)
-        c.ilog("Started worker %d", i)
+        c.ilog(LogInfo, "worker_started", zap.Int("worker", i))
+    }
+    c.wg.Add(1)
+    go c.dropDispatcher()
+    c.wg.Add(1)
+    go c.stateChangeDispatcher()
+    if c.budget != nil {
+        c.wg.Add(1)
+        go c.budgetLoop()
+    }
+    if c.heartbeat != nil {
+        c.wg.Add(1)
+        go c.heartbeatLoop()
+    }
+    if c.deferStart {
+        c.ilog(LogInfo, "scheduler_deferred")
+        return
+    }
+    c.BeginScheduling()
+}
+
+// BeginScheduling starts scheduling every registered endpoint; endpoints
+// added later are scheduled as they are registered. Start calls it unless
+// WithDeferredStart is set. It does nothing before Start, after Stop, or
+// once scheduling has begun.
+func (c *Checker) BeginScheduling() {
+    c.mu.Lock()
+    if !c.launched || c.started || !c.isRunning() {
+        c.mu.Unlock()
+        return
     }
+    c.started = true
+    c.mu.Unlock()
     c.wg.Add(1)
     go c.scheduler()
-    c.ilog("Scheduler started")
+    c.ilog(LogInfo, "scheduler_started")
 }
 
+// Stop stops scheduling and cancels in-flight checks, which are recorded
+// with FailureCancelled, then closes the Results channel. It is Shutdown
+// without a grace period, except that it returns only once Results is
+// closed; calling it again does nothing.
 func (c *Checker) Stop() {
-    // Signal all goroutines to stop, then close jobs to unblock workers
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    _ = c.Shutdown(ctx)
+    <-c.stopped
+}
+
+// Done returns a channel that is closed once the checker has shut down and
+// closed Results, after the last result hook has run.
+func (c *Checker) Done() <-chan struct{} {
+    return c.stopped
+}
+
+// Shutdown stops scheduling, discards queued checks and waits for in-flight
+// checks, notifications and failure actions to finish, then closes the
+// Results channel. If ctx is done first, the remaining checks are cancelled
+// and ctx's error is returned at once; the cancelled checks are still
+// recorded with FailureCancelled, and Results is closed once they and any
+// failure action ignoring its context have returned. Only the first call of
+// Shutdown or Stop shuts the checker down; later ones return nil.
+func (c *Checker) Shutdown(ctx context.Context) error {
+    var err error
+    c.stopOnce.Do(func() { err = c.shutdown(ctx) })
+    return err
+}
+
+func (c *Checker) shutdown(ctx context.Context) error {
+    // Signal all goroutines to stop, then close jobs to unblock workers.
+    // Closing under c.mu keeps BeginScheduling and RegisterNotifier from
+    // adding to c.wg once it is waited on.
+    c.mu.Lock()
     close(c.stopCh)
-    close(c.jobs)
-    c.wg.Wait()
-    close(c.results)
-    c.ilog("Checker stopped")
+    c.mu.Unlock()
+    c.cancelEscalations("")
+    c.jobs.close()
+    done := make(chan struct{})
+    go func() {
+        c.wg.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        // Cancelled checks end promptly and are still recorded; queued
+        // notifications are discarded
+        c.cancelChecks()
+        c.cancelNotify()
+        select {
+        case <-done:
+        default:
+            go c.finishShutdown(done)
+            return ctx.Err()
+        }
+    }
+    c.finishShutdown(done)
+    return nil
 }
 
-// AddSite (requires caller to supply ID)
-func (c *Checker) AddSite(ep Endpoint) {
-    if ep.Frequency == 0 {
-        ep.Frequency = 30 * time.Second
+// finishShutdown closes Results and the audit log once done is closed, that
+// is once every goroutine of c.wg has returned.
+func (c *Checker) finishShutdown(done <-chan struct{}) {
+    <-done
+    c.cancelChecks()
+    c.cancelNotify()
+    c.resultsMu.Lock()
+    c.resultsClosed = true
+    close(c.results)
+    c.resultsMu.Unlock()
+    if c.audit != nil {
+        c.audit.close()
     }
-    if ep.ExpectedStatus == 0 {
-        ep.ExpectedStatus = 200
+    c.ilog(LogInfo, "checker_stopped")
+    close(c.stopped)
+}
+
+// AddSite registers ep and returns it as registered, with defaults applied
+// and, for an endpoint without an ID, the ID from the ID generator (see
+// WithIDGenerator). It returns an error when the endpoint configuration is
+// invalid or, under DuplicateReject (the default), when an endpoint with
+// the same ID is already registered; the endpoint is not registered then.
+// Under DuplicateReplace the existing endpoint is replaced and rescheduled.
+// An endpoint with Vars registers every endpoint it expands to (see
+// ExpandTemplates) and returns the first of them.
+func (c *Checker) AddSite(ep Endpoint) (Endpoint, error) {
+    if len(ep.Vars) > 0 {
+        added, err := c.AddSitesBulk([]Endpoint{ep})
+        if len(added) == 0 {
+            return Endpoint{}, err
+        }
+        return added[0], err
     }
-    if ep.Method == "" {
-        ep.Method = "GET"
+    if err := c.prepareEndpoint(&ep); err != nil {
+        return Endpoint{}, err
     }
     c.mu.Lock()
-    c.endpoints = append(c.endpoints, ep)
+    replaced, err := c.registerLocked(ep)
+    schedule := c.started
     c.mu.Unlock()
+    if err != nil {
+        return Endpoint{}, err
+    }
 
-    c.ilog("Registered site: %s (%s)", ep.Name, ep.URL)
+    c.ilog(LogInfo, "site_registered", endpointFields(ep, zap.String("url", ep.URL), zap.Strings("replaced", replaced))...)
+    c.restoreLogs([]Endpoint{ep})
 
-    if c.isRunning() {
+    for _, id := range replaced {
+        c.unscheduleEndpoint(id)
+    }
+    if schedule && c.isRunning() {
         c.scheduleEndpoint(ep)
     }
+    return ep, nil
 }
 
-// AddSitesBulk
-func (c *Checker) AddSitesBulk(sites []Endpoint) {
+// RemoveSite stops checking the endpoint and forgets it, returning false
+// when no endpoint with that ID is registered. It is safe to call while the
+// checker runs: its scheduler goroutine is stopped, and the results of
+// checks still queued or in flight are discarded. An ongoing incident is
+// ended at removal; the endpoint's logs, incidents and latency statistics
+// stay available until ClearLogs(id).
+func (c *Checker) RemoveSite(id string) bool {
     c.mu.Lock()
-    c.endpoints = append(c.endpoints, sites...)
+    i := slices.IndexFunc(c.endpoints, func(ep Endpoint) bool { return ep.ID == id })
+    if i < 0 {
+        c.mu.Unlock()
+        return false
+    }
+    ep := c.endpoints[i]
+    c.endpoints = slices.Delete(c.endpoints, i, i+1)
+    delete(c.statuses, id)
+    delete(c.backoffs, id)
+    delete(c.flaps, id)
+    delete(c.graces, id)
+    delete(c.lastSuccess, id)
+    delete(c.baselines, id)
+    delete(c.bodyHashes, id)
+    delete(c.dynamicTokens, id)
+    delete(c.resumeAt, id)
+    save, ended := c.endIncidentLocked(id, time.Now())
     c.mu.Unlock()
+    if ended {
+        c.saveIncidents(save)
+    }
+    if a := c.failureAction; a != nil {
+        a.mu.Lock()
+        delete(a.last, id)
+        a.mu.Unlock()
+    }
 
-    c.ilog("Registered %d sites", len(sites))
+    c.unscheduleEndpoint(id)
+    c.cancelEscalations(id)
+    c.ilog(LogInfo, "site_removed", endpointFields(ep)...)
+    return true
+}
 
-    if c.isRunning() {
-        for _, ep := range sites {
-            if ep.Method == "" {
-                ep.Method = "GET"
-            }
-            if ep.Frequency == 0 {
-                ep.Frequency = 30 * time.Second
+// UpdateSite replaces the registered endpoint with ep's ID, applying the
+// same defaults and validation as AddSite. It returns ErrUnknownEndpoint
+// when no endpoint has that ID, rather than adding one. While the checker
+// runs, the endpoint is rescheduled so a new Frequency takes effect at
+// once; a check already in flight finishes with the old configuration.
+// Status and logs are kept; learned baselines and change-detection state
+// are reset when the method or URL changes.
+func (c *Checker) UpdateSite(ep Endpoint) error {
+    if ep.ID == "" {
+        return errors.New("missing id")
+    }
+    if len(ep.Vars) > 0 {
+        return errors.New("vars is not supported when updating an endpoint")
+    }
+    if err := c.prepareEndpoint(&ep); err != nil {
+        return err
+    }
+    c.mu.Lock()
+    i := slices.IndexFunc(c.endpoints, func(cur Endpoint) bool { return cur.ID == ep.ID })
+    if i < 0 {
+        c.mu.Unlock()
+        return fmt.Errorf("%w: %q", ErrUnknownEndpoint, ep.ID)
+    }
+    if c.urlNormalization != nil {
+        for _, cur := range c.endpoints {
+            if cur.ID != ep.ID && ep.URL != "" && cur.Method == ep.Method && cur.URL == ep.URL {
+                c.mu.Unlock()
+                return fmt.Errorf("%w: %s %s already registered as %q", ErrDuplicateURL, ep.Method, ep.URL, cur.ID)
             }
-            if ep.ExpectedStatus == 0 {
-                ep.ExpectedStatus = 200
+        }
+    }
+    prev := c.endpoints[i]
+    c.endpoints[i] = ep
+    if prev.Method != ep.Method || prev.URL != ep.URL {
+        delete(c.baselines, ep.ID)
+        delete(c.bodyHashes, ep.ID)
+        delete(c.dynamicTokens, ep.ID)
+    }
+    c.trimLogsLocked(ep.ID, c.retentionFor(ep))
+    schedule := c.started
+    c.mu.Unlock()
+
+    c.ilog(LogInfo, "site_updated", endpointFields(ep, zap.String("url", ep.URL), zap.Duration("frequency", ep.Frequency))...)
+    c.unscheduleEndpoint(ep.ID)
+    if schedule && c.isRunning() {
+        c.scheduleEndpoint(ep)
+    }
+    return nil
+}
+
+// AddSitesBulk registers every valid endpoint in sites and returns the
+// registered endpoints as AddSite does. Invalid endpoints and rejected
+// duplicates are skipped and reported together in the returned error.
+func (c *Checker) AddSitesBulk(sites []Endpoint) ([]Endpoint, error) {
+    valid, index, errs := c.prepareSites(sites)
+
+    var added []Endpoint
+    var replaced []string
+    c.mu.Lock()
+    for i, ep := range valid {
+        ids, err := c.registerLocked(ep)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("site %d (%s): %w", index[i], ep.ID, err))
+            continue
+        }
+        replaced = append(replaced, ids...)
+        added = append(added, ep)
+    }
+    schedule := c.started
+    c.mu.Unlock()
+
+    c.activateSites(added, replaced, schedule)
+    return added, errors.Join(errs...)
+}
+
+// AddSitesBulkChecked registers sites all-or-nothing and returns the
+// registered endpoints as AddSite does: when any endpoint is invalid or a
+// rejected duplicate, of a registered endpoint or of another one in sites,
+// none is registered and the returned error reports each of them.
+func (c *Checker) AddSitesBulkChecked(sites []Endpoint) ([]Endpoint, error) {
+    valid, index, errs := c.prepareSites(sites)
+    if len(errs) > 0 {
+        return nil, errors.Join(errs...)
+    }
+
+    var replaced []string
+    c.mu.Lock()
+    before, graces := slices.Clone(c.endpoints), maps.Clone(c.graces)
+    for i, ep := range valid {
+        ids, err := c.registerLocked(ep)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("site %d (%s): %w", index[i], ep.ID, err))
+            continue
+        }
+        replaced = append(replaced, ids...)
+    }
+    if len(errs) > 0 {
+        c.endpoints, c.graces = before, graces
+        c.mu.Unlock()
+        c.ilog(LogInfo, "sites_rejected", zap.Int("count", len(valid)), zap.Int("invalid", len(errs)))
+        return nil, errors.Join(errs...)
+    }
+    schedule := c.started
+    c.mu.Unlock()
+
+    c.activateSites(valid, replaced, schedule)
+    return valid, nil
+}
+
+// prepareSites expands templates and applies defaults to sites, returning
+// the valid endpoints, the index in sites each comes from, and an error for
+// each invalid one.
+func (c *Checker) prepareSites(sites []Endpoint) (valid []Endpoint, index []int, errs []error) {
+    valid = make([]Endpoint, 0, len(sites))
+    for i, site := range sites {
+        eps, err := ExpandTemplates([]Endpoint{site})
+        if err != nil {
+            errs = append(errs, fmt.Errorf("site %d (%s): %w", i, site.ID, err))
+            continue
+        }
+        for _, ep := range eps {
+            if err := c.prepareEndpoint(&ep); err != nil {
+                errs = append(errs, fmt.Errorf("site %d (%s): %w", i, ep.ID, err))
+                continue
             }
+            valid = append(valid, ep)
+            index = append(index, i)
+        }
+    }
+    return valid, index, errs
+}
+
+// activateSites restores the logs of newly registered endpoints and
+// schedules them, unscheduling the endpoints they replaced.
+func (c *Checker) activateSites(added []Endpoint, replaced []string, schedule bool) {
+    c.ilog(LogInfo, "sites_registered", zap.Int("count", len(added)), zap.Int("replaced", len(replaced)))
+    c.restoreLogs(added)
+
+    for _, id := range replaced {
+        c.unscheduleEndpoint(id)
+    }
+    if schedule && c.isRunning() {
+        for _, ep := range added {
             c.scheduleEndpoint(ep)
         }
     }
 }
 
+// prepareEndpoint applies defaults and URL normalization to ep and validates
+// the result.
+func (c *Checker) prepareEndpoint(ep *Endpoint) error {
+    applyDefaults(ep)
+    if c.urlNormalization != nil {
+        if err := normalizeEndpointURL(ep, *c.urlNormalization); err != nil {
+            return err
+        }
+    }
+    c.assignID(ep)
+    return c.validateEndpoint(*ep)
+}
+
+// registerLocked adds ep, or handles a clash with an already-registered
+// endpoint according to the duplicate policy: same ID, or — with URL
+// normalization enabled — same method and normalized URL. Under
+// DuplicateReplace ep takes the place of the endpoint with its ID, or else
+// of the one with its URL, and every clashing endpoint is dropped; their IDs
+// are returned. c.mu must be held.
+func (c *Checker) registerLocked(ep Endpoint) (replaced []string, err error) {
+    byID, byURL := -1, -1
+    for i, cur := range c.endpoints {
+        if cur.ID == ep.ID {
+            byID = i
+        } else if c.urlNormalization != nil && ep.URL != "" && cur.Method == ep.Method && cur.URL == ep.URL {
+            byURL = i
+        }
+    }
+    if byID < 0 && byURL < 0 {
+        c.endpoints = append(c.endpoints, ep)
+        c.graces[ep.ID] = &graceState{added: time.Now()}
+        return nil, nil
+    }
+    if c.duplicatePolicy != DuplicateReplace {
+        if byID >= 0 {
+            return nil, fmt.Errorf("%w: %q", ErrDuplicateID, ep.ID)
+        }
+        cur := c.endpoints[byURL]
+        return nil, fmt.Errorf("%w: %s %s already registered as %q", ErrDuplicateURL, ep.Method, ep.URL, cur.ID)
+    }
+    at := byID
+    if at < 0 {
+        at = byURL
+    }
+    for _, i := range []int{byID, byURL} {
+        if i >= 0 {
+            replaced = append(replaced, c.endpoints[i].ID)
+            delete(c.graces, c.endpoints[i].ID)
+        }
+    }
+    c.endpoints[at] = ep
+    if byID >= 0 && byURL >= 0 {
+        c.endpoints = slices.Delete(c.endpoints, byURL, byURL+1)
+    }
+    c.graces[ep.ID] = &graceState{added: time.Now()}
+    return replaced, nil
+}
+
+func applyDefaults(ep *Endpoint) {
+    if ep.Frequency == 0 {
+        ep.Frequency = 30 * time.Second
+    }
+    if ep.ExpectedStatus == 0 && !isPreflight(*ep) && len(ep.AcceptableStatusCodes) == 0 {
+        ep.ExpectedStatus = 200
+        if ep.ExpectUnreachable {
+            ep.ExpectedStatus = http.StatusForbidden
+        }
+    }
+    if ep.Method == "" {
+        ep.Method = "GET"
+    }
+    if ep.FailureThreshold == 0 {
+        ep.FailureThreshold = 1
+    }
+    if ep.BaselineFactor > 0 && ep.BaselineSamples == 0 {
+        ep.BaselineSamples = 10
+    }
+    if ep.SizeDeviation > 0 && ep.SizeSamples == 0 {
+        ep.SizeSamples = 10
+    }
+    if len(ep.URLs) > 0 {
+        if ep.Quorum == 0 {
+            ep.Quorum = len(ep.URLs)
+        }
+        if ep.OutlierFactor == 0 {
+            ep.OutlierFactor = defaultOutlierFactor
+        }
+    }
+}
+
+// validateEndpoint reports configuration errors that would make every check
+// of ep fail.
+func (c *Checker) validateEndpoint(ep Endpoint) error {
+    if ep.URL == "" && len(ep.URLs) == 0 {
+        return errors.New("missing url")
+    }
+    if ep.Frequency < 0 {
+        return fmt.Errorf("invalid frequency %v", ep.Frequency)
+    }
+    if ep.Retention < 0 {
+        return fmt.Errorf("invalid retention %d", ep.Retention)
+    }
+    for _, code := range ep.AcceptableStatusCodes {
+        if code < 100 || code > 599 {
+            return fmt.Errorf("invalid acceptable status code %d", code)
+        }
+    }
+    if err := validateCharset(ep); err != nil {
+        return err
+    }
+    if ep.Retries < 0 || ep.RetryBackoff < 0 {
+        return fmt.Errorf("invalid retries %d with backoff %v", ep.Retries, ep.RetryBackoff)
+    }
+    if ep.AlertGracePeriod < 0 {
+        return fmt.Errorf("invalid alert grace period %v", ep.AlertGracePeriod)
+    }
+    switch ep.Type {
+    case "", TypeHTTP:
+    case TypeGRPC:
+        if len(ep.URLs) > 0 {
+            return errors.New("urls is not supported for grpc endpoints")
+        }
+    case TypeTLS:
+        if len(ep.URLs) > 0 {
+            return errors.New("urls is not supported for tls endpoints")
+        }
+        if _, _, err := tlsAddr(ep.URL); err != nil {
+            return err
+        }
+    default:
+        return fmt.Errorf("unknown endpoint type %q", ep.Type)
+    }
+    if proxy := c.effectiveProxy(ep); proxy != "" {
+        if _, err := parseProxyURL(proxy); err != nil {
+            return err
+        }
+    }
+    if ep.Body != "" && ep.BodyFile != "" {
+        return errors.New("body and body_file are mutually exclusive")
+    }
+    if ep.BodyFile != "" {
+        info, err := os.Stat(ep.BodyFile)
+        if err != nil {
+            return fmt.Errorf("body file: %w", err)
+        }
+        if info.IsDir() {
+            return fmt.Errorf("body file %q is a directory", ep.BodyFile)
+        }
+    }
+    if ep.ForwardedFor != "" {
+        if err := validForwardedFor(ep.ForwardedFor); err != nil {
+            return err
+        }
+    }
+    if ep.FailureThreshold < 0 {
+        return fmt.Errorf("invalid failure threshold %d", ep.FailureThreshold)
+    }
+    keep := c.retentionFor(ep)
+    if ep.FailureThreshold > keep {
+        return fmt.Errorf("failure threshold %d exceeds log retention %d", ep.FailureThreshold, keep)
+    }
+    for kind, n := range ep.FailureThresholds {
+        if n < 1 {
+            return fmt.Errorf("invalid failure threshold %d for %q", n, kind)
+        }
+        if n > keep {
+            return fmt.Errorf("failure threshold %d for %q exceeds log retention %d", n, kind, keep)
+        }
+    }
+    if ep.DetectChange && ep.ExpectChange {
+        return errors.New("detect_change and expect_change are mutually exclusive")
+    }
+    if len(ep.URLs) > 0 && (ep.Quorum < 1 || ep.Quorum > len(ep.URLs)) {
+        return fmt.Errorf("quorum %d out of range for %d urls", ep.Quorum, len(ep.URLs))
+    }
+    if !isPreflight(ep) && (len(ep.CORSRequestHeaders) > 0 || ep.CORSAllowCredentials) {
+        return errors.New("cors_request_headers and cors_allow_credentials need cors_origin")
+    }
+    if err := validateDigest(ep); err != nil {
+        return err
+    }
+    if err := validateHMAC(ep); err != nil {
+        return err
+    }
+    if err := validateCookieAssertions(ep); err != nil {
+        return err
+    }
+    if err := c.validateMultiPath(ep); err != nil {
+        return err
+    }
+    if ep.RequireHTTPSRedirect && expectsRedirect(ep) {
+        return errors.New("require_https_redirect and expected_location are mutually exclusive")
+    }
+    if ep.ExpectedLocationRegex != "" {
+        if _, err := c.pattern(ep.ExpectedLocationRegex); err != nil {
+            return err
+        }
+    }
+    if ep.DynamicBodyRegex != "" {
+        if _, err := c.pattern(ep.DynamicBodyRegex); err != nil {
+            return err
+        }
+    }
+    if hasSchema(ep) {
+        if _, err := c.schema(ep); err != nil {
+            return err
+        }
+    }
+    if err := validateJSONAssertions(ep); err != nil {
+        return err
+    }
+    return nil
+}
+
 // LoadFromFile
 func (c *Checker) LoadFromFile(filePath string) error {
     data, err := os.ReadFile(filePath)
@@ -193,33 +871,108 @@ func (c *Checker) LoadFromFile(filePath string) error {
         return err
     }
     for i := range eps {
-        eps[i].Frequency *= time.Second
-        if eps[i].ExpectedStatus == 0 {
-            eps[i].ExpectedStatus = 200
-        }
+        fromFileUnits(&eps[i])
     }
-    c.ilog("Loaded %d sites from file: %s", len(eps), filePath)
-    c.AddSitesBulk(eps)
-    return nil
+    c.ilog(LogInfo, "sites_loaded", zap.Int("count", len(eps)), zap.String("file", filePath))
+    _, err = c.AddSitesBulk(eps)
+    return err
 }
 
 // Results channel
 func (c *Checker) Results() <-chan Result { return c.results }
 
-// GetLogs returns last N results
+// GetLogs returns a copy of the last limit results of the endpoint, or nil
+// when it has none.
 func (c *Checker) GetLogs(id string, limit int) []Result {
-    c.mu.Lock()
-    defer c.mu.Unlock()
+    c.mu.RLock()
+    defer c.mu.RUnlock()
     logs := c.logs[id]
     if len(logs) > limit {
-        return logs[len(logs)-limit:]
+        logs = logs[len(logs)-limit:]
+    }
+    if len(logs) == 0 {
+        return nil
     }
-    return logs
+    return append([]Result(nil), logs...)
+}
+
+// GetAllLogs returns the last limit results of every registered endpoint,
+// keyed by endpoint ID, in a single locked pass. The slices are copies.
+func (c *Checker) GetAllLogs(limit int) map[string][]Result {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    out := make(map[string][]Result, len(c.endpoints))
+    for _, ep := range c.endpoints {
+        logs := c.logs[ep.ID]
+        if len(logs) > limit {
+            logs = logs[len(logs)-limit:]
+        }
+        out[ep.ID] = append([]Result(nil), logs...)
+    }
+    return out
 }
 
 // ListSites returns all registered sites
 func (c *Checker) ListSites() []Endpoint {
-    c.mu.Lock()
-    defer c.mu.Unlock()
+    c.mu.RLock()
+    defer c.mu.RUnlock()
     return append([]Endpoint(nil), c.endpoints...)
 }
+
+// OverdueSites returns endpoints whose last check (or, if never checked, the
+// start of their schedule) is older than the effective frequency (see
+// EffectiveFrequency) plus threshold. A non-empty
+// result means checks are firing late, usually because the worker pool is
+// too small for the configured endpoints. A passive instance (see
+// WithLeaderCheck) has no overdue endpoints.
+func (c *Checker) OverdueSites(threshold time.Duration) []Endpoint {
+    if !c.IsLeader() {
+        return nil
+    }
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.overdueLocked(time.Now(), func(Endpoint) time.Duration { return threshold })
+}
+
+// Healthy reports whether the checker is running and keeping up with its
+// schedule: it has been started, not stopped, and no endpoint has missed a
+// whole extra interval (see OverdueSites).
+func (c *Checker) Healthy() bool {
+    if !c.isRunning() {
+        return false
+    }
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    if !c.started {
+        return false
+    }
+    if !c.IsLeader() {
+        return true // idle by design
+    }
+    return len(c.overdueLocked(time.Now(), c.effectiveFrequencyLocked)) == 0
+}
+
+// overdueLocked returns the scheduled endpoints whose last check is longer
+// ago than their effective frequency (see effectiveFrequencyLocked) plus
+// threshold. A check deferred by WithCheckBudget may wait one more budget
+// window. c.mu must be held.
+func (c *Checker) overdueLocked(now time.Time, threshold func(Endpoint) time.Duration) []Endpoint {
+    var out []Endpoint
+    for _, ep := range c.endpoints {
+        last, ok := c.scheduledAt[ep.ID]
+        if !ok {
+            continue // not scheduled yet
+        }
+        if logs := c.logs[ep.ID]; len(logs) > 0 && logs[len(logs)-1].Timestamp.After(last) {
+            last = logs[len(logs)-1].Timestamp
+        }
+        allowed := c.effectiveFrequencyLocked(ep) + threshold(ep)
+        if c.budget != nil && c.budget.pending(ep.ID) {
+            allowed += c.budget.per
+        }
+        if now.Sub(last) > allowed {
+            out = append(out, ep)
+        }
+    }
+    return out
+}
@@ -0,0 +1,77 @@
+package uptime
+
+import (
+    "bufio"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// ===== Response Compression =====
+
+// decodeBody returns the content coding of resp and, for gzip or deflate
+// bodies the transport left encoded (an Accept-Encoding header was set
+// explicitly), makes resp.Body decode them so body assertions see the
+// content. Other codings, such as br, are left as received.
+func decodeBody(resp *http.Response) string {
+    if resp.Uncompressed {
+        return "gzip" // requested and decoded by the transport
+    }
+    enc := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+    switch enc {
+    case "gzip", "x-gzip":
+        resp.Body = &lazyDecoder{src: resp.Body, open: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }}
+    case "deflate":
+        resp.Body = &lazyDecoder{src: resp.Body, open: openDeflate}
+    }
+    return enc
+}
+
+// openDeflate decodes an HTTP deflate body, which is zlib-wrapped (RFC
+// 9110), falling back to raw DEFLATE for servers that omit the zlib header.
+func openDeflate(r io.Reader) (io.Reader, error) {
+    br := bufio.NewReader(r)
+    head, err := br.Peek(2)
+    if err == nil && head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+        return zlib.NewReader(br)
+    }
+    return flate.NewReader(br), nil
+}
+
+// lazyDecoder defers creating the decompressor until the body is read, so
+// responses without body assertions aren't decoded.
+type lazyDecoder struct {
+    src  io.ReadCloser
+    open func(io.Reader) (io.Reader, error)
+    r    io.Reader
+    err  error
+}
+
+func (d *lazyDecoder) Read(p []byte) (int, error) {
+    if d.r == nil && d.err == nil {
+        d.r, d.err = d.open(d.src)
+    }
+    if d.err != nil {
+        return 0, d.err
+    }
+    return d.r.Read(p)
+}
+
+func (d *lazyDecoder) Close() error { return d.src.Close() }
+
+// checkCompressed fails a response that was not compressed although ep
+// expects it.
+func checkCompressed(ep Endpoint, encoding string) string {
+    if !ep.ExpectCompressed || encoding != "" && encoding != "identity" {
+        return ""
+    }
+    accept := ep.AcceptEncoding
+    if accept == "" {
+        accept = "gzip"
+    }
+    return fmt.Sprintf("expected a compressed response (Accept-Encoding %q), got no Content-Encoding", accept)
+}
@@ -0,0 +1,316 @@
+package uptime
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// ===== Config Export / Import =====
+
+// Config is the serializable configuration of a Checker: its plain-value
+// options and endpoints, as written by ExportConfig and read by
+// NewFromConfig. Endpoints use the units of endpoint files (frequency in
+// seconds, baseline latency in milliseconds); other durations are Go
+// duration strings such as "30s".
+type Config struct {
+    Workers             int               `json:"workers,omitempty"`
+    Timeout             Duration          `json:"timeout,omitempty"`
+    LogLevel            string            `json:"log_level,omitempty"` // "none", "error", "info" or "debug"
+    LogRetention        int               `json:"log_retention,omitempty"`
+    MaxTotalLogEntries  int               `json:"max_total_log_entries,omitempty"`
+    QueueSize           int               `json:"queue_size,omitempty"`
+    ResultBuffer        int               `json:"result_buffer,omitempty"`
+    PriorityAging       Duration          `json:"priority_aging,omitempty"`
+    Proxy               string            `json:"proxy,omitempty"`
+    DefaultHeaders      map[string]string `json:"default_headers,omitempty"`
+    UserAgents          []string          `json:"user_agents,omitempty"`
+    Resolvers           []string          `json:"resolvers,omitempty"`
+    SourceAddresses     []string          `json:"source_addresses,omitempty"`
+    NetworkPaths        []NetworkPath     `json:"network_paths,omitempty"`
+    ScheduledUptimeOnly bool              `json:"scheduled_uptime_only,omitempty"`
+    LoadShedding        int               `json:"load_shedding,omitempty"` // WithLoadShedding queue depth
+    CheckBudget         *BudgetConfig     `json:"check_budget,omitempty"`
+    TimeoutBackoff      *BackoffConfig    `json:"timeout_backoff,omitempty"`
+    ResultDedup         Duration          `json:"result_dedup,omitempty"`
+    AutoQuarantine      *QuarantineConfig `json:"auto_quarantine,omitempty"`
+    Retries             *RetryConfig      `json:"retries,omitempty"`
+    Endpoints           []Endpoint        `json:"endpoints,omitempty"`
+}
+
+// BudgetConfig holds the WithCheckBudget settings.
+type BudgetConfig struct {
+    Limit int      `json:"limit"`
+    Per   Duration `json:"per"`
+}
+
+// BackoffConfig holds the WithTimeoutBackoff settings.
+type BackoffConfig struct {
+    After int      `json:"after"`
+    Max   Duration `json:"max,omitempty"`
+}
+
+// QuarantineConfig holds the WithAutoQuarantine settings.
+type QuarantineConfig struct {
+    Flaps  int      `json:"flaps"`
+    Window Duration `json:"window"`
+    Stable Duration `json:"stable"`
+}
+
+// RetryConfig holds the WithRetries settings.
+type RetryConfig struct {
+    Count   int      `json:"count"`
+    Backoff Duration `json:"backoff,omitempty"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("1m30s").
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) { return []byte(time.Duration(d).String()), nil }
+
+func (d *Duration) UnmarshalText(b []byte) error {
+    v, err := time.ParseDuration(string(b))
+    if err != nil {
+        return err
+    }
+    *d = Duration(v)
+    return nil
+}
+
+// redacted replaces secret values in exported configs.
+const redacted = "REDACTED"
+
+var logLevelNames = map[LogLevel]string{LogNone: "none", LogError: "error", LogInfo: "info", LogDebug: "debug"}
+
+// ExportConfig serializes the checker's options and endpoints as "json" or
+// "yaml", in a form NewFromConfig accepts, e.g. to manage monitors in git.
+// Secrets are redacted: credentials in proxy URLs and the values of
+// authentication headers (Authorization, Cookie, API keys and tokens).
+// Options holding code or external resources (logger, storage, hooks,
+// signers) are not exported. Endpoint durations use the endpoint-file units,
+// so it fails like SaveToFile when one is not a whole number of its unit.
+func (c *Checker) ExportConfig(format string) ([]byte, error) {
+    cfg := Config{
+        Workers:             c.numWorkers,
+        Timeout:             Duration(c.httpClient.Timeout),
+        LogLevel:            logLevelNames[c.logLevel],
+        LogRetention:        c.logRetention,
+        MaxTotalLogEntries:  c.maxTotalLogs,
+        QueueSize:           c.jobs.cap,
+        ResultBuffer:        cap(c.results),
+        PriorityAging:       Duration(c.jobs.aging),
+        Proxy:               redactURL(c.proxy),
+        DefaultHeaders:      redactHeaders(c.defaultHeaders),
+        UserAgents:          c.userAgents,
+        Resolvers:           c.resolverAddrs,
+        SourceAddresses:     c.sourceAddrs,
+        NetworkPaths:        c.networkPaths,
+        ScheduledUptimeOnly: c.scheduledUptimeOnly,
+        LoadShedding:        c.shedDepth,
+        ResultDedup:         Duration(c.dedupWindow),
+    }
+    if c.budget != nil {
+        cfg.CheckBudget = &BudgetConfig{Limit: c.budget.limit, Per: Duration(c.budget.per)}
+    }
+    if c.backoff != nil {
+        cfg.TimeoutBackoff = &BackoffConfig{After: c.backoff.after, Max: Duration(c.backoff.max)}
+    }
+    if q := c.quarantine; q != nil {
+        cfg.AutoQuarantine = &QuarantineConfig{Flaps: q.flaps, Window: Duration(q.window), Stable: Duration(q.stable)}
+    }
+    if c.retries > 0 {
+        cfg.Retries = &RetryConfig{Count: c.retries, Backoff: Duration(c.retryBackoff)}
+    }
+    cfg.Endpoints = c.ListSites()
+    for i := range cfg.Endpoints {
+        ep := &cfg.Endpoints[i]
+        if err := toFileUnits(ep); err != nil {
+            return nil, err
+        }
+        ep.Proxy = redactURL(ep.Proxy)
+        ep.Headers = redactHeaders(ep.Headers)
+    }
+
+    data, err := json.Marshal(cfg)
+    if err != nil {
+        return nil, err
+    }
+    switch format {
+    case "json":
+        var out bytes.Buffer
+        if err := json.Indent(&out, data, "", "  "); err != nil {
+            return nil, err
+        }
+        out.WriteByte('\n')
+        return out.Bytes(), nil
+    case "yaml":
+        // Go through the JSON form so YAML keys match the JSON field names.
+        var v any
+        if err := json.Unmarshal(data, &v); err != nil {
+            return nil, err
+        }
+        return yaml.Marshal(v)
+    }
+    return nil, fmt.Errorf("unsupported config format %q", format)
+}
+
+// NewFromConfig creates a checker from a "json" or "yaml" configuration as
+// written by ExportConfig and registers its endpoints. opts are applied
+// after the configuration, e.g. for a logger or storage. The checker is not
+// started. Redacted secrets must be filled in before use.
+func NewFromConfig(data []byte, format string, opts ...Option) (*Checker, error) {
+    var cfg Config
+    switch format {
+    case "json":
+        if err := json.Unmarshal(data, &cfg); err != nil {
+            return nil, err
+        }
+    case "yaml":
+        var v any
+        if err := yaml.Unmarshal(data, &v); err != nil {
+            return nil, err
+        }
+        b, err := json.Marshal(v)
+        if err != nil {
+            return nil, err
+        }
+        if err := json.Unmarshal(b, &cfg); err != nil {
+            return nil, err
+        }
+    default:
+        return nil, fmt.Errorf("unsupported config format %q", format)
+    }
+    cfgOpts, err := cfg.options()
+    if err != nil {
+        return nil, err
+    }
+    c := New(append(cfgOpts, opts...)...)
+    eps := cfg.Endpoints
+    for i := range eps {
+        fromFileUnits(&eps[i])
+    }
+    if _, err := c.AddSitesBulk(eps); err != nil {
+        return nil, err
+    }
+    return c, nil
+}
+
+// options returns the Options cfg describes.
+func (cfg Config) options() ([]Option, error) {
+    var opts []Option
+    if cfg.Workers > 0 {
+        opts = append(opts, WithWorkers(cfg.Workers))
+    }
+    if cfg.Timeout > 0 {
+        opts = append(opts, WithTimeout(time.Duration(cfg.Timeout)))
+    }
+    if cfg.LogLevel != "" {
+        level, ok := LogLevel(-1), false
+        for l, name := range logLevelNames {
+            if name == cfg.LogLevel {
+                level, ok = l, true
+            }
+        }
+        if !ok {
+            return nil, fmt.Errorf("unknown log level %q", cfg.LogLevel)
+        }
+        opts = append(opts, WithLogLevel(level))
+    }
+    if cfg.LogRetention > 0 {
+        opts = append(opts, WithLogRetention(cfg.LogRetention))
+    }
+    if cfg.MaxTotalLogEntries > 0 {
+        opts = append(opts, WithMaxTotalLogEntries(cfg.MaxTotalLogEntries))
+    }
+    if cfg.QueueSize > 0 {
+        opts = append(opts, WithQueueSize(cfg.QueueSize))
+    }
+    if cfg.ResultBuffer > 0 {
+        opts = append(opts, WithResultBuffer(cfg.ResultBuffer))
+    }
+    if cfg.PriorityAging > 0 {
+        opts = append(opts, WithPriorityAging(time.Duration(cfg.PriorityAging)))
+    }
+    if cfg.Proxy != "" {
+        opts = append(opts, WithProxy(cfg.Proxy))
+    }
+    if len(cfg.DefaultHeaders) > 0 {
+        opts = append(opts, WithDefaultHeaders(cfg.DefaultHeaders))
+    }
+    if len(cfg.UserAgents) > 0 {
+        opts = append(opts, WithUserAgentRotation(cfg.UserAgents))
+    }
+    if len(cfg.Resolvers) > 0 {
+        opts = append(opts, WithResolver(cfg.Resolvers))
+    }
+    if len(cfg.SourceAddresses) > 0 {
+        opts = append(opts, WithSourceAddresses(cfg.SourceAddresses))
+    }
+    if len(cfg.NetworkPaths) > 0 {
+        opts = append(opts, WithNetworkPaths(cfg.NetworkPaths...))
+    }
+    if cfg.ScheduledUptimeOnly {
+        opts = append(opts, WithScheduledUptimeOnly())
+    }
+    if cfg.LoadShedding > 0 {
+        opts = append(opts, WithLoadShedding(cfg.LoadShedding))
+    }
+    if b := cfg.CheckBudget; b != nil {
+        opts = append(opts, WithCheckBudget(b.Limit, time.Duration(b.Per)))
+    }
+    if b := cfg.TimeoutBackoff; b != nil {
+        opts = append(opts, WithTimeoutBackoff(b.After, time.Duration(b.Max)))
+    }
+    if cfg.ResultDedup > 0 {
+        opts = append(opts, WithResultDedup(time.Duration(cfg.ResultDedup)))
+    }
+    if q := cfg.AutoQuarantine; q != nil {
+        opts = append(opts, WithAutoQuarantine(q.Flaps, time.Duration(q.Window), time.Duration(q.Stable)))
+    }
+    if r := cfg.Retries; r != nil {
+        opts = append(opts, WithRetries(r.Count, time.Duration(r.Backoff)))
+    }
+    return opts, nil
+}
+
+// secretHeader reports whether the header named name carries credentials.
+func secretHeader(name string) bool {
+    n := strings.ToLower(name)
+    switch n {
+    case "authorization", "proxy-authorization", "cookie":
+        return true
+    }
+    return strings.Contains(n, "token") || strings.Contains(n, "secret") ||
+        strings.Contains(n, "api-key") || strings.Contains(n, "apikey") || strings.Contains(n, "password")
+}
+
+func redactHeaders(h map[string]string) map[string]string {
+    if len(h) == 0 {
+        return h
+    }
+    out := make(map[string]string, len(h))
+    for k, v := range h {
+        if secretHeader(k) {
+            v = redacted
+        }
+        out[k] = v
+    }
+    return out
+}
+
+// redactURL hides the password of a URL with credentials.
+func redactURL(raw string) string {
+    u, err := url.Parse(raw)
+    if err != nil || u.User == nil {
+        return raw
+    }
+    if _, ok := u.User.Password(); ok {
+        u.User = url.UserPassword(u.User.Username(), redacted)
+    }
+    return u.String()
+}
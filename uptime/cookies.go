@@ -0,0 +1,83 @@
+package uptime
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "slices"
+    "strings"
+)
+
+// ===== Cookie Assertions =====
+
+func checksCookies(ep Endpoint) bool {
+    return ep.ExpectCookieSecure || ep.ExpectCookieHttpOnly || ep.ExpectCookieSameSite != ""
+}
+
+func validateCookieAssertions(ep Endpoint) error {
+    switch strings.ToLower(ep.ExpectCookieSameSite) {
+    case "", "lax", "strict":
+    default:
+        return fmt.Errorf("expect_cookie_samesite must be \"lax\" or \"strict\", got %q", ep.ExpectCookieSameSite)
+    }
+    if len(ep.CookieNames) > 0 && !checksCookies(ep) {
+        return errors.New("cookie_names needs a cookie assertion")
+    }
+    return nil
+}
+
+// responseCookies returns the cookies set by resp and by the redirect
+// responses that led to it, in the order they were set.
+func responseCookies(resp *http.Response) []*http.Cookie {
+    var chain []*http.Response
+    for r := resp; r != nil; {
+        chain = append(chain, r)
+        if r.Request == nil {
+            break
+        }
+        r = r.Request.Response
+    }
+    var out []*http.Cookie
+    for i := len(chain) - 1; i >= 0; i-- {
+        out = append(out, chain[i].Cookies()...)
+    }
+    return out
+}
+
+// checkCookies validates the attributes of the cookies set on the way to
+// resp, or of those named in CookieNames. It records the first offending
+// cookie on res and returns a message listing what it lacks, or "".
+func checkCookies(ep Endpoint, resp *http.Response, res *Result) string {
+    for _, ck := range responseCookies(resp) {
+        if len(ep.CookieNames) > 0 && !slices.Contains(ep.CookieNames, ck.Name) {
+            continue
+        }
+        var missing []string
+        if ep.ExpectCookieSecure && !ck.Secure {
+            missing = append(missing, "Secure")
+        }
+        if ep.ExpectCookieHttpOnly && !ck.HttpOnly {
+            missing = append(missing, "HttpOnly")
+        }
+        if want := ep.ExpectCookieSameSite; want != "" && !sameSiteAtLeast(ck.SameSite, want) {
+            missing = append(missing, "SameSite="+want)
+        }
+        if len(missing) > 0 {
+            res.InsecureCookie = ck.Name
+            return fmt.Sprintf("cookie %q lacks %s", ck.Name, strings.Join(missing, ", "))
+        }
+    }
+    return ""
+}
+
+// sameSiteAtLeast reports whether mode is as strict as want: "strict"
+// requires Strict, "lax" accepts Lax or Strict.
+func sameSiteAtLeast(mode http.SameSite, want string) bool {
+    switch strings.ToLower(want) {
+    case "strict":
+        return mode == http.SameSiteStrictMode
+    case "lax":
+        return mode == http.SameSiteStrictMode || mode == http.SameSiteLaxMode
+    }
+    return false
+}
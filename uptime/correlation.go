@@ -0,0 +1,104 @@
+package uptime
+
+import (
+    "sort"
+    "time"
+)
+
+// ===== Failure Correlation =====
+
+// maxCorrelationEndpoints bounds FailureCorrelation: only the endpoints with
+// the most downtime in the window are compared.
+const maxCorrelationEndpoints = 500
+
+// interval is a period of downtime.
+type interval struct{ start, end time.Time }
+
+// FailureCorrelation returns, for each pair of endpoints that were both DOWN
+// during the last window, how much their downtime overlapped: the time both
+// were DOWN divided by the time either was (0-1), from the recorded
+// incidents. It is symmetric and leaves out endpoints without downtime and
+// pairs that never overlapped. A value near 1 suggests a shared dependency.
+//
+// Every pair is compared, so the cost grows with the square of the number of
+// endpoints that failed in the window; past 500 only the 500 with the most
+// downtime are compared.
+func (c *Checker) FailureCorrelation(window time.Duration) map[string]map[string]float64 {
+    now := time.Now()
+    since := now.Add(-window)
+
+    c.mu.RLock()
+    down := make(map[string][]interval)
+    for id, incidents := range c.incidents {
+        for _, inc := range incidents {
+            end := inc.End
+            if inc.Ongoing() || end.After(now) {
+                end = now
+            }
+            start := inc.Start
+            if start.Before(since) {
+                start = since
+            }
+            if end.After(start) {
+                down[id] = append(down[id], interval{start, end})
+            }
+        }
+    }
+    c.mu.RUnlock()
+
+    ids := make([]string, 0, len(down))
+    total := make(map[string]time.Duration, len(down))
+    for id, ivs := range down {
+        ids = append(ids, id)
+        for _, iv := range ivs {
+            total[id] += iv.end.Sub(iv.start)
+        }
+    }
+    sort.Slice(ids, func(i, j int) bool { return total[ids[i]] > total[ids[j]] })
+    if len(ids) > maxCorrelationEndpoints {
+        ids = ids[:maxCorrelationEndpoints]
+    }
+
+    out := make(map[string]map[string]float64)
+    for i, a := range ids {
+        for _, b := range ids[i+1:] {
+            both := overlap(down[a], down[b])
+            if both == 0 {
+                continue
+            }
+            r := float64(both) / float64(total[a]+total[b]-both)
+            if out[a] == nil {
+                out[a] = make(map[string]float64)
+            }
+            if out[b] == nil {
+                out[b] = make(map[string]float64)
+            }
+            out[a][b], out[b][a] = r, r
+        }
+    }
+    return out
+}
+
+// overlap returns the total time covered by both a and b, each a list of
+// non-overlapping intervals in time order.
+func overlap(a, b []interval) time.Duration {
+    var d time.Duration
+    for i, j := 0, 0; i < len(a) && j < len(b); {
+        start, end := a[i].start, a[i].end
+        if b[j].start.After(start) {
+            start = b[j].start
+        }
+        if b[j].end.Before(end) {
+            end = b[j].end
+        }
+        if end.After(start) {
+            d += end.Sub(start)
+        }
+        if a[i].end.Before(b[j].end) {
+            i++
+        } else {
+            j++
+        }
+    }
+    return d
+}
@@ -0,0 +1,109 @@
+package uptime
+
+import (
+    "fmt"
+    "net/http"
+    "slices"
+    "strings"
+)
+
+// ===== CORS Preflight =====
+
+// isPreflight reports whether ep is checked with a CORS preflight request.
+func isPreflight(ep Endpoint) bool { return ep.CORSOrigin != "" }
+
+// statusOK reports whether code is a status ep accepts. AcceptableStatusCodes
+// takes precedence when set; otherwise code must equal ExpectedStatus.
+// Preflight checks with neither accept any 2xx.
+func statusOK(ep Endpoint, code int) bool {
+    if len(ep.AcceptableStatusCodes) > 0 {
+        return slices.Contains(ep.AcceptableStatusCodes, code)
+    }
+    if ep.ExpectedStatus == 0 && isPreflight(ep) {
+        return code >= 200 && code <= 299
+    }
+    return code == ep.ExpectedStatus
+}
+
+// setPreflight turns req into the preflight a browser sends before an
+// ep.Method request from ep.CORSOrigin. Like a browser's, it has no body,
+// so it carries no Content-Type and is not signed.
+func setPreflight(req *http.Request, ep Endpoint) {
+    req.Method = http.MethodOptions
+    req.Header.Del("Content-Type")
+    req.Header.Set("Origin", ep.CORSOrigin)
+    req.Header.Set("Access-Control-Request-Method", ep.Method)
+    if len(ep.CORSRequestHeaders) > 0 {
+        req.Header.Set("Access-Control-Request-Headers", strings.Join(ep.CORSRequestHeaders, ", "))
+    }
+}
+
+// corsHeaders returns the Access-Control-* headers of resp.
+func corsHeaders(resp *http.Response) map[string]string {
+    out := make(map[string]string)
+    for name, values := range resp.Header {
+        if strings.HasPrefix(name, "Access-Control-") {
+            out[name] = strings.Join(values, ", ")
+        }
+    }
+    return out
+}
+
+// checkCORS validates a preflight response: the origin, method and request
+// headers must be allowed, and credentials with CORSAllowCredentials. It
+// returns "" when they are and a descriptive error otherwise.
+func checkCORS(ep Endpoint, resp *http.Response) string {
+    origin := resp.Header.Get("Access-Control-Allow-Origin")
+    switch {
+    case origin == "":
+        return fmt.Sprintf("CORS preflight for origin %q: missing Access-Control-Allow-Origin", ep.CORSOrigin)
+    case ep.CORSAllowCredentials && origin == "*":
+        return "CORS preflight: Access-Control-Allow-Origin \"*\" is not allowed with credentials"
+    case origin != "*" && origin != ep.CORSOrigin:
+        return fmt.Sprintf("CORS preflight: Access-Control-Allow-Origin is %q, want %q", origin, ep.CORSOrigin)
+    }
+    methods := headerList(resp.Header, "Access-Control-Allow-Methods")
+    if !listAllows(methods, ep.Method, !ep.CORSAllowCredentials) && !simpleMethod(ep.Method) {
+        return fmt.Sprintf("CORS preflight: method %s not in Access-Control-Allow-Methods %q", ep.Method, strings.Join(methods, ", "))
+    }
+    allowed := headerList(resp.Header, "Access-Control-Allow-Headers")
+    for _, h := range ep.CORSRequestHeaders {
+        if !listAllows(allowed, h, !ep.CORSAllowCredentials) {
+            return fmt.Sprintf("CORS preflight: header %s not in Access-Control-Allow-Headers %q", h, strings.Join(allowed, ", "))
+        }
+    }
+    if ep.CORSAllowCredentials && resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+        return "CORS preflight: Access-Control-Allow-Credentials is not \"true\""
+    }
+    return ""
+}
+
+// headerList splits the comma-separated values of header name.
+func headerList(h http.Header, name string) []string {
+    var out []string
+    for _, v := range h.Values(name) {
+        for _, item := range strings.Split(v, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                out = append(out, item)
+            }
+        }
+    }
+    return out
+}
+
+// listAllows reports whether list contains v, case-insensitively, or "*"
+// when the wildcard applies (it does not for credentialed requests).
+func listAllows(list []string, v string, wildcard bool) bool {
+    for _, item := range list {
+        if strings.EqualFold(item, v) || wildcard && item == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+// simpleMethod reports whether browsers allow method cross-origin without
+// it being listed in Access-Control-Allow-Methods.
+func simpleMethod(method string) bool {
+    return method == http.MethodGet || method == http.MethodHead || method == http.MethodPost
+}
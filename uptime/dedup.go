@@ -0,0 +1,29 @@
+package uptime
+
+// ===== Result Deduplication =====
+
+// duplicateLocked reports whether res repeats the endpoint's last recorded
+// result under WithResultDedup: same outcome, status code, failure kind and
+// flags, with both timestamps in the same dedup window. Only the last result
+// is compared, and res must already have its Degraded and Maintenance flags
+// evaluated. A result that would change the endpoint's status, or a failure
+// counting towards a failure threshold, is never collapsed. c.mu must be
+// held.
+func (c *Checker) duplicateLocked(res Result) bool {
+    w := c.dedupWindow
+    logs := c.logs[res.Endpoint.ID]
+    if w <= 0 || len(logs) == 0 {
+        return false
+    }
+    last := logs[len(logs)-1]
+    if last.Success != res.Success || last.StatusCode != res.StatusCode || last.FailureKind != res.FailureKind ||
+        last.Degraded != res.Degraded || last.Partial != res.Partial || last.Maintenance != res.Maintenance ||
+        !last.Timestamp.Truncate(w).Equal(res.Timestamp.Truncate(w)) {
+        return false
+    }
+    before := currentStatus(res.Endpoint, logs)
+    if !res.Success && before != StatusDown {
+        return false
+    }
+    return currentStatus(res.Endpoint, append(logs[:len(logs):len(logs)], res)) == before
+}
@@ -0,0 +1,38 @@
+package uptime
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptrace"
+    "sync/atomic"
+    "time"
+)
+
+// ===== Download Timing =====
+
+// firstByteTimer records the time to the first response byte of a request.
+type firstByteTimer struct {
+    start time.Time
+    ttfb  atomic.Int64
+}
+
+// trace returns req with a trace recording the first response byte.
+func (t *firstByteTimer) trace(req *http.Request) *http.Request {
+    trace := &httptrace.ClientTrace{
+        GotFirstResponseByte: func() { t.ttfb.Store(int64(time.Since(t.start))) },
+    }
+    return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// finish reads the rest of the body and splits res.Latency into the time to
+// first byte and the full download. A failed read fails a passing check.
+func (t *firstByteTimer) finish(res *Result, resp *http.Response) {
+    _, err := io.Copy(io.Discard, resp.Body)
+    res.Latency = time.Since(t.start)
+    res.TTFB = time.Duration(t.ttfb.Load())
+    if err != nil && res.Success {
+        res.Success = false
+        res.Error = "Error reading response body: " + err.Error()
+        res.FailureKind = classifyError(err)
+    }
+}
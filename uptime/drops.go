@@ -0,0 +1,69 @@
+package uptime
+
+import (
+    "go.uber.org/zap"
+)
+
+// ===== Backpressure Drops =====
+
+// Drop reasons passed to the OnDrop hook.
+const (
+    DropJobQueueFull = "job_queue_full" // the scheduler could not enqueue a due check
+    DropResultsFull  = "results_full"   // the Results channel was full; the result was still stored in the logs
+)
+
+type dropEvent struct {
+    ep     Endpoint
+    reason string
+}
+
+// OnDrop registers fn to be called whenever a check or result is dropped
+// because a queue is full. fn runs on a dedicated goroutine, never on the
+// worker or scheduler; if fn falls behind, further drop events are discarded
+// rather than blocking. Passing nil removes the hook.
+func (c *Checker) OnDrop(fn func(ep Endpoint, reason string)) {
+    c.mu.Lock()
+    c.onDrop = fn
+    c.mu.Unlock()
+}
+
+// dropped records a dropped check or result. It never blocks.
+func (c *Checker) dropped(ep Endpoint, reason string) {
+    if reason == DropJobQueueFull {
+        c.droppedJobs.Add(1)
+    } else {
+        c.droppedResults.Add(1)
+    }
+    c.ilog(LogError, "dropped", endpointFields(ep, zap.String("reason", reason))...)
+    select {
+    case c.drops <- dropEvent{ep: ep, reason: reason}:
+    default:
+    }
+}
+
+// dropDispatcher delivers drop events to the OnDrop hook until Stop.
+func (c *Checker) dropDispatcher() {
+    defer c.wg.Done()
+    for {
+        select {
+        case <-c.stopCh:
+            return
+        case ev := <-c.drops:
+            c.mu.RLock()
+            fn := c.onDrop
+            c.mu.RUnlock()
+            if fn != nil {
+                c.callDropHook(fn, ev)
+            }
+        }
+    }
+}
+
+func (c *Checker) callDropHook(fn func(Endpoint, string), ev dropEvent) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "drop_hook_panic", endpointFields(ev.ep, zap.Any("panic", r))...)
+        }
+    }()
+    fn(ev.ep, ev.reason)
+}
@@ -0,0 +1,97 @@
+package uptime
+
+import (
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Notification Escalation =====
+
+// escalationState is the pending re-notification of an ongoing outage.
+type escalationState struct {
+    down  TransitionEvent // the transition that started the outage
+    step  int             // re-notifications so far
+    timer *time.Timer
+}
+
+// alert passes ev to the notifiers and, with WithEscalation, starts
+// escalating the outage it begins. Outages found by the first check are
+// not escalated, as they are not notified either.
+func (c *Checker) alert(ev TransitionEvent) {
+    c.notify(ev)
+    if ev.To != StatusDown || ev.From == StatusUnknown || len(c.escalation) == 0 {
+        return
+    }
+    c.escMu.Lock()
+    defer c.escMu.Unlock()
+    if _, ok := c.escalations[ev.EndpointID]; !ok && c.isRunning() {
+        c.scheduleEscalationLocked(&escalationState{down: ev})
+    }
+}
+
+// scheduleEscalationLocked arms the next re-notification of e; intervals
+// past the end of the schedule repeat its last one. c.escMu must be held.
+func (c *Checker) scheduleEscalationLocked(e *escalationState) {
+    d := c.escalation[min(e.step, len(c.escalation)-1)]
+    e.timer = time.AfterFunc(d, func() { c.escalate(e) })
+    c.escalations[e.down.EndpointID] = e
+}
+
+// escalate re-notifies the outage of e while the endpoint is still DOWN,
+// and arms the next re-notification. Re-notifications falling in a
+// maintenance window are skipped.
+func (c *Checker) escalate(e *escalationState) {
+    id := e.down.EndpointID
+    c.mu.RLock()
+    st := c.statuses[id]
+    logs := c.logs[id]
+    c.mu.RUnlock()
+
+    c.escMu.Lock()
+    if c.escalations[id] != e || !c.isRunning() {
+        c.escMu.Unlock()
+        return
+    }
+    if st.status != StatusDown || len(logs) == 0 {
+        delete(c.escalations, id)
+        c.escMu.Unlock()
+        return
+    }
+    last := logs[len(logs)-1]
+    now := time.Now()
+    skip := c.inMaintenance(last.Endpoint, now)
+    if !skip {
+        e.step++
+    }
+    step := e.step
+    c.scheduleEscalationLocked(e)
+    c.escMu.Unlock()
+    if skip {
+        return
+    }
+
+    ev := e.down
+    ev.Timestamp = now
+    ev.From = StatusDown
+    ev.Since = st.since
+    ev.Duration = now.Sub(st.since)
+    ev.Error = last.Error
+    ev.FailureKind = last.FailureKind
+    ev.Escalation = step
+    c.ilog(LogInfo, "notification_escalated", endpointFields(last.Endpoint, zap.Int("escalation", step))...)
+    c.notify(ev)
+}
+
+// cancelEscalations stops pending re-notifications: of endpoint id, or of
+// every endpoint when id is empty.
+func (c *Checker) cancelEscalations(id string) {
+    c.escMu.Lock()
+    defer c.escMu.Unlock()
+    for k, e := range c.escalations {
+        if id == "" || k == id {
+            e.timer.Stop()
+            delete(c.escalations, k)
+        }
+    }
+}
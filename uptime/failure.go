@@ -0,0 +1,91 @@
+package uptime
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "net"
+    "net/http"
+    "sort"
+    "syscall"
+)
+
+// ===== Failure Classification =====
+
+// classifyError maps an error from sending a request to a FailureKind.
+func classifyError(err error) FailureKind {
+    var dnsErr *net.DNSError
+    var netErr net.Error
+    var certErr *tls.CertificateVerificationError
+    var hostErr x509.HostnameError
+    var authErr x509.UnknownAuthorityError
+    var invalidErr x509.CertificateInvalidError
+    var recordErr tls.RecordHeaderError
+    switch {
+    case errors.Is(err, context.Canceled):
+        return FailureCancelled
+    case errors.Is(err, context.DeadlineExceeded):
+        return FailureTimeout
+    case errors.As(err, &dnsErr):
+        return FailureDNS
+    case errors.As(err, &netErr) && netErr.Timeout():
+        return FailureTimeout
+    case errors.Is(err, syscall.ECONNREFUSED):
+        return FailureConnRefused
+    case errors.As(err, &certErr), errors.As(err, &hostErr), errors.As(err, &authErr),
+        errors.As(err, &invalidErr), errors.As(err, &recordErr):
+        return FailureTLS
+    case errors.As(err, &netErr), errors.Is(err, syscall.ECONNRESET):
+        return FailureConnection
+    }
+    return FailureOther
+}
+
+// blocked reports whether a request failing with kind shows the endpoint
+// is unreachable, as ExpectUnreachable wants: the connection was refused or
+// reset, or the name did not resolve. Timeouts and cancellations say
+// nothing about reachability, and a TLS failure means the host answered.
+func blocked(kind FailureKind) bool {
+    return kind == FailureConnRefused || kind == FailureDNS || kind == FailureConnection
+}
+
+// counted reports whether r counts towards uptime and status. Cancelled
+// checks (e.g. cut short by Stop) say nothing about the endpoint.
+func (r Result) counted() bool { return r.FailureKind != FailureCancelled }
+
+// ===== Failure Headers =====
+
+// maxFailureHeaderBytes caps the size of the response headers captured by
+// CaptureHeadersOnFailure; headers past the cap are left out.
+const maxFailureHeaderBytes = 8 << 10
+
+// failureHeaders returns the response headers to record for a failed check
+// of ep: those named in CaptureHeaderNames, or all of them, up to
+// maxFailureHeaderBytes in name order.
+func failureHeaders(ep Endpoint, h http.Header) map[string][]string {
+    names := make([]string, 0, len(h))
+    if len(ep.CaptureHeaderNames) > 0 {
+        for _, name := range ep.CaptureHeaderNames {
+            if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+                names = append(names, http.CanonicalHeaderKey(name))
+            }
+        }
+    } else {
+        for name := range h {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    out := make(map[string][]string, len(names))
+    size := 0
+    for _, name := range names {
+        for _, v := range h[name] {
+            if size += len(name) + len(v); size > maxFailureHeaderBytes {
+                return out
+            }
+            out[name] = append(out[name], v)
+        }
+    }
+    return out
+}
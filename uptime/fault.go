@@ -0,0 +1,53 @@
+package uptime
+
+import (
+    "fmt"
+    "math/rand/v2"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Fault Injection =====
+
+// FaultRate returns a WithFaultInjection function failing the given
+// fraction (0-1) of checks at random.
+func FaultRate(fraction float64) func(Endpoint) error {
+    return func(Endpoint) error {
+        if rand.Float64() < fraction {
+            return ErrInjectedFault
+        }
+        return nil
+    }
+}
+
+// injectFault runs the WithFaultInjection hook for a scheduled check of ep.
+// When it returns an error it reports a failed result with Origin
+// OriginInjected in place of the check, and true; the endpoint is not
+// contacted. A panicking hook injects nothing.
+func (c *Checker) injectFault(ep Endpoint) (res Result, injected bool) {
+    if c.faultInjector == nil {
+        return Result{}, false
+    }
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "fault_injection_panic", endpointFields(ep, zap.Any("panic", r))...)
+            res, injected = Result{}, false
+        }
+    }()
+    err := c.faultInjector(ep)
+    if err == nil {
+        return Result{}, false
+    }
+    if c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "fault_injected", endpointFields(ep, zap.Error(err))...)
+    }
+    return Result{
+        Endpoint:    ep,
+        Timestamp:   time.Now(),
+        Success:     false,
+        Error:       fmt.Sprintf("injected: %v", err),
+        FailureKind: FailureOther,
+        Origin:      OriginInjected,
+    }, true
+}
@@ -0,0 +1,68 @@
+package uptime
+
+import (
+    "fmt"
+    "net/http"
+    "net/netip"
+    "strings"
+)
+
+// ===== Forwarded Headers =====
+
+// setForwarded sets the X-Forwarded-* family and the RFC 7239 Forwarded
+// header from ep's Forwarded fields, as a proxy in front of the target
+// would.
+func setForwarded(h http.Header, ep Endpoint) {
+    var pairs []string
+    if ep.ForwardedFor != "" {
+        h.Set("X-Forwarded-For", ep.ForwardedFor)
+        h.Set("X-Real-IP", ep.ForwardedFor)
+        node := ep.ForwardedFor
+        if addr, err := netip.ParseAddr(node); err == nil && addr.Is6() {
+            node = "[" + node + "]" // IPv6 nodes are bracketed, hence quoted
+        }
+        pairs = append(pairs, "for="+forwardedValue(node))
+    }
+    if ep.ForwardedProto != "" {
+        h.Set("X-Forwarded-Proto", ep.ForwardedProto)
+        pairs = append(pairs, "proto="+forwardedValue(ep.ForwardedProto))
+    }
+    if ep.ForwardedHost != "" {
+        h.Set("X-Forwarded-Host", ep.ForwardedHost)
+        pairs = append(pairs, "host="+forwardedValue(ep.ForwardedHost))
+    }
+    if len(pairs) > 0 {
+        h.Set("Forwarded", strings.Join(pairs, ";"))
+    }
+}
+
+// forwardedValue returns v as a Forwarded token, or quoted when it contains
+// characters a token can't, such as the ':' of a port.
+func forwardedValue(v string) string {
+    for _, r := range v {
+        if !isTokenChar(r) {
+            return `"` + strings.ReplaceAll(strings.ReplaceAll(v, `\`, `\\`), `"`, `\"`) + `"`
+        }
+    }
+    return v
+}
+
+func isTokenChar(r rune) bool {
+    switch {
+    case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+        return true
+    }
+    return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+// validForwardedFor accepts an IP address, "unknown" or an obfuscated
+// identifier such as "_hidden" (RFC 7239 section 6).
+func validForwardedFor(v string) error {
+    if v == "unknown" || strings.HasPrefix(v, "_") {
+        return nil
+    }
+    if _, err := netip.ParseAddr(v); err != nil {
+        return fmt.Errorf("invalid forwarded_for %q: want an IP address", v)
+    }
+    return nil
+}
@@ -0,0 +1,83 @@
+package uptime
+
+import (
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Alert Grace Period =====
+
+// graceState tracks an endpoint's AlertGracePeriod.
+type graceState struct {
+    added time.Time       // when the endpoint was registered
+    held  bool            // the failure action of a DOWN transition was held back
+    event TransitionEvent // that transition, notified on release
+}
+
+// inGraceLocked reports whether ep's alert grace period is running at t.
+// c.mu must be held.
+func (c *Checker) inGraceLocked(ep Endpoint, t time.Time) bool {
+    g := c.graces[ep.ID]
+    return ep.AlertGracePeriod > 0 && g != nil && t.Before(g.added.Add(ep.AlertGracePeriod))
+}
+
+// observeGrace applies the endpoint's alert grace period to res and the
+// transition ev it caused, if any. It reports whether the failure action
+// of ev must be held back, because the grace period is running, and
+// whether a held-back failure action is released, because the period has
+// ended with the endpoint still DOWN.
+func (c *Checker) observeGrace(res Result, ev *TransitionEvent) (held, released bool) {
+    ep := res.Endpoint
+    if ep.AlertGracePeriod <= 0 {
+        return false, false
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    g := c.graces[ep.ID]
+    if g == nil {
+        return false, false
+    }
+    if c.inGraceLocked(ep, res.Timestamp) {
+        if ev != nil {
+            g.held = ev.To == StatusDown
+            held = g.held
+            if held {
+                g.event = *ev
+            }
+        }
+        return held, false
+    }
+    released = g.held && c.statuses[ep.ID].status == StatusDown
+    g.held = false
+    return false, released
+}
+
+// inGrace reports whether ep's alert grace period is running at t.
+func (c *Checker) inGrace(ep Endpoint, t time.Time) bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.inGraceLocked(ep, t)
+}
+
+// heldTransition returns the DOWN transition of id held back by its grace
+// period.
+func (c *Checker) heldTransition(id string) TransitionEvent {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    if g := c.graces[id]; g != nil {
+        return g.event
+    }
+    return TransitionEvent{}
+}
+
+// logGrace reports a failure action held back or released by the grace
+// period.
+func (c *Checker) logGrace(res Result, held, released bool) {
+    switch {
+    case held:
+        c.ilog(LogInfo, "failure_action_held", endpointFields(res.Endpoint, zap.Duration("grace_period", res.Endpoint.AlertGracePeriod))...)
+    case released:
+        c.ilog(LogInfo, "failure_action_released", endpointFields(res.Endpoint)...)
+    }
+}
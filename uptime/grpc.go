@@ -0,0 +1,233 @@
+package uptime
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+
+    "golang.org/x/net/http2"
+)
+
+// ===== gRPC Health Checks =====
+
+// Endpoint types.
+const (
+    TypeHTTP = "http" // plain HTTP request (default)
+    TypeGRPC = "grpc" // grpc.health.v1.Health/Check
+    TypeTLS  = "tls"  // TLS handshake only, see checkTLS
+)
+
+// Serving statuses of the gRPC health protocol, as reported in
+// Result.GRPCStatus.
+const (
+    GRPCUnknown        = "UNKNOWN"
+    GRPCServing        = "SERVING"
+    GRPCNotServing     = "NOT_SERVING"
+    GRPCServiceUnknown = "SERVICE_UNKNOWN"
+)
+
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// grpcStatusNotFound is the gRPC status code a health server returns for a
+// service it does not know.
+const grpcStatusNotFound = 5
+
+// checkGRPC calls the gRPC health service at ep.URL and reports the serving
+// status of ep.GRPCService.
+func (c *Checker) checkGRPC(ctx context.Context, ep Endpoint) Result {
+    start := time.Now()
+    res := Result{Endpoint: ep, Timestamp: start}
+    fail := func(kind FailureKind, format string, args ...any) Result {
+        res.Latency = time.Since(start)
+        res.Error = fmt.Sprintf(format, args...)
+        res.FailureKind = kind
+        return res
+    }
+
+    u, err := url.Parse(ep.URL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+        return fail(FailureOther, "grpc endpoint needs an http:// or https:// url, got %q", ep.URL)
+    }
+    u.Path = grpcHealthPath
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(grpcHealthRequest(ep.GRPCService)))
+    if err != nil {
+        return fail(FailureOther, "Error creating request: %v", err)
+    }
+    for k, v := range ep.Headers {
+        req.Header.Set(k, v)
+    }
+    req.Header.Set("Content-Type", "application/grpc")
+    req.Header.Set("TE", "trailers")
+    if c.requestDecorator != nil {
+        if err := c.decorateRequest(req, ep); err != nil {
+            return fail(FailureOther, "%v", err)
+        }
+    }
+
+    key := grpcTransportKey{cleartext: u.Scheme == "http", insecure: ep.InsecureSkipVerify}
+    client := &http.Client{Transport: c.grpcTransport(key), Timeout: c.httpClient.Timeout}
+    resp, err := client.Do(req)
+    if err != nil {
+        return fail(classifyError(err), "%v", err)
+    }
+    defer resp.Body.Close()
+    res.StatusCode = resp.StatusCode
+    if resp.StatusCode != http.StatusOK {
+        return fail(FailureStatus, "grpc: unexpected HTTP status %d", resp.StatusCode)
+    }
+    msg, err := readGRPCMessage(resp.Body)
+    if err != nil {
+        return fail(classifyError(err), "grpc: %v", err)
+    }
+
+    // Trailers-only responses carry the status in the headers.
+    code := resp.Header.Get("Grpc-Status")
+    if code == "" {
+        code = resp.Trailer.Get("Grpc-Status")
+    }
+    if code != "" && code != "0" {
+        if n, _ := strconv.Atoi(code); n == grpcStatusNotFound {
+            res.GRPCStatus = GRPCServiceUnknown
+            return fail(FailureStatus, "grpc health status %s", GRPCServiceUnknown)
+        }
+        text := resp.Header.Get("Grpc-Message")
+        if text == "" {
+            text = resp.Trailer.Get("Grpc-Message")
+        }
+        if text, err := url.PathUnescape(text); err == nil && text != "" {
+            return fail(FailureStatus, "grpc status %s: %s", code, text)
+        }
+        return fail(FailureStatus, "grpc status %s", code)
+    }
+    if msg == nil {
+        return fail(FailureOther, "grpc: empty health check response")
+    }
+    status, err := parseServingStatus(msg)
+    if err != nil {
+        return fail(FailureOther, "grpc: %v", err)
+    }
+
+    res.GRPCStatus = status
+    res.Latency = time.Since(start)
+    res.Success = status == GRPCServing
+    if !res.Success {
+        res.Error = "grpc health status " + status
+        res.FailureKind = FailureStatus
+    }
+    return res
+}
+
+// grpcTransportKey identifies the settings a gRPC transport is built with.
+type grpcTransportKey struct {
+    cleartext bool
+    insecure  bool
+}
+
+// grpcTransport returns the HTTP/2 transport for gRPC checks, shared across
+// checks so connections are reused. Cleartext transports speak HTTP/2
+// without TLS (h2c), as plaintext gRPC servers expect.
+func (c *Checker) grpcTransport(key grpcTransportKey) *http2.Transport {
+    if tr, ok := c.grpcTransports.Load(key); ok {
+        return tr.(*http2.Transport)
+    }
+    tr := &http2.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: key.insecure}}
+    if key.cleartext {
+        tr.AllowHTTP = true
+        tr.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+            var d net.Dialer
+            return d.DialContext(ctx, network, addr)
+        }
+    }
+    actual, _ := c.grpcTransports.LoadOrStore(key, tr)
+    return actual.(*http2.Transport)
+}
+
+// grpcHealthRequest returns the length-prefixed HealthCheckRequest message.
+func grpcHealthRequest(service string) []byte {
+    var msg []byte
+    if service != "" {
+        msg = append(msg, 0x0a) // field 1, length-delimited
+        msg = binary.AppendUvarint(msg, uint64(len(service)))
+        msg = append(msg, service...)
+    }
+    frame := make([]byte, 5, 5+len(msg))
+    binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+    return append(frame, msg...)
+}
+
+// readGRPCMessage reads the first length-prefixed message of a gRPC
+// response body; nil when the body is empty.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+    var hdr [5]byte
+    if _, err := io.ReadFull(r, hdr[:]); err != nil {
+        if errors.Is(err, io.EOF) {
+            _, err = io.Copy(io.Discard, r) // reach the trailers
+            return nil, err
+        }
+        return nil, err
+    }
+    if hdr[0] != 0 {
+        return nil, errors.New("compressed response not supported")
+    }
+    n := binary.BigEndian.Uint32(hdr[1:])
+    if n > 1<<16 {
+        return nil, fmt.Errorf("response message too large (%d bytes)", n)
+    }
+    msg := make([]byte, n)
+    if _, err := io.ReadFull(r, msg); err != nil {
+        return nil, err
+    }
+    _, err := io.Copy(io.Discard, r)
+    return msg, err
+}
+
+// parseServingStatus decodes the status field of a HealthCheckResponse.
+func parseServingStatus(msg []byte) (string, error) {
+    var status uint64 // proto3 default: UNKNOWN
+    for len(msg) > 0 {
+        key, n := binary.Uvarint(msg)
+        if n <= 0 {
+            return "", errors.New("malformed health check response")
+        }
+        msg = msg[n:]
+        switch key & 7 {
+        case 0: // varint
+            v, n := binary.Uvarint(msg)
+            if n <= 0 {
+                return "", errors.New("malformed health check response")
+            }
+            msg = msg[n:]
+            if key>>3 == 1 {
+                status = v
+            }
+        case 2: // length-delimited
+            l, n := binary.Uvarint(msg)
+            if n <= 0 || uint64(len(msg)-n) < l {
+                return "", errors.New("malformed health check response")
+            }
+            msg = msg[n+int(l):]
+        default:
+            return "", fmt.Errorf("unexpected wire type %d in health check response", key&7)
+        }
+    }
+    switch status {
+    case 0:
+        return GRPCUnknown, nil
+    case 1:
+        return GRPCServing, nil
+    case 2:
+        return GRPCNotServing, nil
+    case 3:
+        return GRPCServiceUnknown, nil
+    }
+    return "", fmt.Errorf("unknown serving status %d", status)
+}
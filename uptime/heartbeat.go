@@ -0,0 +1,65 @@
+package uptime
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/http"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Heartbeat =====
+
+// heartbeat configures WithHeartbeat.
+type heartbeat struct {
+    url      string
+    interval time.Duration
+    client   *http.Client
+}
+
+// heartbeatLoop pings the heartbeat URL every interval while the checker is
+// Healthy, until Stop.
+func (c *Checker) heartbeatLoop() {
+    defer c.wg.Done()
+    t := time.NewTicker(c.heartbeat.interval)
+    defer t.Stop()
+    for {
+        select {
+        case <-c.stopCh:
+            return
+        case <-t.C:
+            if !c.Healthy() {
+                c.ilog(LogInfo, "heartbeat_skipped_unhealthy", zap.String("url", c.heartbeat.url))
+                continue
+            }
+            if err := c.ping(); err != nil {
+                c.ilog(LogError, "heartbeat_failed", zap.String("url", c.heartbeat.url), zap.Error(err))
+            }
+        }
+    }
+}
+
+// ping sends one heartbeat request.
+func (c *Checker) ping() error {
+    ctx, cancel := context.WithTimeout(c.checkCtx, c.heartbeat.client.Timeout)
+    defer cancel()
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.heartbeat.url, nil)
+    if err != nil {
+        return err
+    }
+    resp, err := c.heartbeat.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("unexpected status %d", resp.StatusCode)
+    }
+    if c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "heartbeat_sent", zap.String("url", c.heartbeat.url))
+    }
+    return nil
+}
@@ -0,0 +1,320 @@
+// Package histogram provides mergeable base-2 exponential histograms for
+// check latencies, so a central system can combine latency distributions
+// from many checkers without shipping raw samples.
+//
+// Bucket boundaries are powers of base = 2^(2^-scale); a higher scale gives
+// finer buckets (relative error about base-1). Histograms with different
+// scales are merged by downscaling to the coarser one.
+package histogram
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+const (
+    // DefaultScale gives a relative bucket error of about 0.27%.
+    DefaultScale int32 = 8
+    MinScale     int32 = -10
+    MaxScale     int32 = 20
+
+    encodingVersion = 1
+)
+
+// Histogram is a sparse exponential histogram of non-negative values.
+// Values <= 0 are counted in the zero bucket. The zero value is an empty
+// histogram with scale 0.
+type Histogram struct {
+    Scale     int32
+    Count     uint64
+    ZeroCount uint64
+    Sum       float64
+    Min       float64
+    Max       float64
+    Buckets   map[int32]uint64 // bucket index -> count; bucket i covers (base^i, base^(i+1)]
+}
+
+// New returns an empty histogram with the given scale, clamped to
+// [MinScale, MaxScale].
+func New(scale int32) *Histogram {
+    if scale < MinScale {
+        scale = MinScale
+    }
+    if scale > MaxScale {
+        scale = MaxScale
+    }
+    return &Histogram{Scale: scale, Buckets: make(map[int32]uint64)}
+}
+
+// Record adds one observation.
+func (h *Histogram) Record(v float64) {
+    if math.IsNaN(v) || math.IsInf(v, 0) {
+        return
+    }
+    if h.Count == 0 || v < h.Min {
+        h.Min = v
+    }
+    if h.Count == 0 || v > h.Max {
+        h.Max = v
+    }
+    h.Count++
+    h.Sum += v
+    if v <= 0 {
+        h.ZeroCount++
+        return
+    }
+    if h.Buckets == nil {
+        h.Buckets = make(map[int32]uint64)
+    }
+    h.Buckets[bucketIndex(v, h.Scale)]++
+}
+
+// Merge adds the observations of o into h, downscaling h if o is coarser.
+func (h *Histogram) Merge(o *Histogram) {
+    if o == nil || o.Count == 0 {
+        return
+    }
+    if o.Scale < h.Scale {
+        h.downscale(o.Scale)
+    }
+    if h.Buckets == nil {
+        h.Buckets = make(map[int32]uint64, len(o.Buckets))
+    }
+    shift := uint(o.Scale - h.Scale)
+    for idx, n := range o.Buckets {
+        h.Buckets[idx>>shift] += n
+    }
+    if h.Count == 0 || o.Min < h.Min {
+        h.Min = o.Min
+    }
+    if h.Count == 0 || o.Max > h.Max {
+        h.Max = o.Max
+    }
+    h.Count += o.Count
+    h.ZeroCount += o.ZeroCount
+    h.Sum += o.Sum
+}
+
+// Quantile returns an estimate of the q-quantile (0 <= q <= 1), or 0 for an
+// empty histogram.
+func (h *Histogram) Quantile(q float64) float64 {
+    if h.Count == 0 {
+        return 0
+    }
+    if q <= 0 {
+        return h.Min
+    }
+    if q >= 1 {
+        return h.Max
+    }
+    rank := uint64(math.Ceil(q * float64(h.Count)))
+    if rank <= h.ZeroCount {
+        return math.Max(h.Min, 0)
+    }
+    seen := h.ZeroCount
+    for _, idx := range h.sortedIndexes() {
+        seen += h.Buckets[idx]
+        if seen >= rank {
+            lower, upper := bucketBounds(idx, h.Scale)
+            v := math.Sqrt(lower * upper) // geometric midpoint
+            return math.Min(math.Max(v, h.Min), h.Max)
+        }
+    }
+    return h.Max
+}
+
+// Mean returns the arithmetic mean, or 0 for an empty histogram.
+func (h *Histogram) Mean() float64 {
+    if h.Count == 0 {
+        return 0
+    }
+    return h.Sum / float64(h.Count)
+}
+
+func (h *Histogram) downscale(scale int32) {
+    shift := uint(h.Scale - scale)
+    merged := make(map[int32]uint64, len(h.Buckets))
+    for idx, n := range h.Buckets {
+        merged[idx>>shift] += n
+    }
+    h.Buckets = merged
+    h.Scale = scale
+}
+
+func (h *Histogram) sortedIndexes() []int32 {
+    idx := make([]int32, 0, len(h.Buckets))
+    for i := range h.Buckets {
+        idx = append(idx, i)
+    }
+    sort.Slice(idx, func(a, b int) bool { return idx[a] < idx[b] })
+    return idx
+}
+
+// bucketIndex returns i such that base^i < v <= base^(i+1).
+func bucketIndex(v float64, scale int32) int32 {
+    return int32(math.Ceil(math.Log2(v)*math.Exp2(float64(scale)))) - 1
+}
+
+func bucketBounds(idx, scale int32) (lower, upper float64) {
+    step := math.Exp2(-float64(scale))
+    return math.Exp2(float64(idx) * step), math.Exp2(float64(idx+1) * step)
+}
+
+// ===== Encoding =====
+
+// MarshalBinary encodes h in a compact varint form suitable for shipping to
+// an aggregator.
+func (h *Histogram) MarshalBinary() ([]byte, error) {
+    b := []byte{encodingVersion}
+    b = binary.AppendVarint(b, int64(h.Scale))
+    b = binary.AppendUvarint(b, h.Count)
+    b = binary.AppendUvarint(b, h.ZeroCount)
+    b = binary.LittleEndian.AppendUint64(b, math.Float64bits(h.Sum))
+    b = binary.LittleEndian.AppendUint64(b, math.Float64bits(h.Min))
+    b = binary.LittleEndian.AppendUint64(b, math.Float64bits(h.Max))
+    b = binary.AppendUvarint(b, uint64(len(h.Buckets)))
+    prev := int64(0)
+    for _, idx := range h.sortedIndexes() {
+        b = binary.AppendVarint(b, int64(idx)-prev) // delta-encoded indexes
+        b = binary.AppendUvarint(b, h.Buckets[idx])
+        prev = int64(idx)
+    }
+    return b, nil
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary into h.
+func (h *Histogram) UnmarshalBinary(data []byte) error {
+    r := &reader{buf: data}
+    if v := r.byte(); v != encodingVersion {
+        if r.err != nil {
+            return r.err
+        }
+        return fmt.Errorf("histogram: unsupported encoding version %d", v)
+    }
+    scale := r.varint()
+    out := Histogram{
+        Count:     r.uvarint(),
+        ZeroCount: r.uvarint(),
+        Sum:       math.Float64frombits(r.uint64()),
+        Min:       math.Float64frombits(r.uint64()),
+        Max:       math.Float64frombits(r.uint64()),
+    }
+    n := r.uvarint()
+    if r.err == nil && n > uint64(len(data)) {
+        return errors.New("histogram: corrupt bucket count")
+    }
+    out.Buckets = make(map[int32]uint64, n)
+    idx := int64(0)
+    for i := uint64(0); i < n && r.err == nil; i++ {
+        idx += r.varint()
+        out.Buckets[int32(idx)] = r.uvarint()
+    }
+    if r.err != nil {
+        return r.err
+    }
+    if scale < int64(MinScale) || scale > int64(MaxScale) {
+        return fmt.Errorf("histogram: scale %d out of range", scale)
+    }
+    out.Scale = int32(scale)
+    *h = out
+    return nil
+}
+
+// Decode is a convenience wrapper around UnmarshalBinary.
+func Decode(data []byte) (*Histogram, error) {
+    h := &Histogram{}
+    if err := h.UnmarshalBinary(data); err != nil {
+        return nil, err
+    }
+    return h, nil
+}
+
+// Merge combines histograms into a new one at the coarsest scale among them.
+func Merge(hs ...*Histogram) *Histogram {
+    out := New(MaxScale)
+    for _, h := range hs {
+        out.Merge(h)
+    }
+    return out
+}
+
+// ===== Checker Integration =====
+
+// FromResults builds a latency histogram (in seconds) from check results.
+// Failed checks are included; filter beforehand if only successes matter.
+func FromResults(results []uptime.Result, scale int32) *Histogram {
+    h := New(scale)
+    for _, r := range results {
+        h.Record(r.Latency.Seconds())
+    }
+    return h
+}
+
+// Snapshot returns the encoded latency histogram of every endpoint's retained
+// results, keyed by endpoint ID.
+func Snapshot(c *uptime.Checker, scale int32) map[string][]byte {
+    out := make(map[string][]byte)
+    for _, ep := range c.ListSites() {
+        b, _ := FromResults(c.GetLogs(ep.ID, math.MaxInt), scale).MarshalBinary()
+        out[ep.ID] = b
+    }
+    return out
+}
+
+type reader struct {
+    buf []byte
+    err error
+}
+
+var errShort = errors.New("histogram: truncated data")
+
+func (r *reader) byte() byte {
+    if r.err != nil || len(r.buf) < 1 {
+        r.err = errShort
+        return 0
+    }
+    v := r.buf[0]
+    r.buf = r.buf[1:]
+    return v
+}
+
+func (r *reader) uint64() uint64 {
+    if r.err != nil || len(r.buf) < 8 {
+        r.err = errShort
+        return 0
+    }
+    v := binary.LittleEndian.Uint64(r.buf)
+    r.buf = r.buf[8:]
+    return v
+}
+
+func (r *reader) uvarint() uint64 {
+    if r.err != nil {
+        return 0
+    }
+    v, n := binary.Uvarint(r.buf)
+    if n <= 0 {
+        r.err = errShort
+        return 0
+    }
+    r.buf = r.buf[n:]
+    return v
+}
+
+func (r *reader) varint() int64 {
+    if r.err != nil {
+        return 0
+    }
+    v, n := binary.Varint(r.buf)
+    if n <= 0 {
+        r.err = errShort
+        return 0
+    }
+    r.buf = r.buf[n:]
+    return v
+}
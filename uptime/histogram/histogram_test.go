@@ -0,0 +1,65 @@
+package histogram_test
+
+import (
+    "math"
+    "testing"
+
+    "github.com/amartya2002/uptime-checker-core/uptime/histogram"
+)
+
+func TestRoundTripAndQuantile(t *testing.T) {
+    h := histogram.New(histogram.DefaultScale)
+    for i := 1; i <= 1000; i++ {
+        h.Record(float64(i) / 1000) // 1ms .. 1s
+    }
+    b, err := h.MarshalBinary()
+    if err != nil {
+        t.Fatalf("marshal: %v", err)
+    }
+    got, err := histogram.Decode(b)
+    if err != nil {
+        t.Fatalf("decode: %v", err)
+    }
+    if got.Count != 1000 || got.Min != 0.001 || got.Max != 1 {
+        t.Fatalf("unexpected decoded stats: %+v", got)
+    }
+    if p := got.Quantile(0.5); math.Abs(p-0.5)/0.5 > 0.01 {
+        t.Fatalf("p50 off by more than 1%%: %v", p)
+    }
+    if _, err := histogram.Decode(b[:len(b)-1]); err == nil {
+        t.Fatalf("expected error for truncated data")
+    }
+}
+
+func TestMergeAcrossScales(t *testing.T) {
+    fine := histogram.New(10)
+    coarse := histogram.New(4)
+    for i := 1; i <= 500; i++ {
+        fine.Record(float64(i) / 1000)
+        coarse.Record(float64(i+500) / 1000)
+    }
+    m := histogram.Merge(fine, coarse)
+    if m.Scale != 4 {
+        t.Fatalf("expected merge at coarsest scale 4, got %d", m.Scale)
+    }
+    if m.Count != 1000 || m.Min != 0.001 || m.Max != 1 {
+        t.Fatalf("unexpected merged stats: count=%d min=%v max=%v", m.Count, m.Min, m.Max)
+    }
+    if p := m.Quantile(0.9); math.Abs(p-0.9)/0.9 > 0.05 {
+        t.Fatalf("merged p90 off by more than 5%%: %v", p)
+    }
+}
+
+func TestZeroValue(t *testing.T) {
+    var h histogram.Histogram
+    h.Record(0.5)
+    h.Record(0)
+    var m histogram.Histogram
+    m.Merge(&h)
+    if m.Count != 2 || m.ZeroCount != 1 || m.Max != 0.5 {
+        t.Fatalf("unexpected merged zero-value histogram %+v", m)
+    }
+    if q := m.Quantile(0.99); math.Abs(q-0.5) > 0.5*0.5 {
+        t.Fatalf("p99 = %v, want about 0.5", q)
+    }
+}
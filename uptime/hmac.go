@@ -0,0 +1,103 @@
+package uptime
+
+import (
+    "crypto/hmac"
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash"
+    "net/http"
+    "strings"
+)
+
+// ===== HMAC Request Signing =====
+
+const defaultHMACHeader = "X-Signature"
+
+// Secret is a credential that never appears in serialized or logged output:
+// it marshals to JSON, and formats, as "REDACTED". It unmarshals normally,
+// so endpoint files can supply it; files written back (SaveToFile,
+// ExportConfig) carry the placeholder and need the secret filled in again,
+// as an endpoint whose secret is the placeholder is rejected.
+type Secret string
+
+// MarshalJSON encodes a non-empty secret as "REDACTED".
+func (s Secret) MarshalJSON() ([]byte, error) {
+    if s == "" {
+        return []byte(`""`), nil
+    }
+    return json.Marshal(redacted)
+}
+
+// String returns "REDACTED" for a non-empty secret.
+func (s Secret) String() string {
+    if s == "" {
+        return ""
+    }
+    return redacted
+}
+
+// hmacHash returns the hash constructor for an HMACAlgo, "sha256" when
+// empty.
+func hmacHash(algo string) (func() hash.Hash, error) {
+    switch strings.ToLower(algo) {
+    case "", "sha256":
+        return sha256.New, nil
+    case "sha1":
+        return sha1.New, nil
+    case "sha512":
+        return sha512.New, nil
+    }
+    return nil, fmt.Errorf("unsupported hmac_algo %q", algo)
+}
+
+func validateHMAC(ep Endpoint) error {
+    if ep.HMACSecret == "" {
+        if ep.HMACHeader != "" || ep.HMACAlgo != "" {
+            return errors.New("hmac_header and hmac_algo need hmac_secret")
+        }
+        return nil
+    }
+    if ep.HMACSecret == redacted {
+        return errors.New("hmac_secret is the REDACTED placeholder; fill in the secret")
+    }
+    _, err := hmacHash(ep.HMACAlgo)
+    return err
+}
+
+// hmacSigner sets the hex HMAC of the request body, keyed with the
+// endpoint's HMACSecret, in its HMACHeader.
+type hmacSigner struct{ ep Endpoint }
+
+func (s hmacSigner) SignRequest(req *http.Request, body []byte) error {
+    h, err := hmacHash(s.ep.HMACAlgo)
+    if err != nil {
+        return err
+    }
+    mac := hmac.New(h, []byte(s.ep.HMACSecret))
+    mac.Write(body)
+    header := s.ep.HMACHeader
+    if header == "" {
+        header = defaultHMACHeader
+    }
+    req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+    return nil
+}
+
+// requestSigners returns the signers of ep's requests in the order they
+// run: the HMAC signature first, so Endpoint.Signer (e.g. SigV4) can cover
+// it.
+func requestSigners(ep Endpoint) []RequestSigner {
+    var out []RequestSigner
+    if ep.HMACSecret != "" {
+        out = append(out, hmacSigner{ep})
+    }
+    if ep.Signer != nil {
+        out = append(out, ep.Signer)
+    }
+    return out
+}
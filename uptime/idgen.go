@@ -0,0 +1,44 @@
+package uptime
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+)
+
+// ===== Endpoint IDs =====
+
+// DefaultID is the ID generator used for endpoints registered without an
+// ID: "ep-" and the first 12 hex digits of the SHA-256 of what the endpoint
+// checks — its method and URL and, when set, its Type (other than http),
+// URLs and GRPCService. It is deterministic, so the same endpoint gets the same ID
+// across restarts and its stored logs and incidents are found again; two
+// endpoints checking the same target get the same ID and clash as
+// duplicates. It is meant to be applied by the checker, after defaults
+// such as the method: use the Endpoint returned by AddSite rather than
+// calling DefaultID on the endpoint passed in.
+func DefaultID(ep Endpoint) string {
+    key := ep.Method + " " + ep.URL
+    if ep.Type != "" && ep.Type != TypeHTTP {
+        key += "\ntype " + ep.Type
+    }
+    for _, u := range ep.URLs {
+        key += "\nurl " + u
+    }
+    if ep.GRPCService != "" {
+        key += "\ngrpc " + ep.GRPCService
+    }
+    sum := sha256.Sum256([]byte(key))
+    return "ep-" + hex.EncodeToString(sum[:6])
+}
+
+// assignID gives ep an ID from the checker's generator if it has none.
+func (c *Checker) assignID(ep *Endpoint) {
+    if ep.ID != "" {
+        return
+    }
+    gen := c.idGenerator
+    if gen == nil {
+        gen = DefaultID
+    }
+    ep.ID = gen(*ep)
+}
@@ -0,0 +1,184 @@
+package uptime
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Incidents =====
+
+// maxIncidents is how many incidents are kept per endpoint; older ones are
+// dropped first.
+const maxIncidents = 100
+
+// Incident is a period during which an endpoint was DOWN.
+type Incident struct {
+    EndpointID  string         `json:"endpoint_id"`
+    Start       time.Time      `json:"start"`
+    End         time.Time      `json:"end"`                    // zero while ongoing
+    Error       string         `json:"error,omitempty"`        // error of the result that took the endpoint down
+    FailureKind FailureKind    `json:"failure_kind,omitempty"` // failure kind of that result
+    Notes       []IncidentNote `json:"notes,omitempty"`        // see AnnotateIncident
+}
+
+// Ongoing reports whether the endpoint is still DOWN.
+func (i Incident) Ongoing() bool { return i.End.IsZero() }
+
+// IncidentNote is an operator note attached to an incident, e.g. the
+// resolution or a postmortem link.
+type IncidentNote struct {
+    Timestamp time.Time `json:"timestamp"`
+    Text      string    `json:"text"`
+}
+
+// Incidents returns the recorded incidents of the endpoint, oldest first.
+// An incident opens when the endpoint goes DOWN and ends when it leaves
+// DOWN; the last 100 are kept.
+func (c *Checker) Incidents(id string) []Incident {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return copyIncidents(c.incidents[id])
+}
+
+// AnnotateIncident attaches note to the endpoint's incident at incidentIdx,
+// an index into Incidents(id). With a Storage implementing IncidentStorage
+// the note is persisted with the incident.
+func (c *Checker) AnnotateIncident(id string, incidentIdx int, note string) error {
+    note = strings.TrimSpace(note)
+    if note == "" {
+        return errors.New("empty incident note")
+    }
+    c.mu.Lock()
+    incidents := c.incidents[id]
+    if incidentIdx < 0 || incidentIdx >= len(incidents) {
+        c.mu.Unlock()
+        return fmt.Errorf("no incident %d for endpoint %q", incidentIdx, id)
+    }
+    in := &incidents[incidentIdx]
+    in.Notes = append(in.Notes, IncidentNote{Timestamp: time.Now(), Text: note})
+    save := c.incidentSaveLocked(id)
+    c.mu.Unlock()
+    c.saveIncidents(save)
+    return nil
+}
+
+// recordIncident opens an incident when ev takes the endpoint DOWN and ends
+// the ongoing one when ev takes it out of DOWN.
+func (c *Checker) recordIncident(ev *TransitionEvent) {
+    id := ev.EndpointID
+    c.mu.Lock()
+    incidents := c.incidents[id]
+    switch {
+    case ev.To == StatusDown:
+        incidents = append(incidents, Incident{
+            EndpointID:  id,
+            Start:       ev.Timestamp,
+            Error:       ev.Error,
+            FailureKind: ev.FailureKind,
+        })
+        if len(incidents) > maxIncidents {
+            incidents = append([]Incident(nil), incidents[len(incidents)-maxIncidents:]...)
+        }
+    case ev.From == StatusDown:
+        save, ok := c.endIncidentLocked(id, ev.Timestamp)
+        c.mu.Unlock()
+        if ok {
+            c.saveIncidents(save)
+        }
+        return
+    default:
+        c.mu.Unlock()
+        return
+    }
+    c.incidents[id] = incidents
+    save := c.incidentSaveLocked(id)
+    c.mu.Unlock()
+    c.saveIncidents(save)
+}
+
+// endIncidentLocked ends the endpoint's ongoing incident at t, returning the
+// incidents to save; ok is false when none was ongoing. c.mu must be held.
+func (c *Checker) endIncidentLocked(id string, t time.Time) (save incidentSave, ok bool) {
+    cur := c.incidents[id]
+    if len(cur) == 0 || !cur[len(cur)-1].Ongoing() {
+        return incidentSave{}, false
+    }
+    cur[len(cur)-1].End = t
+    return c.incidentSaveLocked(id), true
+}
+
+// incidentSave is a copy of an endpoint's incidents to persist. Saves run
+// outside c.mu, so each is numbered in the order the incidents changed.
+type incidentSave struct {
+    id        string
+    seq       uint64
+    incidents []Incident
+}
+
+// incidentSaveLocked returns a numbered copy of the endpoint's incidents for
+// saveIncidents. c.mu must be held.
+func (c *Checker) incidentSaveLocked(id string) incidentSave {
+    c.incidentSeq++
+    return incidentSave{id: id, seq: c.incidentSeq, incidents: copyIncidents(c.incidents[id])}
+}
+
+// saveIncidents persists the endpoint's incidents when the storage supports
+// it. Saves are serialized, and one overtaken by a later change of the same
+// endpoint's incidents is skipped, so the stored copy is never older than
+// the last one saved.
+func (c *Checker) saveIncidents(save incidentSave) {
+    s, ok := c.storage.(IncidentStorage)
+    if !ok {
+        return
+    }
+    c.incidentSaveMu.Lock()
+    defer c.incidentSaveMu.Unlock()
+    if save.seq <= c.incidentSaved[save.id] {
+        return
+    }
+    c.incidentSaved[save.id] = save.seq
+    if err := s.SaveIncidents(save.id, save.incidents); err != nil {
+        c.ilog(LogError, "incident_save_failed", zap.String("endpoint_id", save.id), zap.Error(err))
+    }
+}
+
+// restoreIncidents loads the persisted incidents of endpoints that have none
+// recorded.
+func (c *Checker) restoreIncidents(eps []Endpoint) {
+    s, ok := c.storage.(IncidentStorage)
+    if !ok {
+        return
+    }
+    for _, ep := range eps {
+        incidents, err := s.LoadIncidents(ep.ID)
+        if err != nil {
+            c.ilog(LogError, "incident_restore_failed", endpointFields(ep, zap.Error(err))...)
+            continue
+        }
+        if len(incidents) == 0 {
+            continue
+        }
+        c.mu.Lock()
+        if len(c.incidents[ep.ID]) == 0 {
+            c.incidents[ep.ID] = incidents
+        }
+        c.mu.Unlock()
+    }
+}
+
+// copyIncidents returns a deep copy of incidents, nil when empty.
+func copyIncidents(incidents []Incident) []Incident {
+    if len(incidents) == 0 {
+        return nil
+    }
+    out := make([]Incident, len(incidents))
+    for i, in := range incidents {
+        in.Notes = append([]IncidentNote(nil), in.Notes...)
+        out[i] = in
+    }
+    return out
+}
@@ -0,0 +1,162 @@
+package uptime
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ===== JSON Body Assertions =====
+
+// JSONAssertion requires the value at a JSON path of the response body to
+// be present, e.g. "$.items" or "$.data.users[0].id". With MinLen the value
+// must be an array of at least MinLen elements, which catches "healthy but
+// empty" feeds.
+type JSONAssertion struct {
+    Path   string `json:"path"`
+    MinLen int    `json:"min_len,omitempty"`
+}
+
+// jsonPathStep is one object key or array index of a parsed path.
+type jsonPathStep struct {
+    key   string
+    index int // used when key is ""
+}
+
+// parseJSONPath parses a path of the form $.a.b[0].c; the leading "$" is
+// optional.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+    p := strings.TrimPrefix(path, "$")
+    var steps []jsonPathStep
+    for p != "" {
+        switch p[0] {
+        case '.':
+            p = p[1:]
+            n := strings.IndexAny(p, ".[")
+            if n < 0 {
+                n = len(p)
+            }
+            if n == 0 {
+                return nil, fmt.Errorf("json path %q: empty key", path)
+            }
+            steps = append(steps, jsonPathStep{key: p[:n]})
+            p = p[n:]
+        case '[':
+            n := strings.IndexByte(p, ']')
+            if n < 0 {
+                return nil, fmt.Errorf("json path %q: unclosed [", path)
+            }
+            i, err := strconv.Atoi(p[1:n])
+            if err != nil || i < 0 {
+                return nil, fmt.Errorf("json path %q: bad index %q", path, p[1:n])
+            }
+            steps = append(steps, jsonPathStep{index: i})
+            p = p[n+1:]
+        default:
+            return nil, fmt.Errorf("json path %q: expected . or [ at %q", path, p)
+        }
+    }
+    return steps, nil
+}
+
+// lookupJSON returns the value at steps in doc and whether it exists.
+func lookupJSON(doc any, steps []jsonPathStep) (any, bool) {
+    for _, s := range steps {
+        switch v := doc.(type) {
+        case map[string]any:
+            if s.key == "" {
+                return nil, false
+            }
+            var ok bool
+            if doc, ok = v[s.key]; !ok {
+                return nil, false
+            }
+        case []any:
+            if s.key != "" || s.index >= len(v) {
+                return nil, false
+            }
+            doc = v[s.index]
+        default:
+            return nil, false
+        }
+    }
+    return doc, true
+}
+
+func validateJSONAssertions(ep Endpoint) error {
+    for _, a := range ep.JSONAssertions {
+        if _, err := parseJSONPath(a.Path); err != nil {
+            return err
+        }
+        if a.MinLen < 0 {
+            return fmt.Errorf("json assertion %q: negative min_len", a.Path)
+        }
+    }
+    return nil
+}
+
+// checkJSON reads the response body as JSON and validates it against ep's
+// JSON Schema and JSON assertions. It returns "" when the body passes and
+// otherwise the first failure. Bodies that aren't JSON fail the check.
+func (c *Checker) checkJSON(ep Endpoint, resp *http.Response) string {
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxSchemaBody))
+    if err != nil {
+        return fmt.Sprintf("Error reading response body: %v", err)
+    }
+    doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+    if err != nil {
+        return fmt.Sprintf("response is not valid JSON (Content-Type %q): %v", resp.Header.Get("Content-Type"), err)
+    }
+    if hasSchema(ep) {
+        if msg := c.checkSchema(ep, doc); msg != "" {
+            return msg
+        }
+    }
+    return checkJSONAssertions(ep, doc)
+}
+
+// checkJSONAssertions returns the first failed assertion of ep on doc.
+func checkJSONAssertions(ep Endpoint, doc any) string {
+    for _, a := range ep.JSONAssertions {
+        steps, err := parseJSONPath(a.Path)
+        if err != nil {
+            return err.Error()
+        }
+        v, ok := lookupJSON(doc, steps)
+        if !ok {
+            return fmt.Sprintf("json assertion %q: path not found", a.Path)
+        }
+        if a.MinLen == 0 {
+            continue
+        }
+        arr, ok := v.([]any)
+        if !ok {
+            return fmt.Sprintf("json assertion %q: expected an array, got %s", a.Path, jsonKind(v))
+        }
+        if len(arr) < a.MinLen {
+            return fmt.Sprintf("json assertion %q: expected at least %d elements, got %d", a.Path, a.MinLen, len(arr))
+        }
+    }
+    return ""
+}
+
+func jsonKind(v any) string {
+    switch v.(type) {
+    case map[string]any:
+        return "object"
+    case []any:
+        return "array"
+    case string:
+        return "string"
+    case bool:
+        return "boolean"
+    case nil:
+        return "null"
+    }
+    return "number"
+}
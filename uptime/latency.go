@@ -0,0 +1,178 @@
+package uptime
+
+import (
+    "math"
+    "slices"
+    "time"
+)
+
+// ===== Rolling Latency =====
+
+// latencyStats is a running mean and variance of latency (Welford's
+// algorithm), updated in O(1) per result.
+type latencyStats struct {
+    n    int64
+    mean float64 // nanoseconds
+    m2   float64
+}
+
+func (s *latencyStats) add(d time.Duration) {
+    x := float64(d)
+    s.n++
+    delta := x - s.mean
+    s.mean += delta / float64(s.n)
+    s.m2 += delta * (x - s.mean)
+}
+
+func (s *latencyStats) stddev() float64 {
+    if s.n < 2 {
+        return 0
+    }
+    return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// minSizeDeviation is the floor of the size standard deviation, as a
+// fraction of the mean size: a static page has none, and would otherwise
+// have every byte of change flagged.
+const minSizeDeviation = 0.01
+
+// sizeStats is an exponentially weighted moving mean and variance of body
+// sizes over about SizeSamples checks, so sizes that drifted long ago stop
+// widening the norm. The first samples are averaged evenly.
+type sizeStats struct {
+    n        int64
+    mean     float64 // bytes
+    variance float64
+}
+
+func (s *sizeStats) add(x float64, samples int) {
+    s.n++
+    alpha := 2 / float64(samples+1)
+    if s.n <= int64(samples) {
+        alpha = 1 / float64(s.n)
+    }
+    diff := x - s.mean
+    incr := alpha * diff
+    s.mean += incr
+    s.variance = (1 - alpha) * (s.variance + diff*incr)
+}
+
+// stddev returns the standard deviation, at least minSizeDeviation of the
+// mean and one byte.
+func (s *sizeStats) stddev() float64 {
+    return max(math.Sqrt(s.variance), minSizeDeviation*s.mean, 1)
+}
+
+// baselineState learns an endpoint's latency baseline from its first
+// successful checks.
+type baselineState struct {
+    samples []time.Duration
+    value   time.Duration // 0 until learned
+}
+
+// baselineLocked returns the latency baseline of ep, or 0 while it is still
+// being learned. c.mu must be held.
+func (c *Checker) baselineLocked(ep Endpoint) time.Duration {
+    if ep.BaselineLatency > 0 {
+        return ep.BaselineLatency
+    }
+    if b, ok := c.baselines[ep.ID]; ok {
+        return b.value
+    }
+    return 0
+}
+
+// applyBaselineLocked flags res as Degraded when it exceeds the baseline of
+// its endpoint, or feeds it into the baseline while that is being learned.
+// c.mu must be held.
+func (c *Checker) applyBaselineLocked(res *Result) {
+    ep := res.Endpoint
+    if ep.BaselineFactor <= 0 || !res.Success {
+        return
+    }
+    if base := c.baselineLocked(ep); base > 0 {
+        res.Degraded = res.Degraded || float64(res.Latency) > ep.BaselineFactor*float64(base)
+        return
+    }
+    b, ok := c.baselines[ep.ID]
+    if !ok {
+        b = &baselineState{}
+        c.baselines[ep.ID] = b
+    }
+    b.samples = append(b.samples, res.Latency)
+    if len(b.samples) >= ep.BaselineSamples {
+        b.value = medianDuration(b.samples)
+        b.samples = nil
+    }
+}
+
+// RollingLatency returns the mean and sample standard deviation of the
+// latency of every successful check of the endpoint since it was registered
+// or its logs were last cleared. Unlike values derived from GetLogs it is not
+// limited by log retention. Both are 0 when there is no data.
+func (c *Checker) RollingLatency(id string) (mean, stddev time.Duration) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    s, ok := c.latency[id]
+    if !ok {
+        return 0, 0
+    }
+    return time.Duration(s.mean), time.Duration(s.stddev())
+}
+
+// ClearLogs discards the retained results, the incidents (in Storage too,
+// if it implements IncidentStorage) and the rolling latency and size
+// statistics of the endpoint.
+func (c *Checker) ClearLogs(id string) {
+    c.mu.Lock()
+    c.addLogsLocked(-len(c.logs[id]))
+    delete(c.logs, id)
+    delete(c.latency, id)
+    delete(c.sizes, id)
+    _, hadIncidents := c.incidents[id]
+    delete(c.incidents, id)
+    save := c.incidentSaveLocked(id)
+    c.mu.Unlock()
+    if hadIncidents {
+        c.saveIncidents(save)
+    }
+}
+
+// applySizeLocked flags res as a size anomaly (and Degraded) when its body
+// size is more than SizeDeviation standard deviations from the endpoint's
+// moving mean, once SizeSamples sizes are known, then adds the size to the
+// statistics so a lasting change becomes the new norm. c.mu must be held.
+func (c *Checker) applySizeLocked(res *Result) {
+    ep := res.Endpoint
+    if ep.SizeDeviation <= 0 || !res.Success {
+        return
+    }
+    s, ok := c.sizes[ep.ID]
+    if !ok {
+        s = &sizeStats{}
+        c.sizes[ep.ID] = s
+    }
+    x := float64(res.BodySize)
+    if s.n >= int64(ep.SizeSamples) && math.Abs(x-s.mean) > ep.SizeDeviation*s.stddev() {
+        res.SizeAnomaly = true
+        res.Degraded = true
+    }
+    s.add(x, ep.SizeSamples)
+}
+
+// LatencySeries returns up to limit of the newest retained checks of the
+// endpoint as chart points, oldest first. Cancelled checks are left out.
+// Unknown IDs yield an empty series.
+func (c *Checker) LatencySeries(id string, limit int) []LatencyPoint {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    logs := c.logs[id]
+    out := make([]LatencyPoint, 0, min(len(logs), max(limit, 0)))
+    for i := len(logs) - 1; i >= 0 && len(out) < limit; i-- {
+        if r := logs[i]; r.counted() {
+            out = append(out, LatencyPoint{Timestamp: r.Timestamp, Latency: r.Latency, Success: r.Success})
+        }
+    }
+    slices.Reverse(out)
+    return out
+}
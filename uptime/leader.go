@@ -0,0 +1,48 @@
+package uptime
+
+import "time"
+
+// ===== Leader Gating =====
+
+const defaultLeaderCheckInterval = 5 * time.Second
+
+// IsLeader reports whether this checker currently dispatches checks. It is
+// always true without WithLeaderCheck.
+func (c *Checker) IsLeader() bool { return c.leader.Load() }
+
+// evaluateLeadership consults the leader check and logs transitions. On
+// becoming leader the schedule baseline is reset so endpoints idle while
+// passive are not reported overdue.
+func (c *Checker) evaluateLeadership() {
+    now := c.leaderCheck()
+    was := c.leader.Swap(now)
+    if now == was {
+        return
+    }
+    if now {
+        c.mu.Lock()
+        t := time.Now()
+        for id := range c.scheduledAt {
+            c.scheduledAt[id] = t
+        }
+        c.mu.Unlock()
+        c.ilog(LogInfo, "leadership_acquired")
+        return
+    }
+    c.ilog(LogInfo, "leadership_lost")
+}
+
+// leaderLoop re-evaluates leadership every leaderInterval until Stop.
+func (c *Checker) leaderLoop() {
+    defer c.wg.Done()
+    t := time.NewTicker(c.leaderInterval)
+    defer t.Stop()
+    for {
+        select {
+        case <-c.stopCh:
+            return
+        case <-t.C:
+            c.evaluateLeadership()
+        }
+    }
+}
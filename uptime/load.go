@@ -0,0 +1,195 @@
+package uptime
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Loading Endpoint Files =====
+
+// LoadFromFileMode loads endpoints from a JSON file like LoadFromFile, but
+// decodes and validates every entry on its own so one bad entry (a wrong
+// type, a missing URL, a bad frequency) is reported as an *EntryError with
+// its index and line instead of failing the whole file. With LoadFailFast
+// the whole file is validated first and then registered all-or-nothing, as
+// by AddSitesBulkChecked: nothing is registered when any entry is invalid or
+// clashes with a registered endpoint. With LoadBestEffort the valid entries
+// are registered and the errors returned joined together.
+func (c *Checker) LoadFromFileMode(filePath string, mode LoadMode) error {
+    data, err := os.ReadFile(filePath)
+    if err != nil {
+        return err
+    }
+    eps, err := decodeEntries(data)
+    if err != nil {
+        return err
+    }
+
+    var errs []error
+    valid := make([]Endpoint, 0, len(eps))
+    seen := make(map[string]int, len(eps))
+    for _, e := range eps {
+        if e.err != nil {
+            errs = append(errs, &EntryError{Index: e.index, Line: e.line, ID: e.ep.ID, Err: e.err})
+            continue
+        }
+        expanded, err := ExpandTemplates([]Endpoint{e.ep})
+        if err != nil {
+            errs = append(errs, &EntryError{Index: e.index, Line: e.line, ID: e.ep.ID, Err: err})
+            continue
+        }
+        for _, ep := range expanded {
+            err := c.prepareEndpoint(&ep)
+            if err == nil {
+                if first, dup := seen[ep.ID]; dup {
+                    err = fmt.Errorf("%w: %q also used by entry %d", ErrDuplicateID, ep.ID, first)
+                }
+            }
+            if err != nil {
+                errs = append(errs, &EntryError{Index: e.index, Line: e.line, ID: ep.ID, Err: err})
+                continue
+            }
+            seen[ep.ID] = e.index
+            valid = append(valid, ep)
+        }
+    }
+
+    c.ilog(LogInfo, "sites_loaded", zap.Int("count", len(valid)), zap.Int("invalid", len(errs)), zap.String("file", filePath))
+    if mode == LoadFailFast {
+        if len(errs) > 0 {
+            return errors.Join(errs...)
+        }
+        _, err := c.AddSitesBulkChecked(valid)
+        return err
+    }
+    if _, err := c.AddSitesBulk(valid); err != nil {
+        errs = append(errs, err)
+    }
+    return errors.Join(errs...)
+}
+
+// SaveToFile writes the registered endpoints to filePath as a JSON array
+// that LoadFromFile reads back, with frequency and alert grace period in
+// whole seconds and baseline latency and retry backoff in whole
+// milliseconds; it fails, writing nothing, when a value is not a whole
+// number of its unit. Signers are not saved.
+func (c *Checker) SaveToFile(filePath string) error {
+    eps := c.ListSites()
+    for i := range eps {
+        if err := toFileUnits(&eps[i]); err != nil {
+            return err
+        }
+    }
+    data, err := json.MarshalIndent(eps, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(filePath, append(data, '\n'), 0o644)
+}
+
+// fromFileUnits converts durations from the units used in endpoint files:
+// frequency in seconds, baseline latency and retry backoff in milliseconds.
+func fromFileUnits(ep *Endpoint) {
+    ep.Frequency *= time.Second
+    ep.BaselineLatency *= time.Millisecond
+    ep.AlertGracePeriod *= time.Second
+    ep.RetryBackoff *= time.Millisecond
+}
+
+// toFileUnits converts durations to the units used in endpoint files. It
+// reports a duration that the unit can't represent, rather than truncating
+// it (e.g. a 500ms frequency to 0, the default).
+func toFileUnits(ep *Endpoint) error {
+    for _, f := range []struct {
+        name string
+        d    *time.Duration
+        unit time.Duration
+    }{
+        {"frequency", &ep.Frequency, time.Second},
+        {"baseline_latency", &ep.BaselineLatency, time.Millisecond},
+        {"alert_grace_period", &ep.AlertGracePeriod, time.Second},
+        {"retry_backoff", &ep.RetryBackoff, time.Millisecond},
+    } {
+        if *f.d%f.unit != 0 {
+            return fmt.Errorf("endpoint %q: %s %v is not a whole number of %v", ep.ID, f.name, *f.d, f.unit)
+        }
+        *f.d /= f.unit
+    }
+    return nil
+}
+
+type fileEntry struct {
+    ep    Endpoint
+    index int
+    line  int
+    err   error // decoding error
+}
+
+// decodeEntries splits a JSON array into its entries and decodes each one,
+// converting durations from file units. Entries that fail to decode keep the
+// error in fileEntry.err; a malformed array is a fatal error.
+func decodeEntries(data []byte) ([]fileEntry, error) {
+    dec := json.NewDecoder(bytes.NewReader(data))
+    tok, err := dec.Token()
+    if err != nil {
+        return nil, syntaxErrorAt(data, err)
+    }
+    if d, ok := tok.(json.Delim); !ok || d != '[' {
+        return nil, errors.New("endpoints file must contain a JSON array")
+    }
+
+    var entries []fileEntry
+    for i := 0; dec.More(); i++ {
+        start := skipSeparators(data, int(dec.InputOffset()))
+        var raw json.RawMessage
+        if err := dec.Decode(&raw); err != nil {
+            return nil, syntaxErrorAt(data, err)
+        }
+        e := fileEntry{index: i, line: lineAt(data, start)}
+        if err := json.Unmarshal(raw, &e.ep); err != nil {
+            var id struct {
+                ID string `json:"id"`
+            }
+            _ = json.Unmarshal(raw, &id)
+            e.ep = Endpoint{ID: id.ID}
+            e.err = err
+        }
+        fromFileUnits(&e.ep)
+        entries = append(entries, e)
+    }
+    return entries, nil
+}
+
+// syntaxErrorAt adds the line number to JSON syntax errors.
+func syntaxErrorAt(data []byte, err error) error {
+    var se *json.SyntaxError
+    if errors.As(err, &se) {
+        return fmt.Errorf("line %d: %w", lineAt(data, int(se.Offset)), err)
+    }
+    return err
+}
+
+func skipSeparators(data []byte, off int) int {
+    for off < len(data) {
+        switch data[off] {
+        case ' ', '\t', '\r', '\n', ',':
+            off++
+        default:
+            return off
+        }
+    }
+    return off
+}
+
+func lineAt(data []byte, off int) int {
+    if off > len(data) {
+        off = len(data)
+    }
+    return bytes.Count(data[:off], []byte("\n")) + 1
+}
@@ -0,0 +1,110 @@
+package uptime
+
+import (
+    "container/heap"
+    "time"
+)
+
+// ===== Global Log Cap =====
+
+// retentionFor returns the number of in-memory logs kept for ep: its own
+// Retention, or the WithLogRetention default.
+func (c *Checker) retentionFor(ep Endpoint) int {
+    if ep.Retention > 0 {
+        return ep.Retention
+    }
+    return c.logRetention
+}
+
+// trimLogsLocked drops the oldest logs of id beyond keep entries. c.mu must
+// be held.
+func (c *Checker) trimLogsLocked(id string, keep int) {
+    logs := c.logs[id]
+    if len(logs) <= keep {
+        return
+    }
+    // Re-slice rather than clear: results handed out earlier may share the
+    // backing array, which append reallocates once the dropped part is spent.
+    c.logs[id] = logs[len(logs)-keep:]
+    c.noteHeadLocked(id)
+    c.addLogsLocked(keep - len(logs))
+}
+
+// addLogsLocked records n entries added to (negative: removed from) c.logs
+// and, with WithMaxTotalLogEntries, evicts the oldest entries across all
+// endpoints until the total is back under the cap. c.mu must be held.
+func (c *Checker) addLogsLocked(n int) {
+    c.totalLogs += n
+    for c.maxTotalLogs > 0 && c.totalLogs > c.maxTotalLogs {
+        if !c.evictOldestLocked() {
+            return
+        }
+    }
+}
+
+// logHead is the timestamp of an endpoint's oldest retained result, as
+// indexed in logHeads.
+type logHead struct {
+    at time.Time
+    id string
+}
+
+// logHeadHeap orders endpoints by their oldest retained result, for
+// WithMaxTotalLogEntries. Entries are not removed when an endpoint's first
+// result changes; stale ones are skipped when popped.
+type logHeadHeap []logHead
+
+func (h logHeadHeap) Len() int           { return len(h) }
+func (h logHeadHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h logHeadHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *logHeadHeap) Push(x any)        { *h = append(*h, x.(logHead)) }
+func (h *logHeadHeap) Pop() any {
+    old := *h
+    x := old[len(old)-1]
+    *h = old[:len(old)-1]
+    return x
+}
+
+// noteHeadLocked indexes the current first result of id after it changed.
+// It does nothing without WithMaxTotalLogEntries. c.mu must be held.
+func (c *Checker) noteHeadLocked(id string) {
+    if c.maxTotalLogs <= 0 {
+        return
+    }
+    if logs := c.logs[id]; len(logs) > 0 {
+        heap.Push(&c.logHeads, logHead{at: logs[0].Timestamp, id: id})
+    }
+    if len(c.logHeads) > 2*len(c.logs)+64 {
+        // Rebuild from the live heads once stale entries dominate
+        c.logHeads = c.logHeads[:0]
+        for k, logs := range c.logs {
+            if len(logs) > 0 {
+                c.logHeads = append(c.logHeads, logHead{at: logs[0].Timestamp, id: k})
+            }
+        }
+        heap.Init(&c.logHeads)
+    }
+}
+
+// evictOldestLocked drops the oldest retained result of any endpoint. Each
+// endpoint's logs are in timestamp order, so only their first entries, as
+// indexed in logHeads, are compared. c.mu must be held.
+func (c *Checker) evictOldestLocked() bool {
+    for len(c.logHeads) > 0 {
+        h := heap.Pop(&c.logHeads).(logHead)
+        logs := c.logs[h.id]
+        if len(logs) == 0 || !logs[0].Timestamp.Equal(h.at) {
+            continue // stale entry
+        }
+        if len(logs) == 1 {
+            delete(c.logs, h.id)
+        } else {
+            c.logs[h.id] = logs[1:]
+            c.noteHeadLocked(h.id)
+        }
+        c.totalLogs--
+        c.evictedLogs.Add(1)
+        return true
+    }
+    return false
+}
@@ -0,0 +1,119 @@
+package uptime
+
+import (
+    "errors"
+    "slices"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Maintenance Windows =====
+
+// maintenanceWindow is a planned maintenance period for one endpoint or for
+// every endpoint with a tag.
+type maintenanceWindow struct {
+    endpointID string // set for single-endpoint windows
+    tag        string // set for tag windows
+    start, end time.Time
+}
+
+func (w maintenanceWindow) covers(ep Endpoint, t time.Time) bool {
+    if t.Before(w.start) || !t.Before(w.end) {
+        return false
+    }
+    if w.tag != "" {
+        return slices.Contains(ep.Tags, w.tag)
+    }
+    return w.endpointID == ep.ID
+}
+
+// MaintenanceWindow schedules planned maintenance for the endpoint from start
+// until end. While it lasts the endpoint is reported as MAINTENANCE, checks
+// keep running but status transitions, and so audit entries, incidents and
+// failure actions, are held back; a change of status is reported on the
+// first check after the window.
+func (c *Checker) MaintenanceWindow(id string, start, end time.Time) error {
+    if id == "" {
+        return errors.New("missing endpoint id")
+    }
+    return c.addMaintenance(maintenanceWindow{endpointID: id, start: start, end: end})
+}
+
+// MaintenanceWindowForTag is MaintenanceWindow for every endpoint carrying
+// tag in Endpoint.Tags, including endpoints added during the window, e.g.
+// all "payments" endpoints during a payments deploy.
+func (c *Checker) MaintenanceWindowForTag(tag string, start, end time.Time) error {
+    if tag == "" {
+        return errors.New("missing tag")
+    }
+    return c.addMaintenance(maintenanceWindow{tag: tag, start: start, end: end})
+}
+
+func (c *Checker) addMaintenance(w maintenanceWindow) error {
+    if !w.end.After(w.start) {
+        return errors.New("maintenance window must end after it starts")
+    }
+    c.mu.Lock()
+    horizon := c.oldestLogLocked()
+    c.maintenance = slices.DeleteFunc(c.maintenance, func(old maintenanceWindow) bool { return !horizon.Before(old.end) })
+    c.maintenance = append(c.maintenance, w)
+    c.mu.Unlock()
+    c.ilog(LogInfo, "maintenance_scheduled", zap.String("endpoint_id", w.endpointID), zap.String("tag", w.tag),
+        zap.Time("start", w.start), zap.Time("end", w.end))
+    return nil
+}
+
+// oldestLogLocked returns the timestamp of the oldest retained result, or
+// now when there is none. Windows that ended before it no longer cover any
+// result, so UptimeExcludingMaintenance does not need them. c.mu must be
+// held.
+func (c *Checker) oldestLogLocked() time.Time {
+    oldest := time.Now()
+    for _, logs := range c.logs {
+        if len(logs) > 0 && logs[0].Timestamp.Before(oldest) {
+            oldest = logs[0].Timestamp
+        }
+    }
+    return oldest
+}
+
+// inMaintenanceLocked reports whether a maintenance window covers ep at t.
+// c.mu must be held.
+func (c *Checker) inMaintenanceLocked(ep Endpoint, t time.Time) bool {
+    for _, w := range c.maintenance {
+        if w.covers(ep, t) {
+            return true
+        }
+    }
+    return false
+}
+
+func (c *Checker) inMaintenance(ep Endpoint, t time.Time) bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.inMaintenanceLocked(ep, t)
+}
+
+// UptimeExcludingMaintenance returns the percentage (0-100) of checks for the
+// endpoint in the last window (all retained checks when window <= 0) that
+// succeeded, leaving out checks made during planned maintenance: those
+// recorded with Result.Maintenance and those covered by a window scheduled
+// after the fact. Other checks count as for Uptime; it returns 0 when none
+// are left.
+func (c *Checker) UptimeExcludingMaintenance(id string, window time.Duration) float64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    var since time.Time
+    if window > 0 {
+        since = time.Now().Add(-window)
+    }
+    var logs []Result
+    for _, r := range c.logs[id] {
+        if r.Timestamp.Before(since) || r.Maintenance || c.inMaintenanceLocked(r.Endpoint, r.Timestamp) {
+            continue
+        }
+        logs = append(logs, r)
+    }
+    return c.uptimePercent(logs)
+}
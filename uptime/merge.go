@@ -0,0 +1,132 @@
+package uptime
+
+// ===== Multi-Region Merge =====
+
+// MergeMode selects how MergePolicy combines the statuses of one endpoint.
+type MergeMode int
+
+const (
+    MergeWorstStatus MergeMode = iota // DOWN beats PARTIAL beats DEGRADED beats UP; uptime is the lowest
+    MergeQuorumUp                     // UP when Quorum regions are up; uptime is the average
+)
+
+// MergePolicy combines StatusSnapshot outputs of several checkers, e.g. one
+// per region, into a global view.
+type MergePolicy struct {
+    Mode MergeMode
+    // Quorum is the number of regions that must be UP, DEGRADED or PARTIAL for
+    // MergeQuorumUp (default: a majority of the regions reporting a known
+    // status for the endpoint).
+    Quorum int
+}
+
+// MergeSnapshots merges snapshots with worst-status-wins, see MergePolicy.
+func MergeSnapshots(snapshots ...[]EndpointStatus) []EndpointStatus {
+    return MergePolicy{}.Merge(snapshots...)
+}
+
+// Merge returns one status per endpoint ID, in order of first appearance.
+// Each endpoint is merged over the snapshots that contain it; regions that
+// report it as UNKNOWN (no checks yet) or MAINTENANCE are ignored, so they
+// count neither way towards a quorum. It is MAINTENANCE when every other
+// region is UNKNOWN, and UNKNOWN when all are. Checks are summed and the
+// last result is the most recent one.
+func (p MergePolicy) Merge(snapshots ...[]EndpointStatus) []EndpointStatus {
+    var order []string
+    byID := make(map[string][]EndpointStatus)
+    for _, snap := range snapshots {
+        for _, st := range snap {
+            if _, ok := byID[st.Endpoint.ID]; !ok {
+                order = append(order, st.Endpoint.ID)
+            }
+            byID[st.Endpoint.ID] = append(byID[st.Endpoint.ID], st)
+        }
+    }
+    out := make([]EndpointStatus, 0, len(order))
+    for _, id := range order {
+        out = append(out, p.merge(byID[id]))
+    }
+    return out
+}
+
+func (p MergePolicy) merge(regions []EndpointStatus) EndpointStatus {
+    m := EndpointStatus{Endpoint: regions[0].Endpoint, Status: StatusUnknown}
+    var known, maintenance []EndpointStatus
+    for _, st := range regions {
+        m.Checks += st.Checks
+        m.Baseline = max(m.Baseline, st.Baseline)
+        m.EffectiveFrequency = max(m.EffectiveFrequency, st.EffectiveFrequency)
+        m.Quarantined = m.Quarantined || st.Quarantined
+        m.InGracePeriod = m.InGracePeriod || st.InGracePeriod
+        if st.LastCheck.After(m.LastCheck) {
+            m.LastCheck, m.LastResult = st.LastCheck, st.LastResult
+        }
+        if in := st.LastIncident; in != nil && (m.LastIncident == nil || in.Start.After(m.LastIncident.Start)) {
+            m.LastIncident = in
+        }
+        switch st.Status {
+        case StatusUnknown:
+        case StatusMaintenance:
+            maintenance = append(maintenance, st)
+        default:
+            known = append(known, st)
+        }
+    }
+    if len(known) == 0 {
+        if len(maintenance) > 0 {
+            m.Status, m.Uptime = StatusMaintenance, maintenance[0].Uptime
+            for _, st := range maintenance[1:] {
+                m.Uptime = min(m.Uptime, st.Uptime)
+            }
+        }
+        return m
+    }
+
+    switch p.Mode {
+    case MergeQuorumUp:
+        quorum := p.Quorum
+        if quorum <= 0 {
+            quorum = len(known)/2 + 1
+        }
+        upCount, worst, sum := 0, StatusUp, 0.0
+        for _, st := range known {
+            sum += st.Uptime
+            switch st.Status {
+            case StatusUp, StatusDegraded, StatusPartial:
+                upCount++
+                if severity(st.Status) > severity(worst) {
+                    worst = st.Status
+                }
+            }
+        }
+        m.Uptime = sum / float64(len(known))
+        if upCount < quorum {
+            m.Status = StatusDown
+        } else {
+            m.Status = worst
+        }
+    default:
+        m.Status, m.Uptime = known[0].Status, known[0].Uptime
+        for _, st := range known[1:] {
+            if severity(st.Status) > severity(m.Status) {
+                m.Status = st.Status
+            }
+            m.Uptime = min(m.Uptime, st.Uptime)
+        }
+    }
+    return m
+}
+
+func severity(s Status) int {
+    switch s {
+    case StatusDown:
+        return 4
+    case StatusPartial:
+        return 3
+    case StatusDegraded:
+        return 2
+    case StatusUp:
+        return 1
+    }
+    return 0
+}
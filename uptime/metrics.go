@@ -0,0 +1,97 @@
+package uptime
+
+import (
+    "fmt"
+    "io"
+    "runtime"
+    "strings"
+)
+
+// SelfMetrics reports the checker's own resource usage. It is cheap enough
+// to call on every scrape (no GC or heap walk).
+func (c *Checker) SelfMetrics() SelfMetrics {
+    c.mu.RLock()
+    m := SelfMetrics{
+        Workers:    c.numWorkers,
+        Schedulers: len(c.siteStop),
+        Endpoints:  len(c.endpoints),
+        LogEntries: c.totalLogs,
+    }
+    c.mu.RUnlock()
+    m.Goroutines = runtime.NumGoroutine()
+    m.QueueDepth = c.jobs.len()
+    m.QueueCapacity = c.jobs.cap
+    m.InFlight = int(c.inFlight.Load())
+    return m
+}
+
+// ===== Prometheus Text Exposition =====
+
+// WriteMetrics writes the current endpoint status in the Prometheus text
+// exposition format.
+func (c *Checker) WriteMetrics(w io.Writer) error {
+    snap := c.StatusSnapshot()
+
+    var b strings.Builder
+    b.WriteString("# HELP uptime_endpoint_up Whether the last check of the endpoint succeeded (1) or not (0).\n")
+    b.WriteString("# TYPE uptime_endpoint_up gauge\n")
+    for _, st := range snap {
+        if st.LastResult == nil {
+            continue
+        }
+        up := 0
+        if st.LastResult.Success {
+            up = 1
+        }
+        fmt.Fprintf(&b, "uptime_endpoint_up{%s} %d\n", metricLabels(st.Endpoint), up)
+    }
+
+    b.WriteString("# HELP uptime_endpoint_latency_seconds Latency of the last check of the endpoint.\n")
+    b.WriteString("# TYPE uptime_endpoint_latency_seconds gauge\n")
+    for _, st := range snap {
+        if st.LastResult == nil {
+            continue
+        }
+        fmt.Fprintf(&b, "uptime_endpoint_latency_seconds{%s} %g\n", metricLabels(st.Endpoint), st.LastResult.Latency.Seconds())
+    }
+
+    b.WriteString("# HELP uptime_endpoint_uptime_ratio Fraction of retained checks that succeeded.\n")
+    b.WriteString("# TYPE uptime_endpoint_uptime_ratio gauge\n")
+    for _, st := range snap {
+        fmt.Fprintf(&b, "uptime_endpoint_uptime_ratio{%s} %g\n", metricLabels(st.Endpoint), st.Uptime/100)
+    }
+
+    b.WriteString("# HELP uptime_endpoint_checks Number of retained checks for the endpoint.\n")
+    b.WriteString("# TYPE uptime_endpoint_checks gauge\n")
+    for _, st := range snap {
+        fmt.Fprintf(&b, "uptime_endpoint_checks{%s} %d\n", metricLabels(st.Endpoint), st.Checks)
+    }
+
+    self := c.SelfMetrics()
+    for _, g := range []struct {
+        name, help string
+        value      int
+    }{
+        {"uptime_self_goroutines", "Goroutines in the process.", self.Goroutines},
+        {"uptime_self_workers", "Configured worker pool size.", self.Workers},
+        {"uptime_self_schedulers", "Running per-endpoint scheduler goroutines.", self.Schedulers},
+        {"uptime_self_queue_depth", "Checks waiting for a worker.", self.QueueDepth},
+        {"uptime_self_queue_capacity", "Job queue capacity.", self.QueueCapacity},
+        {"uptime_self_in_flight", "Checks currently executing.", self.InFlight},
+        {"uptime_self_endpoints", "Registered endpoints.", self.Endpoints},
+        {"uptime_self_log_entries", "Results retained in memory across all endpoints.", self.LogEntries},
+    } {
+        fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, g.value)
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+func metricLabels(ep Endpoint) string {
+    return fmt.Sprintf(`id="%s",name="%s"`, escapeLabel(ep.ID), escapeLabel(ep.Name))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
@@ -0,0 +1,162 @@
+package uptime
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Multi-Path Checks =====
+
+// parseNetworkPaths returns the valid paths, with source and resolver
+// addresses in canonical form, and a description of each invalid one. A
+// path needs a unique name, a network of "", "tcp", "tcp4" or "tcp6", and
+// an IP source address when set.
+func parseNetworkPaths(paths []NetworkPath) (valid []NetworkPath, invalid []string) {
+    seen := make(map[string]bool)
+    for _, p := range paths {
+        var problem string
+        switch {
+        case p.Name == "":
+            problem = "missing name"
+        case seen[p.Name]:
+            problem = "duplicate name"
+        }
+        switch p.Network {
+        case "", "tcp", "tcp4", "tcp6":
+        default:
+            problem = fmt.Sprintf("unknown network %q", p.Network)
+        }
+        if p.Source != "" {
+            src, bad := parseSourceAddrs([]string{p.Source})
+            if len(bad) > 0 {
+                problem = fmt.Sprintf("invalid source %q", p.Source)
+            } else {
+                p.Source = src[0]
+            }
+        }
+        if p.Resolver != "" {
+            p.Resolver = normalizeResolverAddrs([]string{p.Resolver})[0]
+        }
+        if problem != "" {
+            invalid = append(invalid, fmt.Sprintf("%s: %s", p.Name, problem))
+            continue
+        }
+        seen[p.Name] = true
+        valid = append(valid, p)
+    }
+    return valid, invalid
+}
+
+// logInvalidNetworkPaths reports the WithNetworkPaths entries that were
+// dropped.
+func (c *Checker) logInvalidNetworkPaths() {
+    if len(c.invalidNetworkPaths) > 0 {
+        c.ilog(LogError, "network_paths_invalid", zap.Strings("paths", c.invalidNetworkPaths))
+    }
+}
+
+func (c *Checker) validateMultiPath(ep Endpoint) error {
+    if !ep.MultiPath {
+        return nil
+    }
+    switch {
+    case len(c.networkPaths) == 0:
+        return errors.New("multi_path needs network paths (see WithNetworkPaths)")
+    case len(ep.URLs) > 0:
+        return errors.New("multi_path and urls are mutually exclusive")
+    case ep.Type != "" && ep.Type != TypeHTTP:
+        return fmt.Errorf("multi_path is not supported for %s endpoints", ep.Type)
+    }
+    return nil
+}
+
+// checkMultiPath checks ep over every network path in parallel. The check
+// passes when any path passes, and is flagged Partial when some paths fail,
+// e.g. IPv4 works but IPv6 doesn't. With ExpectUnreachable it passes only
+// when every path finds the endpoint unreachable, as one open path is a
+// hole. Latency is the wall time of the whole check.
+func (c *Checker) checkMultiPath(ctx context.Context, ep Endpoint) Result {
+    start := time.Now()
+    paths := c.networkPaths
+    subs := make([]Result, len(paths))
+    var wg sync.WaitGroup
+    for i, p := range paths {
+        wg.Add(1)
+        go func(i int, p NetworkPath) {
+            defer wg.Done()
+            subs[i] = c.checkURLVia(ctx, ep, p)
+        }(i, p)
+    }
+    wg.Wait()
+
+    res := Result{
+        Endpoint:   ep,
+        Timestamp:  start,
+        SubResults: make([]SubResult, len(subs)),
+    }
+    passed := 0
+    for i, r := range subs {
+        res.SubResults[i] = SubResult{
+            Path:       paths[i].Name,
+            URL:        ep.URL,
+            StatusCode: r.StatusCode,
+            Latency:    r.Latency,
+            Success:    r.Success,
+            Error:      r.Error,
+        }
+        res.Inverted = r.Inverted
+        if r.Success {
+            if passed == 0 {
+                res.StatusCode = r.StatusCode
+            }
+            passed++
+        }
+    }
+    res.Latency = time.Since(start)
+    res.Success = passed > 0
+    if ep.ExpectUnreachable {
+        res.Success = passed == len(subs)
+    }
+    res.Partial = res.Success && passed < len(subs)
+    if !res.Success {
+        f := slices.IndexFunc(subs, func(r Result) bool { return !r.Success })
+        res.StatusCode = subs[f].StatusCode
+        res.FailureKind = subs[f].FailureKind
+        res.Error = fmt.Sprintf("%d of %d network paths failed: %s", len(subs)-passed, len(subs), subs[f].Error)
+    }
+    return res
+}
+
+// pathDialContext returns the dial function for transports of a network
+// path: bound to its source address, resolving through its resolver (or
+// WithResolver), and restricted to its network.
+func (c *Checker) pathDialContext(key transportKey) func(ctx context.Context, network, addr string) (net.Conn, error) {
+    d := &net.Dialer{
+        Timeout:   30 * time.Second,
+        KeepAlive: 30 * time.Second,
+        Resolver:  c.resolver,
+    }
+    if key.source != "" {
+        d.LocalAddr = &net.TCPAddr{IP: net.ParseIP(key.source)}
+    }
+    if key.resolver != "" {
+        d.Resolver = c.newResolver([]string{key.resolver})
+    }
+    if key.network == "" {
+        return d.DialContext
+    }
+    return func(ctx context.Context, network, addr string) (net.Conn, error) {
+        if strings.HasPrefix(network, "tcp") {
+            network = key.network
+        }
+        return d.DialContext(ctx, network, addr)
+    }
+}
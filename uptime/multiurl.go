@@ -0,0 +1,120 @@
+package uptime
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// ===== Multi-URL Endpoints =====
+
+const defaultOutlierFactor = 3
+
+// checkMulti checks every URL of ep in parallel and aggregates the outcome:
+// the check passes when at least ep.Quorum URLs pass. Latency is the wall
+// time of the whole check.
+func (c *Checker) checkMulti(ctx context.Context, ep Endpoint) Result {
+    start := time.Now()
+    subs := make([]Result, len(ep.URLs))
+    var wg sync.WaitGroup
+    for i, u := range ep.URLs {
+        sub := ep
+        sub.URL = u
+        sub.URLs = nil
+        wg.Add(1)
+        go func(i int, sub Endpoint) {
+            defer wg.Done()
+            subs[i] = c.checkURL(ctx, sub)
+        }(i, sub)
+    }
+    wg.Wait()
+
+    res := Result{
+        Endpoint:   ep,
+        Timestamp:  start,
+        SubResults: make([]SubResult, len(subs)),
+    }
+    passed := 0
+    for i, r := range subs {
+        res.SubResults[i] = SubResult{
+            URL:        r.Endpoint.URL,
+            StatusCode: r.StatusCode,
+            Latency:    r.Latency,
+            Success:    r.Success,
+            Error:      r.Error,
+        }
+        res.ProxyUsed = res.ProxyUsed || r.ProxyUsed
+        res.Inverted = r.Inverted
+        if r.Success {
+            if passed == 0 {
+                res.StatusCode = r.StatusCode
+            }
+            passed++
+        }
+    }
+    if passed == 0 && len(subs) > 0 {
+        res.StatusCode = subs[0].StatusCode
+    }
+    markLatencyOutliers(&res, ep.OutlierFactor)
+    res.Latency = time.Since(start)
+    res.Success = passed >= ep.Quorum
+    if !res.Success {
+        res.Error = fmt.Sprintf("quorum not met: %d/%d urls passed, need %d", passed, len(subs), ep.Quorum)
+        for _, r := range subs {
+            if !r.Success {
+                res.FailureKind = r.FailureKind
+                break
+            }
+        }
+    }
+    return res
+}
+
+// markLatencyOutliers sets FastestURL and flags passing URLs slower than
+// factor times the median latency of the other passing URLs. Failed URLs are
+// not compared; they are already reported by their own Success.
+func markLatencyOutliers(res *Result, factor float64) {
+    var ok []int
+    for i, s := range res.SubResults {
+        if s.Success {
+            ok = append(ok, i)
+        }
+    }
+    if len(ok) == 0 {
+        return
+    }
+    fastest := ok[0]
+    for _, i := range ok[1:] {
+        if res.SubResults[i].Latency < res.SubResults[fastest].Latency {
+            fastest = i
+        }
+    }
+    res.FastestURL = res.SubResults[fastest].URL
+    if len(ok) < 2 {
+        return
+    }
+    for _, i := range ok {
+        others := make([]time.Duration, 0, len(ok)-1)
+        for _, j := range ok {
+            if j != i {
+                others = append(others, res.SubResults[j].Latency)
+            }
+        }
+        if float64(res.SubResults[i].Latency) > factor*float64(medianDuration(others)) {
+            res.SubResults[i].Outlier = true
+            res.Outliers = append(res.Outliers, res.SubResults[i].URL)
+        }
+    }
+}
+
+func medianDuration(ds []time.Duration) time.Duration {
+    sorted := append([]time.Duration(nil), ds...)
+    sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+    n := len(sorted)
+    if n%2 == 1 {
+        return sorted[n/2]
+    }
+    return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,142 @@
+package uptime
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Notifiers =====
+
+const (
+    defaultNotifyTimeout = 10 * time.Second
+    notifierQueueSize    = 64
+)
+
+// Notifier delivers status transitions to an alerting integration such as
+// a webhook, chat or paging service. Notify gets each TransitionEvent (see
+// there for the payload) and should return once it is delivered or ctx is
+// done; its error is logged.
+type Notifier interface {
+    Notify(ctx context.Context, event TransitionEvent) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, event TransitionEvent) error
+
+func (f NotifierFunc) Notify(ctx context.Context, event TransitionEvent) error { return f(ctx, event) }
+
+// notifierQueue holds the undelivered events of one registered Notifier.
+type notifierQueue struct {
+    n       Notifier
+    initial bool // also gets transitions from UNKNOWN
+    events  chan TransitionEvent
+}
+
+// RegisterNotifier adds n to the notifiers of status transitions. Every
+// notifier receives every transition except those from UNKNOWN, and none
+// while the endpoint is quarantined or within its AlertGracePeriod; a DOWN
+// transition held back by the grace period is delivered when the period
+// ends with the endpoint still DOWN. Each notifier has its own goroutine
+// and queue, so a slow one delays only itself; events are delivered in
+// order, each with the WithNotifyTimeout deadline, and discarded with a log
+// entry when its queue is full. Shutdown delivers the events still queued
+// until its ctx is done; Stop discards them. Notifiers registered after
+// Stop are ignored.
+func (c *Checker) RegisterNotifier(n Notifier) {
+    if n == nil {
+        return
+    }
+    c.addNotifier(n, false)
+}
+
+// addNotifier registers n and starts its delivery goroutine; with initial
+// it also gets transitions from UNKNOWN.
+func (c *Checker) addNotifier(n Notifier, initial bool) {
+    q := &notifierQueue{n: n, initial: initial, events: make(chan TransitionEvent, notifierQueueSize)}
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if !c.isRunning() {
+        return
+    }
+    c.notifiers = append(c.notifiers, q)
+    c.wg.Add(1)
+    go c.notifierLoop(q)
+}
+
+// notify queues ev for every registered notifier without blocking.
+func (c *Checker) notify(ev TransitionEvent) {
+    c.mu.RLock()
+    queues := c.notifiers
+    c.mu.RUnlock()
+    for _, q := range queues {
+        if ev.From == StatusUnknown && !q.initial {
+            continue
+        }
+        select {
+        case q.events <- ev:
+        default:
+            c.ilog(LogError, "notification_dropped", notifyFields(q.n, ev)...)
+        }
+    }
+}
+
+// notifierLoop delivers the events of q until the checker stops, then
+// those still queued.
+func (c *Checker) notifierLoop(q *notifierQueue) {
+    defer c.wg.Done()
+    for {
+        select {
+        case <-c.stopCh:
+            c.drainNotifier(q)
+            return
+        case ev := <-q.events:
+            c.deliver(q.n, ev)
+        }
+    }
+}
+
+// drainNotifier delivers the events left in q at shutdown, discarding them
+// once the Shutdown context is done.
+func (c *Checker) drainNotifier(q *notifierQueue) {
+    for {
+        select {
+        case ev := <-q.events:
+            if c.notifyCtx.Err() != nil {
+                c.ilog(LogError, "notification_dropped", notifyFields(q.n, ev, zap.String("reason", "shutdown"))...)
+                continue
+            }
+            c.deliver(q.n, ev)
+        default:
+            return
+        }
+    }
+}
+
+// deliver calls n for ev with the notify timeout, logging its outcome.
+func (c *Checker) deliver(n Notifier, ev TransitionEvent) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "notifier_panic", notifyFields(n, ev, zap.Any("panic", r))...)
+        }
+    }()
+    ctx, cancel := context.WithTimeout(c.notifyCtx, c.notifyTimeout)
+    defer cancel()
+    start := time.Now()
+    if err := n.Notify(ctx, ev); err != nil {
+        c.ilog(LogError, "notify_failed", notifyFields(n, ev, zap.Error(err), zap.Duration("took", time.Since(start)))...)
+        return
+    }
+    c.ilog(LogDebug, "notified", notifyFields(n, ev, zap.Duration("took", time.Since(start)))...)
+}
+
+func notifyFields(n Notifier, ev TransitionEvent, extra ...zap.Field) []zap.Field {
+    return append([]zap.Field{
+        zap.String("notifier", fmt.Sprintf("%T", n)),
+        zap.String("endpoint_id", ev.EndpointID),
+        zap.String("from", string(ev.From)),
+        zap.String("to", string(ev.To)),
+    }, extra...)
+}
@@ -0,0 +1,43 @@
+package uptime
+
+import (
+    "go.uber.org/zap"
+)
+
+// ===== Result Hooks =====
+
+// OnResult registers fn to be called with every recorded result, in
+// addition to hooks registered before. Hooks run synchronously on the
+// goroutine that finished the check, in registration order, after the
+// result is stored in the logs and Storage and its transition evaluated,
+// and before it is sent on the Results channel. Deduplicated results and
+// results of removed endpoints are not passed on. A slow hook delays the
+// worker, so hand long work off to another goroutine; a panicking hook is
+// recovered and logged.
+func (c *Checker) OnResult(fn func(Result)) {
+    if fn == nil {
+        return
+    }
+    c.mu.Lock()
+    c.resultHooks = append(c.resultHooks, fn)
+    c.mu.Unlock()
+}
+
+// runResultHooks calls the OnResult hooks with res.
+func (c *Checker) runResultHooks(res Result) {
+    c.mu.RLock()
+    hooks := c.resultHooks
+    c.mu.RUnlock()
+    for i, fn := range hooks {
+        c.callResultHook(i, fn, res)
+    }
+}
+
+func (c *Checker) callResultHook(i int, fn func(Result), res Result) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "result_hook_panic", endpointFields(res.Endpoint, zap.Int("hook", i), zap.Any("panic", r))...)
+        }
+    }()
+    fn(res)
+}
@@ -3,7 +3,9 @@
 package uptime
 
 import (
+    "context"
     "fmt"
+    "net/http"
     "time"
 
     "go.uber.org/zap"
@@ -28,11 +30,324 @@ func WithResultBuffer(size int) Option {
     return func(c *Checker) { c.results = make(chan Result, size) }
 }
 
+// WithProxy routes checks through the given http://, https://, socks5:// or
+// socks5h:// proxy. Endpoint.Proxy takes precedence when set.
+func WithProxy(proxyURL string) Option {
+    return func(c *Checker) { c.proxy = proxyURL }
+}
+
+// WithResolver resolves every check's host names with the given DNS
+// servers ("8.8.8.8", "[2001:4860:4860::8888]:53"; port 53 is assumed when
+// omitted) instead of the system resolver. Servers are used round-robin.
+// An empty list keeps the system resolver.
+func WithResolver(addresses []string) Option {
+    return func(c *Checker) { c.resolverAddrs = normalizeResolverAddrs(addresses) }
+}
+
+// WithSourceAddresses binds the connections of HTTP checks to the given
+// local IP addresses, one per check round-robin, e.g. to spread load or test
+// each uplink of a multi-homed host. Entries that are not IP addresses are
+// dropped and logged. Result.SourceAddr records the address used.
+func WithSourceAddresses(addresses []string) Option {
+    return func(c *Checker) { c.sourceAddrs, c.invalidSourceAddrs = parseSourceAddrs(addresses) }
+}
+
+// WithNetworkPaths sets the network paths that endpoints with MultiPath are
+// checked over, e.g. IPv4 and IPv6, or resolvers in different regions. A
+// check passing on some paths but not all is flagged Partial and the
+// endpoint reported PARTIAL. Invalid paths are dropped and logged.
+func WithNetworkPaths(paths ...NetworkPath) Option {
+    return func(c *Checker) { c.networkPaths, c.invalidNetworkPaths = parseNetworkPaths(paths) }
+}
+
+// WithScheduledUptimeOnly excludes results of manual, confirmation and
+// other non-scheduled checks from uptime figures, so extra checks around an
+// outage don't skew them.
+func WithScheduledUptimeOnly() Option {
+    return func(c *Checker) { c.scheduledUptimeOnly = true }
+}
+
+// WithDeferredStart makes Start launch the workers without scheduling any
+// checks until BeginScheduling is called, so every endpoint can be
+// registered first:
+//
+//    c := uptime.New(uptime.WithDeferredStart())
+//    c.Start()
+//    c.AddSitesBulk(loadFromDB())
+//    c.BeginScheduling()
+func WithDeferredStart() Option {
+    return func(c *Checker) { c.deferStart = true }
+}
+
+// WithLeaderCheck gates scheduled checks on fn, for active-passive
+// deployments: while fn reports false no checks are dispatched, so only the
+// active instance checks and alerts. fn is called on Start and then every
+// WithLeaderCheckInterval (default 5s); transitions are logged.
+func WithLeaderCheck(fn func() bool) Option {
+    return func(c *Checker) { c.leaderCheck = fn }
+}
+
+// WithLeaderCheckInterval sets how often WithLeaderCheck is re-evaluated.
+func WithLeaderCheckInterval(d time.Duration) Option {
+    return func(c *Checker) {
+        if d > 0 {
+            c.leaderInterval = d
+        }
+    }
+}
+
+// WithLoadShedding skips due checks of endpoints with Priority <= 0 while
+// more than maxQueueDepth checks are waiting for a worker, so the backlog of
+// important (Priority > 0) endpoints drains first. Shedding stops once the
+// queue is back at or under the limit; shed checks are counted in Stats.
+// A full queue still drops checks of any priority (see WithQueueSize).
+func WithLoadShedding(maxQueueDepth int) Option {
+    return func(c *Checker) { c.shedDepth = maxQueueDepth }
+}
+
+// WithCheckBudget caps the checks dispatched across all endpoints to n per
+// window of length per, e.g. for synthetic monitoring quotas. A due check
+// over the cap is deferred; when the next window opens, deferred checks are
+// dispatched highest Priority first and the rest wait another window. At
+// most one check per endpoint is pending. Retries and on-demand checks
+// (RecheckFailing, RunOnce) are never deferred but use up budget, which may
+// take a window over n; scheduled checks then wait for the next window.
+// Usage is reported in Stats.
+func WithCheckBudget(n int, per time.Duration) Option {
+    return func(c *Checker) {
+        if n > 0 && per > 0 {
+            c.budget = &checkBudget{limit: n, per: per, deferred: make(map[string]struct{})}
+        }
+    }
+}
+
+// WithUserAgentRotation sends each check with the next User-Agent from
+// agents, round-robin, for sites that block a fixed client fingerprint. The
+// agent used is recorded in Result.UserAgent. A User-Agent in
+// Endpoint.Headers still takes precedence.
+func WithUserAgentRotation(agents []string) Option {
+    return func(c *Checker) { c.userAgents = append([]string(nil), agents...) }
+}
+
+// WithStorage persists every result to s in addition to the in-memory logs.
+// When an endpoint is registered without retained results, its recent
+// results (up to the log retention) are restored from s.
+func WithStorage(s Storage) Option {
+    return func(c *Checker) { c.storage = s }
+}
+
+// WithTransitionAudit appends one JSON line (a TransitionEvent) per status
+// transition between UP, DOWN and DEGRADED to the file at path, independent
+// of log retention. The file is rotated at 10MB keeping 5 backups, see
+// WithTransitionAuditRotation.
+func WithTransitionAudit(path string) Option {
+    return func(c *Checker) {
+        if path == "" {
+            c.audit = nil
+            return
+        }
+        c.audit = &auditSink{path: path, maxBytes: defaultAuditMaxBytes, backups: defaultAuditBackups}
+    }
+}
+
+// WithTransitionAuditRotation rotates the transition audit file once it
+// would exceed maxBytes, keeping backups old files (path.1 is the newest).
+// With backups 0 the file is truncated instead. Apply it after
+// WithTransitionAudit.
+func WithTransitionAuditRotation(maxBytes int64, backups int) Option {
+    return func(c *Checker) {
+        if c.audit == nil {
+            return
+        }
+        if maxBytes > 0 {
+            c.audit.maxBytes = maxBytes
+        }
+        if backups >= 0 {
+            c.audit.backups = backups
+        }
+    }
+}
+
+// WithTimeoutBackoff stops endpoints that keep hanging from tying up
+// workers: after `after` consecutive timed-out checks the endpoint is
+// checked at twice its Frequency, doubling with each further timeout up to
+// maxInterval (default 30m). The first check that does not time out
+// restores the configured Frequency. Entering and leaving backoff is logged.
+func WithTimeoutBackoff(after int, maxInterval time.Duration) Option {
+    return func(c *Checker) {
+        if after < 1 {
+            c.backoff = nil
+            return
+        }
+        if maxInterval <= 0 {
+            maxInterval = defaultBackoffMax
+        }
+        c.backoff = &timeoutBackoff{after: after, max: maxInterval}
+    }
+}
+
+// WithRequestDecorator calls fn on every check request just before it is
+// sent, e.g. to add an auth scheme, custom headers or tracing context. It
+// runs after all built-in headers are set, so it can override them, and
+// before Endpoint.Signer, so decorated requests are signed as sent. A
+// panicking decorator fails the check with FailureOther.
+func WithRequestDecorator(fn func(*http.Request, Endpoint)) Option {
+    return func(c *Checker) { c.requestDecorator = fn }
+}
+
+// WithFaultInjection calls fn before every scheduled check, for rehearsing
+// alerting and runbooks. When fn returns an error the endpoint is not
+// contacted and the check fails with that error instead, marked with
+// Origin OriginInjected; injected results drive status, incidents and
+// failure actions like real ones but are left out of uptime figures.
+// FaultRate fails a random fraction of checks. Disabled by default; the
+// checker logs a warning when it is enabled.
+func WithFaultInjection(fn func(Endpoint) error) Option {
+    return func(c *Checker) { c.faultInjector = fn }
+}
+
+// WithIDGenerator sets the function that assigns IDs to endpoints added
+// without one by AddSite, AddSitesBulk and the file loaders, e.g.
+// uuid.NewString for random IDs. It runs after defaults are applied and
+// URLs normalized. The default is DefaultID, which derives a stable ID from
+// the method, URL and other target fields.
+func WithIDGenerator(fn func(Endpoint) string) Option {
+    return func(c *Checker) { c.idGenerator = fn }
+}
+
+// WithFailureAction runs fn, e.g. to restart a service or call a recovery
+// webhook, when an endpoint transitions to DOWN. It is dispatched as a
+// notifier (see RegisterNotifier), so quarantine and the alert grace
+// period hold it back, but it also runs for an endpoint found DOWN by its
+// first check. fn gets the result that took the endpoint down and runs in
+// its own goroutine (at most 4 at once); its outcome is recorded in
+// internal logs. It runs at most once per endpoint per debounce window
+// (default 5m). Its context is cancelled after 30s, when it is logged as
+// timed out, or when Shutdown gives up; see WithFailureActionTimeout and
+// WithFailureActionDebounce. Shutdown waits for running actions.
+func WithFailureAction(fn func(context.Context, Result) error) Option {
+    return func(c *Checker) {
+        if fn == nil {
+            c.failureAction = nil
+            return
+        }
+        c.failureAction = &failureAction{
+            fn:       fn,
+            timeout:  defaultFailureActionTimeout,
+            debounce: defaultFailureActionDebounce,
+            sem:      make(chan struct{}, maxConcurrentFailureActions),
+            last:     make(map[string]time.Time),
+        }
+    }
+}
+
+// WithFailureActionTimeout sets how long a failure action may run before its
+// context is cancelled and it is logged as timed out. Apply it after
+// WithFailureAction.
+func WithFailureActionTimeout(d time.Duration) Option {
+    return func(c *Checker) {
+        if c.failureAction != nil && d > 0 {
+            c.failureAction.timeout = d
+        }
+    }
+}
+
+// WithFailureActionDebounce sets the minimum time between failure actions
+// for one endpoint, so a flapping endpoint doesn't trigger remediation on
+// every relapse. Apply it after WithFailureAction.
+func WithFailureActionDebounce(d time.Duration) Option {
+    return func(c *Checker) {
+        if c.failureAction != nil && d >= 0 {
+            c.failureAction.debounce = d
+        }
+    }
+}
+
+// WithEscalation re-notifies the registered notifiers while an outage
+// lasts: after the DOWN transition, again after each interval of schedule,
+// the last interval repeating, e.g. 5m, 15m, 1h. Re-notifications are
+// TransitionEvents from DOWN to DOWN with Escalation counting them. When
+// the endpoint recovers, pending re-notifications are cancelled and the
+// recovery is notified once, as the transition it is. The failure action
+// is not affected.
+func WithEscalation(schedule []time.Duration) Option {
+    return func(c *Checker) {
+        for _, d := range schedule {
+            if d <= 0 {
+                return
+            }
+        }
+        c.escalation = append([]time.Duration(nil), schedule...)
+    }
+}
+
+// WithNotifyTimeout bounds each delivery of a transition to a registered
+// Notifier (default 10s); the context passed to Notify ends after d.
+func WithNotifyTimeout(d time.Duration) Option {
+    return func(c *Checker) {
+        if d > 0 {
+            c.notifyTimeout = d
+        }
+    }
+}
+
+// WithDefaultHeaders sets headers sent with every HTTP check. Endpoint.Headers
+// are applied afterwards and win on conflicting keys.
+func WithDefaultHeaders(headers map[string]string) Option {
+    return func(c *Checker) {
+        c.defaultHeaders = make(map[string]string, len(headers))
+        for k, v := range headers {
+            c.defaultHeaders[k] = v
+        }
+    }
+}
+
+// WithDuplicatePolicy sets how endpoints with an already-registered ID are
+// handled (default DuplicateReject).
+func WithDuplicatePolicy(p DuplicatePolicy) Option {
+    return func(c *Checker) { c.duplicatePolicy = p }
+}
+
+// WithQueueSize sets the capacity of the pending job queue (default 1000).
+// Due checks that don't fit are dropped (see OnDrop).
+func WithQueueSize(n int) Option {
+    return func(c *Checker) {
+        if n > 0 {
+            c.jobs.cap = n
+        }
+    }
+}
+
+// WithPriorityAging mitigates starvation of low-priority endpoints: a queued
+// job gains one priority level for every d it waits. Zero (default) means
+// strict priority order.
+func WithPriorityAging(d time.Duration) Option {
+    return func(c *Checker) { c.jobs.aging = d }
+}
+
+// WithURLNormalization normalizes endpoint URLs at registration: scheme and
+// host are lowercased, default ports (:80 for http, :443 for https) dropped,
+// an empty path becomes "/", and trailing slashes are handled per n. The
+// normalized URL is also used to detect duplicates (ErrDuplicateURL, or
+// replacement under DuplicateReplace). Endpoint.OriginalURL keeps the URL as
+// supplied.
+func WithURLNormalization(n URLNormalization) Option {
+    return func(c *Checker) { c.urlNormalization = &n }
+}
+
 // enable/disable internal logs
 func WithInternalLogs(enabled bool) Option {
     return func(c *Checker) { c.enableInternalLogs = enabled }
 }
 
+// WithInternalLogLevel sets the verbosity of internal logs independently of
+// site-check logs: LogInfo keeps lifecycle events (workers, scheduler,
+// registrations), LogDebug (default) adds per-job events, LogError keeps only
+// internal errors. Internal logs must still be enabled via WithInternalLogs.
+func WithInternalLogLevel(level LogLevel) Option {
+    return func(c *Checker) { c.internalLogLevel = level }
+}
+
 // Deprecated: prefer LogConsole/LogFile/DisableLogs.
 // WithZapLogger sets up a logger. If filePath is empty, logs to console.
 func WithZapLogger(filePath string) Option {
@@ -62,6 +377,67 @@ func WithLogRetention(n int) Option {
     return func(c *Checker) { if n > 0 { c.logRetention = n } }
 }
 
+// WithMaxTotalLogEntries caps the in-memory logs of all endpoints together
+// at n entries. Past the cap the oldest entries are evicted, whichever
+// endpoint they belong to; the per-endpoint WithLogRetention cap still
+// applies first, so an endpoint may keep fewer entries than its retention.
+func WithMaxTotalLogEntries(n int) Option {
+    return func(c *Checker) { if n > 0 { c.maxTotalLogs = n } }
+}
+
+// WithAutoQuarantine quarantines an endpoint that changes status flaps
+// times within window. A quarantined endpoint is still checked and its
+// transitions recorded, but the failure action is not run and it is listed
+// by Quarantined for review. It is released once stable passes without a
+// transition.
+func WithAutoQuarantine(flaps int, window, stable time.Duration) Option {
+    return func(c *Checker) {
+        if flaps > 0 && window > 0 && stable > 0 {
+            c.quarantine = &autoQuarantine{flaps: flaps, window: window, stable: stable}
+        }
+    }
+}
+
+// WithHeartbeat sends a GET request to url every interval while the
+// checker is Healthy, for a dead man's switch service such as
+// Healthchecks.io that alerts when the pings stop, e.g. because the
+// scheduler stalled or the process died. Failed pings are logged. The pings
+// stop with Stop.
+func WithHeartbeat(url string, interval time.Duration) Option {
+    return func(c *Checker) {
+        if url != "" && interval > 0 {
+            c.heartbeat = &heartbeat{url: url, interval: interval, client: &http.Client{Timeout: min(interval, 10*time.Second)}}
+        }
+    }
+}
+
+// WithResultDedup drops a result that repeats the endpoint's last one, with
+// the same outcome, status code and failure kind, when both fall in the same
+// window-aligned period, e.g. a confirmation recheck right after a check.
+// Dropped results are not stored, published or logged and are counted in
+// Stats.DedupedResults. A result that changes the endpoint's status, such as
+// UP to DEGRADED, and a failure counting towards FailureThreshold are always
+// kept, but with a window close to the check frequency most results of a
+// steady endpoint are dropped. Disabled by default.
+func WithResultDedup(window time.Duration) Option {
+    return func(c *Checker) { if window > 0 { c.dedupWindow = window } }
+}
+
+// WithRetries retries a check that failed with a network error or an
+// unexpected status up to n more times, waiting backoff between attempts,
+// before its result is recorded. Only the final attempt's result is
+// emitted, with Result.Attempts set. It applies to endpoints that do not
+// set Retries themselves. A worker is held for the whole series.
+func WithRetries(n int, backoff time.Duration) Option {
+    return func(c *Checker) {
+        if n < 0 {
+            n = 0
+        }
+        c.retries = n
+        c.retryBackoff = max(backoff, 0)
+    }
+}
+
 // Log configures outputs in a single call.
 // Values: "console" (stdout), "none" (disable), or one/more file paths.
 func Log(outputs ...string) Option {
@@ -0,0 +1,84 @@
+package uptime
+
+import (
+    "slices"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Flap Quarantine =====
+
+// autoQuarantine configures WithAutoQuarantine.
+type autoQuarantine struct {
+    flaps  int           // transitions within window that quarantine an endpoint
+    window time.Duration // period transitions are counted over
+    stable time.Duration // time without transitions that releases it
+}
+
+// flapState tracks an endpoint's recent transitions.
+type flapState struct {
+    transitions []time.Time // within the window, oldest first
+    quarantined bool
+    since       time.Time // when the endpoint was quarantined
+}
+
+// observeFlaps counts the transition ev caused by res, if any, and puts the
+// endpoint into or releases it from quarantine. It reports whether the
+// endpoint is quarantined after res.
+func (c *Checker) observeFlaps(res Result, ev *TransitionEvent) bool {
+    q := c.quarantine
+    if q == nil {
+        return false
+    }
+    ep := res.Endpoint
+    c.mu.Lock()
+    st := c.flaps[ep.ID]
+    if st == nil {
+        st = &flapState{}
+        c.flaps[ep.ID] = st
+    }
+    released := false
+    if st.quarantined && ev == nil && len(st.transitions) > 0 && res.Timestamp.Sub(st.transitions[len(st.transitions)-1]) >= q.stable {
+        st.quarantined, st.transitions = false, nil
+        released = true
+    }
+    entered := false
+    if ev != nil && ev.From != StatusUnknown {
+        cutoff := res.Timestamp.Add(-q.window)
+        st.transitions = slices.DeleteFunc(append(st.transitions, res.Timestamp), func(t time.Time) bool { return t.Before(cutoff) })
+        if !st.quarantined && len(st.transitions) >= q.flaps {
+            st.quarantined, st.since = true, res.Timestamp
+            entered = true
+        }
+    }
+    quarantined, n := st.quarantined, len(st.transitions)
+    c.mu.Unlock()
+
+    switch {
+    case entered:
+        c.ilog(LogInfo, "endpoint_quarantined", endpointFields(ep, zap.Int("transitions", n), zap.Duration("window", q.window))...)
+    case released:
+        c.ilog(LogInfo, "endpoint_unquarantined", endpointFields(ep)...)
+    }
+    return quarantined
+}
+
+// Quarantined returns the endpoints currently quarantined for flapping by
+// WithAutoQuarantine, in registration order, for human review.
+func (c *Checker) Quarantined() []Endpoint {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    var out []Endpoint
+    for _, ep := range c.endpoints {
+        if c.quarantinedLocked(ep.ID) {
+            out = append(out, ep)
+        }
+    }
+    return out
+}
+
+func (c *Checker) quarantinedLocked(id string) bool {
+    st := c.flaps[id]
+    return st != nil && st.quarantined
+}
@@ -0,0 +1,118 @@
+package uptime
+
+import (
+    "container/heap"
+    "sync"
+    "time"
+)
+
+// ===== Priority Job Queue =====
+
+// jobQueue is a bounded priority queue of jobs consumed by the workers.
+// Higher Endpoint.Priority is dispatched first; ties are FIFO.
+//
+// Strict priority can starve low-priority endpoints when workers never catch
+// up, so an optional aging interval raises a waiting job's priority by one
+// level per interval waited. Because every queued job ages at the same rate,
+// the relative order of two jobs never changes while they wait, so aging is
+// folded into a static sort key: enqueue time minus Priority*aging.
+type jobQueue struct {
+    mu     sync.Mutex
+    cond   *sync.Cond
+    items  jobHeap
+    cap    int
+    aging  time.Duration
+    seq    uint64
+    closed bool
+}
+
+type queuedJob struct {
+    job Job
+    key int64  // aged sort key (only with aging)
+    seq uint64 // FIFO tie-breaker
+}
+
+func newJobQueue(capacity int) *jobQueue {
+    q := &jobQueue{cap: capacity}
+    q.cond = sync.NewCond(&q.mu)
+    return q
+}
+
+// push enqueues j. It returns false without blocking when the queue is full
+// or closed.
+func (q *jobQueue) push(j Job) bool {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if q.closed || len(q.items.jobs) >= q.cap {
+        return false
+    }
+    q.seq++
+    item := queuedJob{job: j, seq: q.seq}
+    if q.aging > 0 {
+        item.key = j.RunAt.UnixNano() - int64(j.Endpoint.Priority)*int64(q.aging)
+    }
+    q.items.aged = q.aging > 0
+    heap.Push(&q.items, item)
+    q.cond.Signal()
+    return true
+}
+
+// pop blocks until a job is available or the queue is closed.
+func (q *jobQueue) pop() (Job, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    for len(q.items.jobs) == 0 && !q.closed {
+        q.cond.Wait()
+    }
+    if q.closed {
+        return Job{}, false
+    }
+    return heap.Pop(&q.items).(queuedJob).job, true
+}
+
+// close wakes all blocked consumers and rejects further pushes. Queued jobs
+// are discarded.
+func (q *jobQueue) close() {
+    q.mu.Lock()
+    q.closed = true
+    q.items.jobs = nil
+    q.mu.Unlock()
+    q.cond.Broadcast()
+}
+
+func (q *jobQueue) len() int {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return len(q.items.jobs)
+}
+
+type jobHeap struct {
+    jobs []queuedJob
+    aged bool
+}
+
+func (h jobHeap) Len() int { return len(h.jobs) }
+
+func (h jobHeap) Less(i, j int) bool {
+    a, b := h.jobs[i], h.jobs[j]
+    if h.aged {
+        if a.key != b.key {
+            return a.key < b.key
+        }
+    } else if a.job.Endpoint.Priority != b.job.Endpoint.Priority {
+        return a.job.Endpoint.Priority > b.job.Endpoint.Priority
+    }
+    return a.seq < b.seq
+}
+
+func (h jobHeap) Swap(i, j int) { h.jobs[i], h.jobs[j] = h.jobs[j], h.jobs[i] }
+
+func (h *jobHeap) Push(x interface{}) { h.jobs = append(h.jobs, x.(queuedJob)) }
+
+func (h *jobHeap) Pop() interface{} {
+    old := h.jobs
+    n := len(old)
+    item := old[n-1]
+    h.jobs = old[:n-1]
+    return item
+}
@@ -0,0 +1,125 @@
+package uptime
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== On-demand Checks =====
+
+// errPassDeadline is the cancellation cause of a RunOnce pass that ran out
+// of time.
+var errPassDeadline = errors.New("Timeout (pass deadline)")
+
+// RecheckFailing immediately re-checks every endpoint whose aggregated status
+// is DOWN or DEGRADED and returns the fresh results keyed by endpoint ID.
+// Other endpoints are not touched. At most WithWorkers checks run concurrently;
+// endpoints not yet started when ctx is done are left out of the result.
+// Results are recorded like scheduled ones (logs, Results channel) with
+// Origin set to OriginManual.
+func (c *Checker) RecheckFailing(ctx context.Context) map[string]Result {
+    var failing []Endpoint
+    for _, st := range c.StatusSnapshot() {
+        if st.Status == StatusDown || st.Status == StatusDegraded {
+            failing = append(failing, st.Endpoint)
+        }
+    }
+    c.ilog(LogInfo, "recheck_failing", zap.Int("count", len(failing)))
+    return c.checkAll(ctx, failing)
+}
+
+// RunOnce checks every registered endpoint once, e.g. from a CI job, and
+// returns the results keyed by endpoint ID. The checker does not need to be
+// started; concurrency and recording are as for RecheckFailing.
+//
+// With a positive deadline the pass ends after that long: checks still
+// running are cancelled, and they and endpoints not yet checked get a
+// failed "Timeout (pass deadline)" result with FailureTimeout. Endpoints not
+// yet started when ctx itself is done are left out.
+func (c *Checker) RunOnce(ctx context.Context, deadline time.Duration) map[string]Result {
+    c.mu.RLock()
+    eps := append([]Endpoint(nil), c.endpoints...)
+    c.mu.RUnlock()
+    c.ilog(LogInfo, "run_once", zap.Int("count", len(eps)), zap.Duration("deadline", deadline))
+    if deadline <= 0 {
+        return c.checkAll(ctx, eps)
+    }
+
+    start := time.Now()
+    pass, cancel := context.WithTimeoutCause(ctx, deadline, errPassDeadline)
+    defer cancel()
+    out := c.checkAll(pass, eps)
+    if ctx.Err() != nil {
+        return out
+    }
+    for _, ep := range eps {
+        if _, ok := out[ep.ID]; !ok {
+            out[ep.ID] = c.handleResult(passDeadlineResult(ep, start))
+        }
+    }
+    return out
+}
+
+// passDeadlineResult is the result of a check cut off by a RunOnce deadline.
+func passDeadlineResult(ep Endpoint, start time.Time) Result {
+    return Result{
+        Endpoint:    ep,
+        Timestamp:   time.Now(),
+        Latency:     time.Since(start),
+        Success:     false,
+        Error:       errPassDeadline.Error(),
+        Origin:      OriginManual,
+        FailureKind: FailureTimeout,
+    }
+}
+
+// checkAll checks eps with at most WithWorkers checks at once and records
+// the results with OriginManual. The checks are charged to WithCheckBudget
+// but not deferred by it. Endpoints not yet started when ctx is done are
+// left out.
+func (c *Checker) checkAll(ctx context.Context, eps []Endpoint) map[string]Result {
+    limit := c.numWorkers
+    if limit < 1 {
+        limit = 1
+    }
+    sem := make(chan struct{}, limit)
+    out := make(map[string]Result, len(eps))
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for _, ep := range eps {
+        select {
+        case sem <- struct{}{}:
+        case <-ctx.Done():
+            wg.Wait()
+            return out
+        }
+        if ctx.Err() != nil {
+            <-sem
+            break
+        }
+        wg.Add(1)
+        go func(ep Endpoint) {
+            defer wg.Done()
+            defer func() { <-sem }()
+            start := time.Now()
+            c.chargeBudget()
+            c.inFlight.Add(1)
+            res := c.checkEndpoint(ctx, ep)
+            res.Origin = OriginManual
+            c.inFlight.Add(-1)
+            if !res.Success && errors.Is(context.Cause(ctx), errPassDeadline) {
+                res = passDeadlineResult(ep, start)
+            }
+            res = c.handleResult(res)
+            mu.Lock()
+            out[ep.ID] = res
+            mu.Unlock()
+        }(ep)
+    }
+    wg.Wait()
+    return out
+}
@@ -0,0 +1,285 @@
+// Package remotewrite pushes check results to a Prometheus remote-write
+// endpoint, for keeping long-term history in a TSDB without scraping.
+//
+// Each Result becomes two samples at its timestamp: uptime_endpoint_up
+// (1 or 0) and uptime_endpoint_latency_seconds, labelled with the endpoint
+// id and name.
+//
+//    w, err := remotewrite.New(remotewrite.Config{URL: "http://prometheus:9090/api/v1/write"})
+//    if err != nil { ... }
+//    w.Start()
+//    defer w.Close()
+//    for res := range checker.Results() {
+//        w.Add(res)
+//    }
+package remotewrite
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "sort"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// Config configures a Writer. Zero values select the defaults.
+type Config struct {
+    URL           string            // remote-write endpoint (required)
+    FlushInterval time.Duration     // how often pending results are sent (default 15s)
+    BatchSize     int               // results per request; a full batch is sent early (default 500)
+    MaxPending    int               // results buffered before Add drops new ones (default 10000)
+    MaxRetries    int               // retries of a failed request (default 3; negative disables retries)
+    RetryBackoff  time.Duration     // initial retry delay, doubled per retry (default 500ms)
+    Headers       map[string]string // extra request headers, e.g. Authorization
+    Client        *http.Client      // default: 30s timeout
+}
+
+// Writer batches results and sends them to a remote-write endpoint.
+type Writer struct {
+    cfg Config
+
+    mu      sync.Mutex
+    pending []uptime.Result
+
+    kick    chan struct{}
+    stop    chan struct{}
+    done    chan struct{}
+    started bool
+    closeOnce sync.Once
+
+    dropped atomic.Int64
+    failed  atomic.Int64
+    sent    atomic.Int64
+}
+
+// New returns a Writer for cfg.
+func New(cfg Config) (*Writer, error) {
+    u, err := url.Parse(cfg.URL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return nil, fmt.Errorf("remotewrite: invalid url %q", cfg.URL)
+    }
+    if cfg.FlushInterval <= 0 {
+        cfg.FlushInterval = 15 * time.Second
+    }
+    if cfg.BatchSize <= 0 {
+        cfg.BatchSize = 500
+    }
+    if cfg.MaxPending <= 0 {
+        cfg.MaxPending = 10000
+    }
+    if cfg.MaxRetries < 0 {
+        cfg.MaxRetries = 0
+    } else if cfg.MaxRetries == 0 {
+        cfg.MaxRetries = 3
+    }
+    if cfg.RetryBackoff <= 0 {
+        cfg.RetryBackoff = 500 * time.Millisecond
+    }
+    if cfg.Client == nil {
+        cfg.Client = &http.Client{Timeout: 30 * time.Second}
+    }
+    return &Writer{
+        cfg:  cfg,
+        kick: make(chan struct{}, 1),
+        stop: make(chan struct{}),
+        done: make(chan struct{}),
+    }, nil
+}
+
+// Add queues res for the next flush. It never blocks: when MaxPending
+// results are already waiting (the endpoint is slow or down) res is dropped
+// and counted in Stats.
+func (w *Writer) Add(res uptime.Result) {
+    w.mu.Lock()
+    if len(w.pending) >= w.cfg.MaxPending {
+        w.mu.Unlock()
+        w.dropped.Add(1)
+        return
+    }
+    w.pending = append(w.pending, res)
+    full := len(w.pending) >= w.cfg.BatchSize
+    w.mu.Unlock()
+    if full {
+        select {
+        case w.kick <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// Start runs the background flush loop until Close.
+func (w *Writer) Start() {
+    w.mu.Lock()
+    if w.started {
+        w.mu.Unlock()
+        return
+    }
+    w.started = true
+    w.mu.Unlock()
+    go w.loop()
+}
+
+// Close stops the flush loop and sends whatever is still pending.
+func (w *Writer) Close() error {
+    var err error
+    w.closeOnce.Do(func() {
+        close(w.stop)
+        w.mu.Lock()
+        started := w.started
+        w.mu.Unlock()
+        if started {
+            <-w.done
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Client.Timeout+time.Second)
+        defer cancel()
+        err = w.Flush(ctx)
+    })
+    return err
+}
+
+// Stats reports results sent, results dropped because the buffer was full,
+// and results discarded after a request failed for good.
+func (w *Writer) Stats() (sent, dropped, failed int64) {
+    return w.sent.Load(), w.dropped.Load(), w.failed.Load()
+}
+
+func (w *Writer) loop() {
+    defer close(w.done)
+    t := time.NewTicker(w.cfg.FlushInterval)
+    defer t.Stop()
+    for {
+        select {
+        case <-w.stop:
+            return
+        case <-t.C:
+        case <-w.kick:
+        }
+        ctx, cancel := context.WithCancel(context.Background())
+        go func() {
+            select {
+            case <-w.stop:
+                cancel()
+            case <-ctx.Done():
+            }
+        }()
+        _ = w.Flush(ctx)
+        cancel()
+    }
+}
+
+// Flush sends all pending results in batches of BatchSize, retrying
+// failures with exponential backoff. A batch that still fails is discarded
+// and counted; Flush returns the last such error.
+func (w *Writer) Flush(ctx context.Context) error {
+    var lastErr error
+    for {
+        w.mu.Lock()
+        n := len(w.pending)
+        if n > w.cfg.BatchSize {
+            n = w.cfg.BatchSize
+        }
+        batch := append([]uptime.Result(nil), w.pending[:n]...)
+        w.pending = w.pending[n:]
+        w.mu.Unlock()
+        if len(batch) == 0 {
+            return lastErr
+        }
+        if err := w.send(ctx, batch); err != nil {
+            w.failed.Add(int64(len(batch)))
+            lastErr = err
+            if ctx.Err() != nil {
+                return lastErr
+            }
+            continue
+        }
+        w.sent.Add(int64(len(batch)))
+    }
+}
+
+// errPermanent marks responses that retrying won't fix.
+var errPermanent = errors.New("remotewrite: request rejected")
+
+func (w *Writer) send(ctx context.Context, batch []uptime.Result) error {
+    payload := snappyEncode(marshalWriteRequest(toSeries(batch)))
+    backoff := w.cfg.RetryBackoff
+    var err error
+    for attempt := 0; ; attempt++ {
+        err = w.post(ctx, payload)
+        if err == nil || errors.Is(err, errPermanent) || attempt >= w.cfg.MaxRetries {
+            return err
+        }
+        select {
+        case <-time.After(backoff):
+        case <-ctx.Done():
+            return err
+        }
+        backoff *= 2
+    }
+}
+
+func (w *Writer) post(ctx context.Context, payload []byte) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(payload))
+    if err != nil {
+        return fmt.Errorf("%w: %v", errPermanent, err)
+    }
+    req.Header.Set("Content-Encoding", "snappy")
+    req.Header.Set("Content-Type", "application/x-protobuf")
+    req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+    for k, v := range w.cfg.Headers {
+        req.Header.Set(k, v)
+    }
+    resp, err := w.cfg.Client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    switch {
+    case resp.StatusCode/100 == 2:
+        return nil
+    case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5:
+        return fmt.Errorf("remotewrite: status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+    default:
+        return fmt.Errorf("%w: status %d: %s", errPermanent, resp.StatusCode, bytes.TrimSpace(msg))
+    }
+}
+
+// toSeries groups the samples of batch by series, in timestamp order.
+func toSeries(batch []uptime.Result) []series {
+    byKey := make(map[string]*series)
+    var keys []string
+    add := func(metric string, ep uptime.Endpoint, v float64, ts int64) {
+        key := metric + "\xff" + ep.ID + "\xff" + ep.Name
+        s, ok := byKey[key]
+        if !ok {
+            s = &series{labels: []label{{"__name__", metric}, {"id", ep.ID}, {"name", ep.Name}}}
+            byKey[key] = s
+            keys = append(keys, key)
+        }
+        s.samples = append(s.samples, sample{value: v, timestamp: ts})
+    }
+    for _, r := range batch {
+        ts := r.Timestamp.UnixMilli()
+        up := 0.0
+        if r.Success {
+            up = 1
+        }
+        add("uptime_endpoint_up", r.Endpoint, up, ts)
+        add("uptime_endpoint_latency_seconds", r.Endpoint, r.Latency.Seconds(), ts)
+    }
+    out := make([]series, 0, len(keys))
+    for _, k := range keys {
+        s := byKey[k]
+        sort.SliceStable(s.samples, func(i, j int) bool { return s.samples[i].timestamp < s.samples[j].timestamp })
+        out = append(out, *s)
+    }
+    return out
+}
@@ -0,0 +1,177 @@
+package remotewrite_test
+
+import (
+    "context"
+    "encoding/binary"
+    "io"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+    "github.com/amartya2002/uptime-checker-core/uptime/remotewrite"
+)
+
+type gotSample struct {
+    metric, id string
+    value      float64
+    ts         int64
+}
+
+// The pushed payload decodes to up and latency samples per result; 5xx
+// responses are retried.
+func TestWriterPushesSamples(t *testing.T) {
+    var mu sync.Mutex
+    var got []gotSample
+    var calls atomic.Int32
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if calls.Add(1) == 1 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+            t.Errorf("unexpected headers %v", r.Header)
+        }
+        body, _ := io.ReadAll(r.Body)
+        mu.Lock()
+        got = append(got, decodeWriteRequest(t, snappyDecode(t, body))...)
+        mu.Unlock()
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer ts.Close()
+
+    w, err := remotewrite.New(remotewrite.Config{URL: ts.URL, RetryBackoff: time.Millisecond})
+    if err != nil {
+        t.Fatalf("New: %v", err)
+    }
+    at := time.UnixMilli(1700000000000)
+    ep := uptime.Endpoint{ID: "api", Name: "API"}
+    w.Add(uptime.Result{Endpoint: ep, Timestamp: at, Success: true, Latency: 250 * time.Millisecond})
+    w.Add(uptime.Result{Endpoint: ep, Timestamp: at.Add(time.Second), Success: false, Latency: time.Second})
+    if err := w.Flush(context.Background()); err != nil {
+        t.Fatalf("Flush: %v", err)
+    }
+
+    want := []gotSample{
+        {"uptime_endpoint_up", "api", 1, 1700000000000},
+        {"uptime_endpoint_up", "api", 0, 1700000001000},
+        {"uptime_endpoint_latency_seconds", "api", 0.25, 1700000000000},
+        {"uptime_endpoint_latency_seconds", "api", 1, 1700000001000},
+    }
+    if len(got) != len(want) {
+        t.Fatalf("expected %d samples, got %+v", len(want), got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("sample %d: got %+v, want %+v", i, got[i], want[i])
+        }
+    }
+    if sent, dropped, failed := w.Stats(); sent != 2 || dropped != 0 || failed != 0 {
+        t.Fatalf("unexpected stats sent=%d dropped=%d failed=%d", sent, dropped, failed)
+    }
+}
+
+// 4xx responses are not retried and buffered results beyond MaxPending are
+// dropped.
+func TestWriterRejectAndBackpressure(t *testing.T) {
+    var calls atomic.Int32
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        calls.Add(1)
+        w.WriteHeader(http.StatusBadRequest)
+    }))
+    defer ts.Close()
+
+    w, _ := remotewrite.New(remotewrite.Config{URL: ts.URL, MaxPending: 2, RetryBackoff: time.Millisecond})
+    for i := 0; i < 3; i++ {
+        w.Add(uptime.Result{Endpoint: uptime.Endpoint{ID: "a"}, Timestamp: time.Now()})
+    }
+    if err := w.Flush(context.Background()); err == nil {
+        t.Fatalf("expected error from rejected batch")
+    }
+    if calls.Load() != 1 {
+        t.Fatalf("expected no retries on 400, got %d calls", calls.Load())
+    }
+    if sent, dropped, failed := w.Stats(); sent != 0 || dropped != 1 || failed != 2 {
+        t.Fatalf("unexpected stats sent=%d dropped=%d failed=%d", sent, dropped, failed)
+    }
+}
+
+// snappyDecode decodes the literal-only snappy blocks the writer produces.
+func snappyDecode(t *testing.T, b []byte) []byte {
+    t.Helper()
+    n, k := binary.Uvarint(b)
+    b = b[k:]
+    var out []byte
+    for len(b) > 0 {
+        tag := b[0]
+        if tag&3 != 0 {
+            t.Fatalf("unexpected copy element")
+        }
+        l := int(tag>>2) + 1
+        b = b[1:]
+        switch tag >> 2 {
+        case 60:
+            l = int(b[0]) + 1
+            b = b[1:]
+        case 61:
+            l = int(b[0]) | int(b[1])<<8 + 1
+            b = b[2:]
+        }
+        out = append(out, b[:l]...)
+        b = b[l:]
+    }
+    if uint64(len(out)) != n {
+        t.Fatalf("snappy length mismatch")
+    }
+    return out
+}
+
+func decodeWriteRequest(t *testing.T, b []byte) []gotSample {
+    t.Helper()
+    var out []gotSample
+    for _, ts := range fields(t, b) {
+        labels := map[string]string{}
+        var samples [][]byte
+        for _, f := range fields(t, ts.data) {
+            if f.num == 1 {
+                l := fields(t, f.data)
+                labels[string(l[0].data)] = string(l[1].data)
+            } else {
+                samples = append(samples, f.data)
+            }
+        }
+        for _, s := range samples {
+            v := math.Float64frombits(binary.LittleEndian.Uint64(s[1:9]))
+            tsMs, _ := binary.Uvarint(s[10:])
+            out = append(out, gotSample{labels["__name__"], labels["id"], v, int64(tsMs)})
+        }
+    }
+    return out
+}
+
+type field struct {
+    num  uint64
+    data []byte
+}
+
+// fields splits a message made only of length-delimited fields.
+func fields(t *testing.T, b []byte) []field {
+    t.Helper()
+    var out []field
+    for len(b) > 0 {
+        key, k := binary.Uvarint(b)
+        b = b[k:]
+        if key&7 != 2 {
+            t.Fatalf("unexpected wire type %d", key&7)
+        }
+        l, k := binary.Uvarint(b)
+        b = b[k:]
+        out = append(out, field{num: key >> 3, data: b[:l]})
+        b = b[l:]
+    }
+    return out
+}
@@ -0,0 +1,63 @@
+package uptime
+
+import (
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Replay =====
+
+// Replay feeds pre-recorded results of the registered endpoint id through
+// the status engine as if its checks had produced them, without any HTTP
+// requests: they are stored in the logs, evaluated against the failure
+// thresholds and latency baseline, audited as transitions and published on
+// Results. It is meant for testing alerting and tuning thresholds
+// deterministically, typically on a checker that is not started.
+//
+// Each result is attributed to the endpoint as registered. Results without a
+// Timestamp are placed one Frequency after the previous one (the first at
+// the current time); results without an Origin get OriginReplay. Unknown
+// IDs are ignored.
+func (c *Checker) Replay(id string, results []Result) {
+    c.mu.RLock()
+    ep, ok := c.endpointLocked(id)
+    c.mu.RUnlock()
+    if !ok {
+        c.ilog(LogError, "replay_unknown_endpoint", zap.String("endpoint_id", id))
+        return
+    }
+    c.ilog(LogInfo, "replay_started", endpointFields(ep, zap.Int("count", len(results)))...)
+    var at time.Time
+    for _, res := range results {
+        res.Endpoint = ep
+        switch {
+        case !res.Timestamp.IsZero():
+            at = res.Timestamp
+        case at.IsZero():
+            at = time.Now()
+            res.Timestamp = at
+        default:
+            at = at.Add(ep.Frequency)
+            res.Timestamp = at
+        }
+        if res.Origin == "" {
+            res.Origin = OriginReplay
+        }
+        if !res.Success && res.FailureKind == "" {
+            res.FailureKind = FailureOther
+        }
+        c.handleResult(res)
+    }
+}
+
+// endpointLocked returns the registered endpoint with the given ID. c.mu
+// must be held.
+func (c *Checker) endpointLocked(id string) (Endpoint, bool) {
+    for _, ep := range c.endpoints {
+        if ep.ID == id {
+            return ep, true
+        }
+    }
+    return Endpoint{}, false
+}
@@ -0,0 +1,67 @@
+package uptime
+
+import (
+    "context"
+    "net"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Custom DNS Resolver =====
+
+// useResolver installs a transport on the base client whose dialer resolves
+// names through c.resolverAddrs. Per-endpoint transports are cloned from it
+// (see baseTransport), so proxied and insecure endpoints use it too.
+func (c *Checker) useResolver() {
+    addrs := c.resolverAddrs
+    resolver := c.newResolver(addrs)
+    c.resolver = resolver
+    dialer := &net.Dialer{
+        Timeout:   30 * time.Second,
+        KeepAlive: 30 * time.Second,
+        Resolver:  resolver,
+    }
+    tr := c.baseTransport()
+    tr.DialContext = dialer.DialContext
+    client := *c.httpClient
+    client.Transport = tr
+    c.httpClient = &client
+    c.ilog(LogInfo, "resolver_configured", zap.Strings("resolvers", addrs))
+}
+
+// newResolver returns a resolver querying the DNS servers addrs
+// round-robin.
+func (c *Checker) newResolver(addrs []string) *net.Resolver {
+    var next atomic.Uint32
+    return &net.Resolver{
+        PreferGo: true,
+        Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+            addr := addrs[int(next.Add(1)-1)%len(addrs)]
+            if c.internalEnabled(LogDebug) {
+                c.ilog(LogDebug, "dns_query", zap.String("resolver", addr), zap.String("network", network))
+            }
+            var d net.Dialer
+            return d.DialContext(ctx, network, addr)
+        },
+    }
+}
+
+// normalizeResolverAddrs adds the default DNS port to addresses without one
+// and drops empty entries.
+func normalizeResolverAddrs(addresses []string) []string {
+    var out []string
+    for _, a := range addresses {
+        a = strings.TrimSpace(a)
+        if a == "" {
+            continue
+        }
+        if _, _, err := net.SplitHostPort(a); err != nil {
+            a = net.JoinHostPort(strings.Trim(a, "[]"), "53")
+        }
+        out = append(out, a)
+    }
+    return out
+}
@@ -0,0 +1,29 @@
+package uptime
+
+import "time"
+
+// ===== Retries =====
+
+// retriesFor returns how often a failed check of ep is retried and the
+// wait between attempts: the endpoint's Retries and RetryBackoff when set,
+// otherwise the WithRetries defaults.
+func (c *Checker) retriesFor(ep Endpoint) (int, time.Duration) {
+    if ep.Retries > 0 {
+        return ep.Retries, ep.RetryBackoff
+    }
+    return c.retries, c.retryBackoff
+}
+
+// retryable reports whether res failed in a way a retry may fix: a network
+// error or an unexpected status. Assertion failures and cancelled checks
+// are not retried.
+func retryable(res Result) bool {
+    if res.Success {
+        return false
+    }
+    switch res.FailureKind {
+    case FailureTimeout, FailureConnRefused, FailureDNS, FailureConnection, FailureStatus:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,92 @@
+package uptime
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "os"
+
+    "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ===== JSON Schema Assertions =====
+
+// maxSchemaBody caps how much of a response body is read for schema
+// validation and JSON assertions.
+const maxSchemaBody = 10 << 20
+
+func hasSchema(ep Endpoint) bool {
+    return ep.JSONSchemaFile != "" || len(ep.JSONSchema) > 0
+}
+
+// schema returns the compiled JSON Schema of ep. Schemas are compiled once,
+// when the endpoint is registered, and cached by file path or inline
+// content, so later edits to a schema file are not picked up.
+func (c *Checker) schema(ep Endpoint) (*jsonschema.Schema, error) {
+    key := "inline:" + string(ep.JSONSchema)
+    if ep.JSONSchemaFile != "" {
+        key = "file:" + ep.JSONSchemaFile
+    }
+    if s, ok := c.schemas.Load(key); ok {
+        return s.(*jsonschema.Schema), nil
+    }
+
+    raw := []byte(ep.JSONSchema)
+    if ep.JSONSchemaFile != "" {
+        b, err := os.ReadFile(ep.JSONSchemaFile)
+        if err != nil {
+            return nil, fmt.Errorf("json schema: %w", err)
+        }
+        raw = b
+    }
+    doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+    if err != nil {
+        return nil, fmt.Errorf("json schema: %w", err)
+    }
+    compiler := jsonschema.NewCompiler()
+    if err := compiler.AddResource("schema.json", doc); err != nil {
+        return nil, fmt.Errorf("json schema: %w", err)
+    }
+    s, err := compiler.Compile("schema.json")
+    if err != nil {
+        return nil, fmt.Errorf("json schema: %w", err)
+    }
+    c.schemas.Store(key, s)
+    return s, nil
+}
+
+// checkSchema validates the parsed response body against ep's JSON Schema.
+// It returns "" when the body conforms and otherwise the first validation
+// error.
+func (c *Checker) checkSchema(ep Endpoint, inst any) string {
+    s, err := c.schema(ep)
+    if err != nil {
+        return err.Error()
+    }
+    err = s.Validate(inst)
+    if err == nil {
+        return ""
+    }
+    var ve *jsonschema.ValidationError
+    if !errors.As(err, &ve) {
+        return fmt.Sprintf("json schema: %v", err)
+    }
+    return "json schema: " + firstSchemaError(ve)
+}
+
+// firstSchemaError returns the first leaf validation error, with the
+// location of the offending value.
+func firstSchemaError(ve *jsonschema.ValidationError) string {
+    out := ve.BasicOutput()
+    for _, u := range out.Errors {
+        if u.Error == nil {
+            continue
+        }
+        loc := u.InstanceLocation
+        if loc == "" {
+            loc = "/"
+        }
+        return fmt.Sprintf("at %s: %s", loc, u.Error)
+    }
+    return ve.Error()
+}
@@ -0,0 +1,103 @@
+package uptime
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Built-in HTTP Server =====
+
+// Handler returns an http.Handler exposing:
+//
+//   - GET /status         -> StatusSnapshot as JSON
+//   - GET /metrics        -> Prometheus text metrics
+//   - GET /logs?id=&limit= -> recent results for one endpoint (limit defaults to 50)
+//   - GET /incidents?id=   -> recorded incidents of one endpoint, with notes
+//   - GET /events          -> status transitions as Server-Sent Events (see ServeSSE)
+//
+// It can be mounted in an existing server or served with ServeHTTP.
+func (c *Checker) Handler() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+        writeJSON(w, http.StatusOK, c.StatusSnapshot())
+    })
+    mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        _ = c.WriteMetrics(w)
+    })
+    mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+        id := r.URL.Query().Get("id")
+        if id == "" {
+            writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing id"})
+            return
+        }
+        limit := 50
+        if v := r.URL.Query().Get("limit"); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil || n <= 0 {
+                writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+                return
+            }
+            limit = n
+        }
+        logs := c.GetLogs(id, limit)
+        if logs == nil {
+            logs = []Result{}
+        }
+        writeJSON(w, http.StatusOK, logs)
+    })
+    mux.HandleFunc("/incidents", func(w http.ResponseWriter, r *http.Request) {
+        id := r.URL.Query().Get("id")
+        if id == "" {
+            writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing id"})
+            return
+        }
+        incidents := c.Incidents(id)
+        if incidents == nil {
+            incidents = []Incident{}
+        }
+        writeJSON(w, http.StatusOK, incidents)
+    })
+    mux.HandleFunc("/events", c.ServeSSE)
+    return mux
+}
+
+// ServeHTTP starts a small HTTP server on addr serving Handler. It blocks
+// until the server fails or the Checker is stopped, in which case it shuts
+// the server down and returns nil.
+func (c *Checker) ServeHTTP(addr string) error {
+    srv := &http.Server{
+        Addr:              addr,
+        Handler:           c.Handler(),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+    done := make(chan struct{})
+    defer close(done)
+    go func() {
+        select {
+        case <-c.stopCh:
+            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            defer cancel()
+            _ = srv.Shutdown(ctx)
+        case <-done:
+        }
+    }()
+
+    c.ilog(LogInfo, "http_server_listening", zap.String("addr", addr))
+    if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+        return err
+    }
+    return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    _ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,247 @@
+package uptime_test
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    up "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// The built-in handler exposes status, metrics, and logs for checked endpoints.
+func TestHandler_StatusMetricsLogs(t *testing.T) {
+    target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer target.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    c.Start()
+    defer c.Stop()
+    c.AddSite(up.Endpoint{ID: "web", Name: "web", URL: target.URL, Frequency: 10 * time.Millisecond})
+
+    select {
+    case <-c.Results():
+    case <-time.After(2 * time.Second):
+        t.Fatalf("timed out waiting for result")
+    }
+
+    srv := httptest.NewServer(c.Handler())
+    defer srv.Close()
+
+    resp, err := http.Get(srv.URL + "/status")
+    if err != nil {
+        t.Fatalf("GET /status: %v", err)
+    }
+    var snap []up.EndpointStatus
+    if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+        t.Fatalf("decode status: %v", err)
+    }
+    resp.Body.Close()
+    if len(snap) != 1 || snap[0].Status != up.StatusUp {
+        t.Fatalf("expected one UP endpoint, got %+v", snap)
+    }
+
+    resp, err = http.Get(srv.URL + "/metrics")
+    if err != nil {
+        t.Fatalf("GET /metrics: %v", err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if !strings.Contains(string(body), `uptime_endpoint_up{id="web",name="web"} 1`) {
+        t.Fatalf("metrics missing up sample:\n%s", body)
+    }
+    if !strings.Contains(string(body), "uptime_self_workers 1\n") {
+        t.Fatalf("metrics missing self metrics:\n%s", body)
+    }
+    if m := c.SelfMetrics(); m.Workers != 1 || m.Endpoints != 1 || m.Schedulers != 1 || m.LogEntries == 0 {
+        t.Fatalf("unexpected self metrics: %+v", m)
+    }
+
+    resp, err = http.Get(srv.URL + "/logs")
+    if err != nil {
+        t.Fatalf("GET /logs: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusBadRequest {
+        t.Fatalf("expected 400 without id, got %d", resp.StatusCode)
+    }
+
+    resp, err = http.Get(srv.URL + "/logs?id=web&limit=1")
+    if err != nil {
+        t.Fatalf("GET /logs: %v", err)
+    }
+    var logs []up.Result
+    if err := json.NewDecoder(resp.Body).Decode(&logs); err != nil {
+        t.Fatalf("decode logs: %v", err)
+    }
+    resp.Body.Close()
+    if len(logs) != 1 {
+        t.Fatalf("expected 1 log entry, got %d", len(logs))
+    }
+}
+
+// Incidents open on DOWN, end on recovery, take validated notes and are
+// served by the handler.
+func TestIncidents_Annotate(t *testing.T) {
+    c := up.New(up.DisableLogs())
+    c.AddSite(up.Endpoint{ID: "api", URL: "http://203.0.113.1", Frequency: time.Minute})
+    down := up.Result{Success: false, Error: "503", FailureKind: up.FailureStatus}
+    c.Replay("api", []up.Result{{Success: true}, down, {Success: true}, down})
+
+    got := c.Incidents("api")
+    if len(got) != 2 || got[0].Ongoing() || !got[1].Ongoing() || got[0].Error != "503" {
+        t.Fatalf("expected a resolved and an ongoing incident, got %+v", got)
+    }
+    if !got[0].End.After(got[0].Start) {
+        t.Fatalf("expected the first incident to end after it started: %+v", got[0])
+    }
+    if err := c.AnnotateIncident("api", 0, "upstream outage"); err != nil {
+        t.Fatalf("AnnotateIncident: %v", err)
+    }
+    for _, tc := range []struct {
+        id   string
+        idx  int
+        note string
+    }{{"api", 2, "x"}, {"api", -1, "x"}, {"missing", 0, "x"}, {"api", 0, "  "}} {
+        if err := c.AnnotateIncident(tc.id, tc.idx, tc.note); err == nil {
+            t.Fatalf("expected an error annotating %+v", tc)
+        }
+    }
+
+    srv := httptest.NewServer(c.Handler())
+    defer srv.Close()
+    resp, err := http.Get(srv.URL + "/incidents?id=api")
+    if err != nil {
+        t.Fatalf("GET /incidents: %v", err)
+    }
+    var served []up.Incident
+    if err := json.NewDecoder(resp.Body).Decode(&served); err != nil {
+        t.Fatalf("decode incidents: %v", err)
+    }
+    resp.Body.Close()
+    if len(served) != 2 || len(served[0].Notes) != 1 || served[0].Notes[0].Text != "upstream outage" {
+        t.Fatalf("expected the annotated incidents served, got %+v", served)
+    }
+
+    c.AnnotateIncident("api", 1, "rolling back")
+    resp, err = http.Get(srv.URL + "/status")
+    if err != nil {
+        t.Fatalf("GET /status: %v", err)
+    }
+    var status []up.EndpointStatus
+    json.NewDecoder(resp.Body).Decode(&status)
+    resp.Body.Close()
+    if in := status[0].LastIncident; in == nil || !in.Ongoing() || len(in.Notes) != 1 || in.Notes[0].Text != "rolling back" {
+        t.Fatalf("expected the ongoing incident with its note in /status, got %+v", in)
+    }
+}
+
+// incidentStore is a Storage keeping only the last incidents saved, slowly.
+type incidentStore struct {
+    mu    sync.Mutex
+    saved map[string][]up.Incident
+}
+
+func (s *incidentStore) Append(up.Result) error                      { return nil }
+func (s *incidentStore) Recent(string, int) ([]up.Result, error)     { return nil, nil }
+func (s *incidentStore) LoadIncidents(string) ([]up.Incident, error) { return nil, nil }
+
+func (s *incidentStore) SaveIncidents(id string, incidents []up.Incident) error {
+    time.Sleep(time.Millisecond)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.saved[id] = incidents
+    return nil
+}
+
+// Concurrent incident changes are saved in order: the stored copy is the
+// latest one.
+func TestIncidents_SaveOrder(t *testing.T) {
+    store := &incidentStore{saved: make(map[string][]up.Incident)}
+    c := up.New(up.DisableLogs(), up.WithStorage(store))
+    c.AddSite(up.Endpoint{ID: "api", URL: "http://203.0.113.1", Frequency: time.Minute})
+    c.Replay("api", []up.Result{{FailureKind: up.FailureStatus}})
+
+    var wg sync.WaitGroup
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            c.AnnotateIncident("api", 0, fmt.Sprintf("note %d", i))
+        }()
+    }
+    wg.Wait()
+    store.mu.Lock()
+    defer store.mu.Unlock()
+    if in := store.saved["api"]; len(in) != 1 || len(in[0].Notes) != 20 {
+        t.Fatalf("expected the last save to hold all 20 notes, got %+v", in)
+    }
+}
+
+// /events streams each status transition as a Server-Sent Event.
+func TestServeSSE_Transitions(t *testing.T) {
+    var failing atomic.Bool
+    target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if failing.Load() {
+            w.WriteHeader(http.StatusInternalServerError)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer target.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    c.Start()
+    defer c.Stop()
+    srv := httptest.NewServer(c.Handler())
+    defer srv.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("GET /events: %v", err)
+    }
+    defer resp.Body.Close()
+    if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+        t.Fatalf("unexpected content type %q", ct)
+    }
+
+    c.AddSite(up.Endpoint{ID: "web", URL: target.URL, Frequency: 10 * time.Millisecond})
+    lines := bufio.NewScanner(resp.Body)
+    next := func() up.TransitionEvent {
+        t.Helper()
+        for lines.Scan() {
+            data, ok := strings.CutPrefix(lines.Text(), "data: ")
+            if !ok {
+                continue
+            }
+            var ev up.TransitionEvent
+            if err := json.Unmarshal([]byte(data), &ev); err != nil {
+                t.Fatalf("decode event %q: %v", data, err)
+            }
+            return ev
+        }
+        t.Fatalf("stream ended: %v", lines.Err())
+        return up.TransitionEvent{}
+    }
+
+    if ev := next(); ev.EndpointID != "web" || ev.From != up.StatusUnknown || ev.To != up.StatusUp {
+        t.Fatalf("unexpected first event %+v", ev)
+    }
+    failing.Store(true)
+    if ev := next(); ev.From != up.StatusUp || ev.To != up.StatusDown {
+        t.Fatalf("unexpected second event %+v", ev)
+    }
+}
@@ -0,0 +1,98 @@
+package uptime
+
+import (
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ===== Server-Timing =====
+
+// applyServerTiming records the Server-Timing metrics of resp on res, from
+// the header and, when the server announced it, the trailer. Reading the
+// trailer drains the body, so it runs after the body assertions.
+func applyServerTiming(res *Result, resp *http.Response) {
+    values := resp.Header.Values("Server-Timing")
+    if _, ok := resp.Trailer["Server-Timing"]; ok {
+        io.Copy(io.Discard, io.LimitReader(resp.Body, maxSchemaBody))
+        values = append(values, resp.Trailer.Values("Server-Timing")...)
+    }
+    for _, v := range values {
+        res.ServerTiming = append(res.ServerTiming, parseServerTiming(v)...)
+    }
+    if len(res.ServerTiming) == 0 {
+        return
+    }
+    res.ServerTime = serverTime(res.ServerTiming)
+    if res.Latency > res.ServerTime {
+        res.NetworkTime = res.Latency - res.ServerTime
+    }
+}
+
+// serverTime is the duration of the "total" metric, else of the longest
+// one: metrics such as db and app usually overlap, so summing them would
+// overstate the server's share.
+func serverTime(metrics []ServerTimingMetric) time.Duration {
+    var longest time.Duration
+    for _, m := range metrics {
+        if strings.EqualFold(m.Name, "total") {
+            return m.Duration
+        }
+        longest = max(longest, m.Duration)
+    }
+    return longest
+}
+
+// parseServerTiming parses one Server-Timing header value, e.g.
+// `db;dur=53, app;dur=47.2;desc="render"`. Malformed parameters are
+// ignored.
+func parseServerTiming(v string) []ServerTimingMetric {
+    var out []ServerTimingMetric
+    for _, entry := range splitQuoted(v, ',') {
+        parts := splitQuoted(entry, ';')
+        name := strings.TrimSpace(parts[0])
+        if name == "" {
+            continue
+        }
+        m := ServerTimingMetric{Name: name}
+        for _, p := range parts[1:] {
+            key, val, _ := strings.Cut(p, "=")
+            val = strings.TrimSpace(val)
+            if uq, err := strconv.Unquote(val); err == nil && strings.HasPrefix(val, `"`) {
+                val = uq
+            }
+            switch strings.ToLower(strings.TrimSpace(key)) {
+            case "dur":
+                if ms, err := strconv.ParseFloat(val, 64); err == nil && ms >= 0 {
+                    m.Duration = time.Duration(ms * float64(time.Millisecond))
+                }
+            case "desc":
+                m.Description = val
+            }
+        }
+        out = append(out, m)
+    }
+    return out
+}
+
+// splitQuoted splits s at sep outside double-quoted strings.
+func splitQuoted(s string, sep byte) []string {
+    var out []string
+    quoted, escaped, start := false, false, 0
+    for i := 0; i < len(s); i++ {
+        switch {
+        case escaped:
+            escaped = false
+        case s[i] == '\\' && quoted:
+            escaped = true
+        case s[i] == '"':
+            quoted = !quoted
+        case s[i] == sep && !quoted:
+            out = append(out, s[start:i])
+            start = i + 1
+        }
+    }
+    return append(out, s[start:])
+}
@@ -0,0 +1,50 @@
+package uptime
+
+import "go.uber.org/zap"
+
+// ===== Load Shedding =====
+
+// shed reports whether the due check of ep should be skipped because the job
+// queue is backed up (see WithLoadShedding). Only checks with Priority <= 0
+// are shed; shedding start and end are logged.
+func (c *Checker) shed(ep Endpoint) bool {
+    if c.shedDepth <= 0 {
+        return false
+    }
+    depth := c.jobs.len()
+    if depth <= c.shedDepth {
+        if c.shedding.CompareAndSwap(true, false) {
+            c.ilog(LogInfo, "load_shedding_stopped", zap.Int("queue_depth", depth), zap.Int64("shed_total", c.shedCount.Load()))
+        }
+        return false
+    }
+    if c.shedding.CompareAndSwap(false, true) {
+        c.ilog(LogInfo, "load_shedding_started", zap.Int("queue_depth", depth), zap.Int("limit", c.shedDepth))
+    }
+    if ep.Priority > 0 {
+        return false
+    }
+    c.shedCount.Add(1)
+    if c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "job_shed", endpointFields(ep, zap.Int("queue_depth", depth))...)
+    }
+    return true
+}
+
+// Stats returns counters accumulated since the checker was created.
+func (c *Checker) Stats() Stats {
+    s := Stats{
+        Checks:         c.checksDone.Load(),
+        Shed:           c.shedCount.Load(),
+        DroppedJobs:    c.droppedJobs.Load(),
+        DroppedResults: c.droppedResults.Load(),
+        Shedding:       c.shedding.Load(),
+        EvictedLogs:    c.evictedLogs.Load(),
+        DedupedResults: c.dedupedResults.Load(),
+        FaultInjection: c.faultInjector != nil,
+    }
+    if c.budget != nil {
+        c.budget.stats(&s)
+    }
+    return s
+}
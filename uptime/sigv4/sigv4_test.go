@@ -0,0 +1,64 @@
+package sigv4_test
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+    "github.com/amartya2002/uptime-checker-core/uptime/sigv4"
+)
+
+var testCreds = sigv4.StaticCredentials{
+    AccessKeyID:     "AKIDEXAMPLE",
+    SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// get-vanilla from the AWS SigV4 test suite.
+func TestSignRequest_GetVanilla(t *testing.T) {
+    req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+    s := sigv4.WithAWSSigV4("us-east-1", "service", testCreds)
+    s.Now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+    if err := s.SignRequest(req, nil); err != nil {
+        t.Fatalf("SignRequest: %v", err)
+    }
+    want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+        "SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+    if got := req.Header.Get("Authorization"); got != want {
+        t.Fatalf("unexpected Authorization:\n got %s\nwant %s", got, want)
+    }
+}
+
+// Each check is signed at send time by the endpoint's signer.
+func TestSignerUsedByChecker(t *testing.T) {
+    auth := make(chan string, 10)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case auth <- r.Header.Get("Authorization"):
+        default:
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    c := uptime.New(uptime.WithWorkers(1), uptime.DisableLogs())
+    c.Start()
+    defer c.Stop()
+    c.AddSite(uptime.Endpoint{
+        ID:        "api",
+        URL:       ts.URL + "/prod/health",
+        Frequency: 10 * time.Millisecond,
+        Signer:    sigv4.WithAWSSigV4("eu-west-1", "execute-api", testCreds),
+    })
+
+    select {
+    case got := <-auth:
+        if !strings.HasPrefix(got, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(got, "/eu-west-1/execute-api/aws4_request") {
+            t.Fatalf("unexpected Authorization header %q", got)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatalf("timed out waiting for signed request")
+    }
+}
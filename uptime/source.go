@@ -0,0 +1,42 @@
+package uptime
+
+import (
+    "net"
+    "strings"
+
+    "go.uber.org/zap"
+)
+
+// ===== Source Addresses =====
+
+// parseSourceAddrs returns the valid IP addresses in addresses, in
+// canonical form, and the entries that are not IP addresses.
+func parseSourceAddrs(addresses []string) (valid, invalid []string) {
+    for _, a := range addresses {
+        a = strings.TrimSpace(a)
+        if ip := net.ParseIP(strings.Trim(a, "[]")); ip != nil {
+            valid = append(valid, ip.String())
+        } else if a != "" {
+            invalid = append(invalid, a)
+        }
+    }
+    return valid, invalid
+}
+
+// nextSourceAddr returns the local address for the next check, round-robin
+// over WithSourceAddresses, or "" to let the system choose.
+func (c *Checker) nextSourceAddr() string {
+    if len(c.sourceAddrs) == 0 {
+        return ""
+    }
+    n := c.nextSource.Add(1) - 1
+    return c.sourceAddrs[int(n%uint32(len(c.sourceAddrs)))]
+}
+
+// logInvalidSourceAddrs reports the WithSourceAddresses entries that were
+// dropped.
+func (c *Checker) logInvalidSourceAddrs() {
+    if len(c.invalidSourceAddrs) > 0 {
+        c.ilog(LogError, "source_addresses_invalid", zap.Strings("addresses", c.invalidSourceAddrs))
+    }
+}
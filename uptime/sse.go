@@ -0,0 +1,99 @@
+package uptime
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "time"
+)
+
+// ===== Transition Subscriptions =====
+
+// sseKeepAlive is how often ServeSSE writes a comment line on an idle
+// stream, so proxies do not close it.
+const sseKeepAlive = 15 * time.Second
+
+// SubscribeTransitions returns a channel receiving every status transition
+// from now on, and a function ending the subscription and closing the
+// channel. Transitions within an endpoint's AlertGracePeriod are held back
+// as for notifiers: a DOWN is sent when the period ends with the endpoint
+// still DOWN. buffer is the channel capacity (at least 1). Delivery never
+// blocks checks: an event is dropped for a subscriber whose buffer is full.
+func (c *Checker) SubscribeTransitions(buffer int) (<-chan TransitionEvent, func()) {
+    if buffer < 1 {
+        buffer = 1
+    }
+    ch := make(chan TransitionEvent, buffer)
+    c.subsMu.Lock()
+    c.subs[ch] = struct{}{}
+    c.subsMu.Unlock()
+    cancel := func() {
+        c.subsMu.Lock()
+        defer c.subsMu.Unlock()
+        if _, ok := c.subs[ch]; ok {
+            delete(c.subs, ch)
+            close(ch)
+        }
+    }
+    return ch, cancel
+}
+
+// publishTransition fans ev out to every subscriber without blocking.
+func (c *Checker) publishTransition(ev TransitionEvent) {
+    c.subsMu.Lock()
+    defer c.subsMu.Unlock()
+    for ch := range c.subs {
+        select {
+        case ch <- ev:
+        default:
+        }
+    }
+}
+
+// ServeSSE streams status transitions to w as Server-Sent Events, one
+// "transition" event with a JSON TransitionEvent per change, until the
+// client disconnects or the Checker is stopped. A keep-alive comment is
+// written every 15s while the stream is idle. It responds 500 if w cannot
+// be flushed.
+func (c *Checker) ServeSSE(w http.ResponseWriter, r *http.Request) {
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+        return
+    }
+    events, cancel := c.SubscribeTransitions(64)
+    defer cancel()
+
+    h := w.Header()
+    h.Set("Content-Type", "text/event-stream")
+    h.Set("Cache-Control", "no-cache")
+    h.Set("Connection", "keep-alive")
+    w.WriteHeader(http.StatusOK)
+    flusher.Flush()
+
+    keepAlive := time.NewTicker(sseKeepAlive)
+    defer keepAlive.Stop()
+    for {
+        select {
+        case <-r.Context().Done():
+            return
+        case <-c.stopCh:
+            return
+        case <-keepAlive.C:
+            if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+                return
+            }
+            flusher.Flush()
+        case ev := <-events:
+            data, err := json.Marshal(ev)
+            if err != nil {
+                continue
+            }
+            if _, err := fmt.Fprintf(w, "event: transition\ndata: %s\n\n", data); err != nil {
+                return
+            }
+            flusher.Flush()
+            keepAlive.Reset(sseKeepAlive)
+        }
+    }
+}
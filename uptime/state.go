@@ -0,0 +1,84 @@
+package uptime
+
+import (
+    "sort"
+    "time"
+)
+
+// ===== Scheduler State =====
+
+// State is the scheduler state saved by DumpState, e.g. as JSON across a
+// process restart.
+type State struct {
+    NextRuns map[string]time.Time `json:"next_runs"` // next scheduled check per endpoint ID
+}
+
+// DumpState returns the next scheduled check time of every endpoint,
+// including restored times not yet scheduled.
+func (c *Checker) DumpState() State {
+    now := time.Now()
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    st := State{NextRuns: make(map[string]time.Time, len(c.endpoints))}
+    for _, ep := range c.endpoints {
+        if at, ok := c.resumeAt[ep.ID]; ok {
+            st.NextRuns[ep.ID] = at
+        } else if base, ok := c.tickBase[ep.ID]; ok {
+            st.NextRuns[ep.ID] = nextTick(base, ep.Frequency, now)
+        }
+    }
+    return st
+}
+
+// LoadState restores next run times saved by DumpState. An endpoint
+// scheduled afterwards runs at its saved time and keeps its cadence from
+// there instead of waiting a full interval; a saved time already in the past
+// runs it once immediately. Remaining intervals are capped at the current
+// Frequency. Call it before Start; endpoints already scheduled are not
+// affected.
+func (c *Checker) LoadState(st State) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for id, at := range st.NextRuns {
+        if _, scheduled := c.siteStop[id]; !scheduled {
+            c.resumeAt[id] = at
+        }
+    }
+}
+
+// ScheduledCheck is an upcoming scheduled check, see UpcomingChecks.
+type ScheduledCheck struct {
+    Endpoint Endpoint  `json:"endpoint"`
+    NextRun  time.Time `json:"next_run"`
+}
+
+// UpcomingChecks returns the scheduled endpoints whose next check is due
+// within the given window from now, soonest first.
+func (c *Checker) UpcomingChecks(within time.Duration) []ScheduledCheck {
+    now := time.Now()
+    deadline := now.Add(within)
+    c.mu.RLock()
+    var out []ScheduledCheck
+    for _, ep := range c.endpoints {
+        base, ok := c.tickBase[ep.ID]
+        if !ok {
+            continue
+        }
+        if next := nextTick(base, ep.Frequency, now); !next.After(deadline) {
+            out = append(out, ScheduledCheck{Endpoint: ep, NextRun: next})
+        }
+    }
+    c.mu.RUnlock()
+    sort.SliceStable(out, func(i, j int) bool { return out[i].NextRun.Before(out[j].NextRun) })
+    return out
+}
+
+// nextTick returns the first tick after now of a ticker with period freq
+// whose ticks are counted from base. base itself counts when it is still
+// ahead, as for resumed endpoints.
+func nextTick(base time.Time, freq time.Duration, now time.Time) time.Time {
+    if now.Before(base) {
+        return base
+    }
+    return base.Add((now.Sub(base)/freq + 1) * freq)
+}
@@ -0,0 +1,84 @@
+package uptime
+
+import (
+    "go.uber.org/zap"
+)
+
+// ===== State Change Hook =====
+
+type stateChange struct {
+    ep       Endpoint
+    from, to bool
+}
+
+// OnStateChange registers fn to be called whenever an endpoint's check
+// outcome flips: from and to are the Success of its previous and latest
+// result, regardless of FailureThreshold. The first result of an endpoint
+// is reported with from == to, as its previous state is unknown. Results
+// within the endpoint's AlertGracePeriod are not reported: the first result
+// after it counts as the first. fn runs on a dedicated goroutine, in order
+// of the results; if fn falls behind, further events are discarded and
+// logged rather than blocking. Passing nil removes the hook.
+func (c *Checker) OnStateChange(fn func(ep Endpoint, from, to bool)) {
+    c.mu.Lock()
+    c.onStateChange = fn
+    c.mu.Unlock()
+}
+
+// observeStateChange updates the last known outcome of res's endpoint and
+// queues an event for the OnStateChange hook when it flipped. Cancelled
+// checks and results within the alert grace period are ignored.
+func (c *Checker) observeStateChange(res Result) {
+    if res.FailureKind == FailureCancelled {
+        return
+    }
+    id := res.Endpoint.ID
+    c.mu.Lock()
+    if c.inGraceLocked(res.Endpoint, res.Timestamp) {
+        c.mu.Unlock()
+        return
+    }
+    prev, seen := c.lastSuccess[id]
+    c.lastSuccess[id] = res.Success
+    fn := c.onStateChange
+    c.mu.Unlock()
+    if fn == nil || (seen && prev == res.Success) {
+        return
+    }
+    if !seen {
+        prev = res.Success
+    }
+    select {
+    case c.stateChanges <- stateChange{ep: res.Endpoint, from: prev, to: res.Success}:
+    default:
+        c.ilog(LogError, "state_change_dropped", endpointFields(res.Endpoint, zap.Bool("success", res.Success))...)
+    }
+}
+
+// stateChangeDispatcher delivers state changes to the OnStateChange hook
+// until Stop.
+func (c *Checker) stateChangeDispatcher() {
+    defer c.wg.Done()
+    for {
+        select {
+        case <-c.stopCh:
+            return
+        case ev := <-c.stateChanges:
+            c.mu.RLock()
+            fn := c.onStateChange
+            c.mu.RUnlock()
+            if fn != nil {
+                c.callStateChangeHook(fn, ev)
+            }
+        }
+    }
+}
+
+func (c *Checker) callStateChangeHook(fn func(Endpoint, bool, bool), ev stateChange) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "state_change_hook_panic", endpointFields(ev.ep, zap.Any("panic", r))...)
+        }
+    }()
+    fn(ev.ep, ev.from, ev.to)
+}
@@ -0,0 +1,191 @@
+package uptime
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "time"
+)
+
+// ===== Status Aggregation =====
+
+// StatusSnapshot returns the current status of every registered endpoint,
+// in registration order.
+func (c *Checker) StatusSnapshot() []EndpointStatus {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    out := make([]EndpointStatus, 0, len(c.endpoints))
+    for _, ep := range c.endpoints {
+        out = append(out, c.endpointStatusLocked(ep))
+    }
+    return out
+}
+
+// Uptime returns the percentage (0-100) of retained checks for the endpoint
+// that succeeded. It returns 0 when no checks have been recorded. With
+// WithScheduledUptimeOnly only scheduled checks are counted.
+func (c *Checker) Uptime(id string) float64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.uptimePercent(c.logs[id])
+}
+
+// EffectiveFrequency returns the interval at which the endpoint is
+// currently checked: its Frequency, or the longer interval in force while it
+// is in timeout backoff (see WithTimeoutBackoff). It returns 0 for an
+// unknown endpoint.
+func (c *Checker) EffectiveFrequency(id string) time.Duration {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    ep, ok := c.endpointLocked(id)
+    if !ok {
+        return 0
+    }
+    return c.effectiveFrequencyLocked(ep)
+}
+
+func (c *Checker) effectiveFrequencyLocked(ep Endpoint) time.Duration {
+    if st := c.backoffs[ep.ID]; st != nil && st.interval > 0 {
+        return st.interval
+    }
+    return ep.Frequency
+}
+
+// ServiceUptime returns the availability (0-100) of a service made of
+// several endpoints: the Uptime of each endpoint ID in weights, weighted by
+// its value, e.g. {"api": 0.7, "static": 0.3}. Weights are relative and need
+// not sum to 1; a zero weight leaves its endpoint out. Endpoints that are
+// unknown or have no checks yet are left out too and the rest reweighted;
+// it returns 0 when nothing is left. A negative or non-finite weight, or no
+// positive one, is an error.
+func (c *Checker) ServiceUptime(weights map[string]float64) (float64, error) {
+    positive := false
+    for id, w := range weights {
+        if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+            return 0, fmt.Errorf("invalid weight %v for endpoint %q", w, id)
+        }
+        positive = positive || w > 0
+    }
+    if !positive {
+        return 0, errors.New("service uptime needs a positive weight")
+    }
+
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    var sum, total float64
+    for id, w := range weights {
+        ok, n := c.uptimeCounts(c.logs[id])
+        if w == 0 || n == 0 {
+            continue
+        }
+        sum += w * float64(ok) / float64(n) * 100
+        total += w
+    }
+    if total == 0 {
+        return 0, nil
+    }
+    return sum / total, nil
+}
+
+func (c *Checker) endpointStatusLocked(ep Endpoint) EndpointStatus {
+    logs := c.logs[ep.ID]
+    st := EndpointStatus{
+        Endpoint:           ep,
+        Status:             StatusUnknown,
+        Uptime:             c.uptimePercent(logs),
+        Checks:             len(logs),
+        Baseline:           c.baselineLocked(ep),
+        EffectiveFrequency: c.effectiveFrequencyLocked(ep),
+        Quarantined:        c.quarantinedLocked(ep.ID),
+        InGracePeriod:      c.inGraceLocked(ep, time.Now()),
+    }
+    if in := c.incidents[ep.ID]; len(in) > 0 {
+        last := copyIncidents(in[len(in)-1:])[0]
+        st.LastIncident = &last
+    }
+    if len(logs) > 0 {
+        last := logs[len(logs)-1]
+        st.LastResult = &last
+        st.LastCheck = last.Timestamp
+        st.Status = currentStatus(ep, logs)
+    }
+    if c.inMaintenanceLocked(ep, time.Now()) {
+        st.Status = StatusMaintenance
+    }
+    return st
+}
+
+// currentStatus is the status of ep given its logs, with PARTIAL for an UP
+// endpoint whose last check failed on some network paths and DEGRADED for
+// one whose last check was slow.
+func currentStatus(ep Endpoint, logs []Result) Status {
+    st := statusFromLogs(ep, logs)
+    if st != StatusUp {
+        return st
+    }
+    switch last := logs[len(logs)-1]; {
+    case last.Partial:
+        return StatusPartial
+    case last.Degraded:
+        return StatusDegraded
+    }
+    return st
+}
+
+// statusFromLogs applies ep's failure thresholds to the trailing run of
+// failed checks: DOWN once any failure kind reaches its threshold, otherwise
+// the status before the run (UP, or UNKNOWN when no check has passed).
+func statusFromLogs(ep Endpoint, logs []Result) Status {
+    counts := make(map[FailureKind]int)
+    for i := len(logs) - 1; i >= 0; i-- {
+        r := logs[i]
+        if !r.counted() {
+            continue
+        }
+        if r.Success {
+            return StatusUp
+        }
+        counts[r.FailureKind]++
+        if counts[r.FailureKind] >= failureThreshold(ep, r.FailureKind) {
+            return StatusDown
+        }
+    }
+    return StatusUnknown
+}
+
+func failureThreshold(ep Endpoint, kind FailureKind) int {
+    if n, ok := ep.FailureThresholds[kind]; ok {
+        return n
+    }
+    if ep.FailureThreshold > 0 {
+        return ep.FailureThreshold
+    }
+    return 1
+}
+
+func (c *Checker) uptimePercent(logs []Result) float64 {
+    ok, total := c.uptimeCounts(logs)
+    if total == 0 {
+        return 0
+    }
+    return float64(ok) / float64(total) * 100
+}
+
+// uptimeCounts returns how many of the checks in logs that count towards
+// uptime succeeded, and how many there are.
+func (c *Checker) uptimeCounts(logs []Result) (ok, total int) {
+    for _, r := range logs {
+        if !r.counted() || r.Origin == OriginInjected || c.scheduledUptimeOnly && !r.Origin.scheduled() {
+            continue
+        }
+        total++
+        if r.Success {
+            ok++
+        }
+    }
+    return ok, total
+}
+
+// scheduled reports whether o is a regular scheduled check. Results without
+// an origin predate the field and count as scheduled.
+func (o Origin) scheduled() bool { return o == "" || o == OriginScheduled }
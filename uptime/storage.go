@@ -0,0 +1,60 @@
+package uptime
+
+import "go.uber.org/zap"
+
+// ===== Persistent Storage =====
+
+// Storage persists results beyond the in-memory logs, see WithStorage.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+    // Append stores res.
+    Append(res Result) error
+    // Recent returns up to limit of the newest results of the endpoint,
+    // oldest first.
+    Recent(id string, limit int) ([]Result, error)
+}
+
+// IncidentStorage is implemented by a Storage that also persists incidents
+// and their notes; the checker uses it when available.
+type IncidentStorage interface {
+    // SaveIncidents replaces the stored incidents of the endpoint.
+    SaveIncidents(id string, incidents []Incident) error
+    // LoadIncidents returns the stored incidents of the endpoint.
+    LoadIncidents(id string) ([]Incident, error)
+}
+
+// restoreLogs loads the recent results (and incidents) of endpoints that
+// have none retained from storage. Restored results carry the endpoint as registered now.
+func (c *Checker) restoreLogs(eps []Endpoint) {
+    if c.storage == nil {
+        return
+    }
+    c.restoreIncidents(eps)
+    for _, ep := range eps {
+        c.mu.RLock()
+        have := len(c.logs[ep.ID]) > 0
+        c.mu.RUnlock()
+        if have {
+            continue
+        }
+        recent, err := c.storage.Recent(ep.ID, c.retentionFor(ep))
+        if err != nil {
+            c.ilog(LogError, "storage_restore_failed", endpointFields(ep, zap.Error(err))...)
+            continue
+        }
+        if len(recent) == 0 {
+            continue
+        }
+        for i := range recent {
+            recent[i].Endpoint = ep
+        }
+        c.mu.Lock()
+        if len(c.logs[ep.ID]) == 0 {
+            c.logs[ep.ID] = recent
+            c.noteHeadLocked(ep.ID)
+            c.addLogsLocked(len(recent))
+        }
+        c.mu.Unlock()
+        c.ilog(LogInfo, "logs_restored", endpointFields(ep, zap.Int("count", len(recent)))...)
+    }
+}
@@ -0,0 +1,87 @@
+package uptime
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "text/template"
+)
+
+// ===== Templated Endpoints =====
+
+// ExpandTemplates replaces every endpoint that has Vars with one endpoint per
+// variable set, rendering URL and Name as text/template templates (e.g.
+// "https://{{.Region}}.api.example.com/health"). Each generated endpoint gets
+// a stable ID: the template's ID followed by the variable values in key
+// order, e.g. "api-eu-west-1". Endpoints without Vars are returned as is.
+// AddSite, AddSitesBulk and LoadFromFile expand templates automatically.
+func ExpandTemplates(eps []Endpoint) ([]Endpoint, error) {
+    out := make([]Endpoint, 0, len(eps))
+    for _, ep := range eps {
+        if len(ep.Vars) == 0 {
+            out = append(out, ep)
+            continue
+        }
+        expanded, err := expandTemplate(ep)
+        if err != nil {
+            return nil, fmt.Errorf("endpoint %q: %w", ep.ID, err)
+        }
+        out = append(out, expanded...)
+    }
+    return out, nil
+}
+
+func expandTemplate(ep Endpoint) ([]Endpoint, error) {
+    urlTmpl, err := template.New("url").Option("missingkey=error").Parse(ep.URL)
+    if err != nil {
+        return nil, fmt.Errorf("url template: %w", err)
+    }
+    nameTmpl, err := template.New("name").Option("missingkey=error").Parse(ep.Name)
+    if err != nil {
+        return nil, fmt.Errorf("name template: %w", err)
+    }
+
+    out := make([]Endpoint, 0, len(ep.Vars))
+    for i, vars := range ep.Vars {
+        e := ep
+        e.Vars = nil
+        if e.URL, err = render(urlTmpl, vars); err != nil {
+            return nil, fmt.Errorf("vars %d: %w", i, err)
+        }
+        if e.Name, err = render(nameTmpl, vars); err != nil {
+            return nil, fmt.Errorf("vars %d: %w", i, err)
+        }
+        e.ID = templateID(ep.ID, vars)
+        out = append(out, e)
+    }
+    return out, nil
+}
+
+func render(t *template.Template, vars map[string]string) (string, error) {
+    var b strings.Builder
+    if err := t.Execute(&b, vars); err != nil {
+        return "", err
+    }
+    return b.String(), nil
+}
+
+// templateID derives a stable ID from base and the variable values, ordered
+// by key, with anything but letters, digits, '-' and '_' replaced by '-'.
+func templateID(base string, vars map[string]string) string {
+    keys := make([]string, 0, len(vars))
+    for k := range vars {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    parts := []string{base}
+    for _, k := range keys {
+        parts = append(parts, strings.Map(func(r rune) rune {
+            switch {
+            case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+                return r
+            }
+            return '-'
+        }, vars[k]))
+    }
+    return strings.Join(parts, "-")
+}
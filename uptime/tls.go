@@ -0,0 +1,208 @@
+package uptime
+
+import (
+    "context"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/rsa"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// ===== Certificate Posture =====
+
+// certIssues verifies the peer certificate chain and hostname of an
+// established connection independently of how the connection itself was
+// verified, returning one message per problem found.
+func certIssues(state *tls.ConnectionState, host string) []string {
+    if len(state.PeerCertificates) == 0 {
+        return []string{"no peer certificate presented"}
+    }
+    leaf := state.PeerCertificates[0]
+
+    var issues []string
+    if err := leaf.VerifyHostname(host); err != nil {
+        issues = append(issues, fmt.Sprintf("certificate hostname mismatch: %v", err))
+    }
+
+    intermediates := x509.NewCertPool()
+    for _, cert := range state.PeerCertificates[1:] {
+        intermediates.AddCert(cert)
+    }
+    _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates})
+    var unknown x509.UnknownAuthorityError
+    switch {
+    case err == nil:
+    case isSelfSigned(leaf):
+        issues = append(issues, "self-signed certificate")
+    case errors.As(err, &unknown):
+        issues = append(issues, fmt.Sprintf("incomplete or untrusted certificate chain: %v", err))
+    default:
+        issues = append(issues, fmt.Sprintf("certificate verification failed: %v", err))
+    }
+    return issues
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+    return cert.CheckSignatureFrom(cert) == nil
+}
+
+func checksCertStrength(ep Endpoint) bool { return ep.MinKeyBits > 0 || len(ep.DeniedSigAlgs) > 0 }
+
+// certStrength records the leaf certificate's signature algorithm and key on
+// res and returns a message when they are weaker than ep allows.
+func certStrength(ep Endpoint, state *tls.ConnectionState, res *Result) string {
+    if len(state.PeerCertificates) == 0 {
+        return ""
+    }
+    leaf := state.PeerCertificates[0]
+    res.CertSignatureAlgorithm = leaf.SignatureAlgorithm.String()
+    res.CertKeyType, res.CertKeyBits = keyInfo(leaf)
+
+    for _, denied := range ep.DeniedSigAlgs {
+        if sigAlgMatches(res.CertSignatureAlgorithm, denied) {
+            return fmt.Sprintf("certificate signature algorithm %s is denied", res.CertSignatureAlgorithm)
+        }
+    }
+    if ep.MinKeyBits > 0 && res.CertKeyType == "RSA" && res.CertKeyBits < ep.MinKeyBits {
+        return fmt.Sprintf("certificate RSA key is %d bits, below %d", res.CertKeyBits, ep.MinKeyBits)
+    }
+    return ""
+}
+
+func keyInfo(cert *x509.Certificate) (string, int) {
+    switch k := cert.PublicKey.(type) {
+    case *rsa.PublicKey:
+        return "RSA", k.N.BitLen()
+    case *ecdsa.PublicKey:
+        return "ECDSA", k.Curve.Params().BitSize
+    case ed25519.PublicKey:
+        return "Ed25519", 256
+    }
+    return cert.PublicKeyAlgorithm.String(), 0
+}
+
+// sigAlgMatches reports whether the algorithm name (e.g. "SHA1-RSA") is
+// denied: either the whole name or one of its parts matches, so "SHA1"
+// denies every SHA-1 based signature.
+func sigAlgMatches(alg, denied string) bool {
+    if strings.EqualFold(alg, denied) {
+        return true
+    }
+    for _, part := range strings.Split(alg, "-") {
+        if strings.EqualFold(part, denied) {
+            return true
+        }
+    }
+    return false
+}
+
+// ===== TLS Listener Checks =====
+
+// checkTLS performs a TLS handshake with ep.URL (tls://host:port, port 443
+// by default) without sending a request, and applies the certificate
+// checks of ep to the connection.
+func (c *Checker) checkTLS(ctx context.Context, ep Endpoint) Result {
+    start := time.Now()
+    res := Result{Endpoint: ep, Timestamp: start}
+    addr, host, err := tlsAddr(ep.URL)
+    if err != nil {
+        res.Latency = time.Since(start)
+        res.Error = err.Error()
+        res.FailureKind = FailureOther
+        return res
+    }
+    if timeout := c.httpClient.Timeout; timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, timeout)
+        defer cancel()
+    }
+    dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, InsecureSkipVerify: ep.InsecureSkipVerify}}
+    var conn net.Conn
+    if dial := c.dialContext(); dial != nil {
+        conn, err = tlsHandshake(ctx, dial, addr, dialer.Config)
+    } else {
+        conn, err = dialer.DialContext(ctx, "tcp", addr)
+    }
+    res.Latency = time.Since(start)
+    if err != nil {
+        res.Error = err.Error()
+        res.FailureKind = classifyError(err)
+        return res
+    }
+    defer conn.Close()
+    state := conn.(*tls.Conn).ConnectionState()
+    res.TLSVersion = tls.VersionName(state.Version)
+    if len(state.PeerCertificates) > 0 {
+        res.CertExpiry = state.PeerCertificates[0].NotAfter
+    }
+    res.Success = true
+    if msg := certPosture(ep, &state, host, &res); msg != "" {
+        res.Success = false
+        res.Error = msg
+        res.FailureKind = FailureTLS
+    }
+    return res
+}
+
+// tlsAddr returns the dial address and server name of a tls:// URL.
+func tlsAddr(raw string) (addr, host string, err error) {
+    u, err := url.Parse(raw)
+    if err != nil || u.Scheme != "tls" || u.Hostname() == "" {
+        return "", "", fmt.Errorf("tls endpoint needs a tls://host:port url, got %q", raw)
+    }
+    port := u.Port()
+    if port == "" {
+        port = "443"
+    }
+    return net.JoinHostPort(u.Hostname(), port), u.Hostname(), nil
+}
+
+// dialContext returns the dial function of the base transport, e.g. the one
+// installed by WithResolver, or nil for the default.
+func (c *Checker) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+    if tr, ok := c.httpClient.Transport.(*http.Transport); ok {
+        return tr.DialContext
+    }
+    return nil
+}
+
+// tlsHandshake dials addr with dial and runs a TLS client handshake on it.
+func tlsHandshake(ctx context.Context, dial func(context.Context, string, string) (net.Conn, error), addr string, cfg *tls.Config) (net.Conn, error) {
+    raw, err := dial(ctx, "tcp", addr)
+    if err != nil {
+        return nil, err
+    }
+    conn := tls.Client(raw, cfg)
+    if err := conn.HandshakeContext(ctx); err != nil {
+        raw.Close()
+        return nil, err
+    }
+    return conn, nil
+}
+
+// certPosture applies ep's VerifyCertInfo and certificate strength checks
+// to state. It returns a message when the check fails; weak certificates
+// under DegradeOnWeakCert flag res Degraded instead.
+func certPosture(ep Endpoint, state *tls.ConnectionState, host string, res *Result) string {
+    if ep.VerifyCertInfo {
+        res.CertErrors = certIssues(state, host)
+    }
+    if checksCertStrength(ep) {
+        if msg := certStrength(ep, state, res); msg != "" {
+            if !ep.DegradeOnWeakCert {
+                return msg
+            }
+            res.Degraded = res.Success
+            res.CertErrors = append(res.CertErrors, msg)
+        }
+    }
+    return ""
+}
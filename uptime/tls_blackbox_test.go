@@ -0,0 +1,105 @@
+package uptime_test
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+
+    up "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// Certificate problems are reported without failing an insecure connection check.
+func TestVerifyCertInfo_ReportsWithoutFailing(t *testing.T) {
+    ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    // The test certificate is self-signed and only valid for example.com and 127.0.0.1.
+    url := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+    res := checkOnce(t, up.Endpoint{ID: "tls", URL: url, InsecureSkipVerify: true, VerifyCertInfo: true})
+    if !res.Success {
+        t.Fatalf("expected connection check to succeed, got error=%s", res.Error)
+    }
+    joined := strings.Join(res.CertErrors, "; ")
+    if !strings.Contains(joined, "hostname mismatch") || !strings.Contains(joined, "self-signed") {
+        t.Fatalf("expected hostname and self-signed issues, got %q", joined)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "plain", URL: url, InsecureSkipVerify: true})
+    if len(res.CertErrors) != 0 {
+        t.Fatalf("expected no cert inspection without VerifyCertInfo, got %v", res.CertErrors)
+    }
+}
+
+// Weak keys and denied signature algorithms fail the check, or flag it
+// degraded; the certificate details are recorded either way.
+func TestCertStrength(t *testing.T) {
+    ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer ts.Close()
+    alg := ts.Certificate().SignatureAlgorithm.String() // SHA256-RSA, 2048 bits
+
+    res := checkOnce(t, up.Endpoint{ID: "ok", URL: ts.URL, InsecureSkipVerify: true, MinKeyBits: 2048, DeniedSigAlgs: []string{"SHA1", "MD5"}})
+    if !res.Success || res.CertSignatureAlgorithm != alg || res.CertKeyType != "RSA" || res.CertKeyBits != 2048 {
+        t.Fatalf("expected pass with recorded cert details, got %+v", res)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "short", URL: ts.URL, InsecureSkipVerify: true, MinKeyBits: 4096})
+    if res.Success || res.FailureKind != up.FailureTLS || !strings.Contains(res.Error, "2048 bits, below 4096") {
+        t.Fatalf("expected weak key failure, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "denied", URL: ts.URL, InsecureSkipVerify: true, DeniedSigAlgs: []string{"sha256"}})
+    if res.Success || !strings.Contains(res.Error, alg+" is denied") {
+        t.Fatalf("expected denied algorithm failure, got %+v", res)
+    }
+    res = checkOnce(t, up.Endpoint{ID: "soft", URL: ts.URL, InsecureSkipVerify: true, MinKeyBits: 4096, DegradeOnWeakCert: true})
+    if !res.Success || !res.Degraded || len(res.CertErrors) != 1 {
+        t.Fatalf("expected degraded success, got %+v", res)
+    }
+
+    plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer plain.Close()
+    if res := checkOnce(t, up.Endpoint{ID: "http", URL: plain.URL, MinKeyBits: 4096}); !res.Success || res.CertKeyBits != 0 {
+        t.Fatalf("expected plain HTTP to be skipped, got %+v", res)
+    }
+}
+
+// A tls endpoint only completes the handshake and reports the negotiated
+// version, certificate expiry and posture.
+func TestTLSEndpoint(t *testing.T) {
+    var requests atomic.Int64
+    ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { requests.Add(1) }))
+    defer ts.Close()
+    addr := "tls://" + ts.Listener.Addr().String()
+
+    res := checkOnce(t, up.Endpoint{ID: "tls", Type: up.TypeTLS, URL: addr, InsecureSkipVerify: true, VerifyCertInfo: true})
+    if !res.Success || res.TLSVersion != "TLS 1.3" {
+        t.Fatalf("expected a TLS 1.3 handshake, got %+v", res)
+    }
+    if !res.CertExpiry.Equal(ts.Certificate().NotAfter) {
+        t.Fatalf("cert expiry %v, want %v", res.CertExpiry, ts.Certificate().NotAfter)
+    }
+    if !strings.Contains(strings.Join(res.CertErrors, "; "), "self-signed") {
+        t.Fatalf("expected cert posture issues, got %v", res.CertErrors)
+    }
+    if n := requests.Load(); n != 0 {
+        t.Fatalf("expected no HTTP request, got %d", n)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "untimed", Type: up.TypeTLS, URL: addr, InsecureSkipVerify: true}, up.WithTimeout(0))
+    if !res.Success {
+        t.Fatalf("expected a handshake without timeout to succeed, got %+v", res)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "strict", Type: up.TypeTLS, URL: addr})
+    if res.Success || res.FailureKind != up.FailureTLS {
+        t.Fatalf("expected an untrusted certificate to fail, got %+v", res)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", Type: up.TypeTLS, URL: "https://example.com"}); err == nil {
+        t.Fatalf("expected a non-tls:// url to be rejected")
+    }
+}
@@ -0,0 +1,213 @@
+package uptime
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "sync"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// ===== Status Transitions =====
+
+// TransitionEvent records an endpoint moving between UP, DOWN, DEGRADED and
+// PARTIAL.
+type TransitionEvent struct {
+    Timestamp    time.Time     `json:"timestamp"`
+    EndpointID   string        `json:"endpoint_id"`
+    EndpointName string        `json:"endpoint_name,omitempty"`
+    From         Status        `json:"from"`
+    To           Status        `json:"to"`
+    Since        time.Time     `json:"since"`                  // when the endpoint entered From
+    Duration     time.Duration `json:"duration"`               // time spent in From
+    Error        string        `json:"error,omitempty"`        // error of the result causing the transition
+    FailureKind  FailureKind   `json:"failure_kind,omitempty"` // failure kind of that result
+    Escalation   int           `json:"escalation,omitempty"`   // re-notification number of an ongoing DOWN, with WithEscalation
+
+    cause *Result // the result causing the transition, for the failure action
+}
+
+// statusState is the last known status of an endpoint and since when.
+type statusState struct {
+    status Status
+    since  time.Time
+}
+
+// observeTransition updates the endpoint's known status from its logs after
+// res was recorded. It returns the transition res caused, or nil; the first
+// known status is a transition from UNKNOWN. Relapses to UNKNOWN are not
+// transitions.
+func (c *Checker) observeTransition(res Result) *TransitionEvent {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    now := currentStatus(res.Endpoint, c.logs[res.Endpoint.ID])
+    if now == StatusUnknown {
+        return nil
+    }
+    prev, ok := c.statuses[res.Endpoint.ID]
+    if ok && prev.status == now {
+        return nil
+    }
+    c.statuses[res.Endpoint.ID] = statusState{status: now, since: res.Timestamp}
+    if !ok {
+        return &TransitionEvent{
+            Timestamp:    res.Timestamp,
+            EndpointID:   res.Endpoint.ID,
+            EndpointName: res.Endpoint.Name,
+            From:         StatusUnknown,
+            To:           now,
+            Since:        res.Timestamp,
+            Error:        res.Error,
+            FailureKind:  res.FailureKind,
+        }
+    }
+    return &TransitionEvent{
+        Timestamp:    res.Timestamp,
+        EndpointID:   res.Endpoint.ID,
+        EndpointName: res.Endpoint.Name,
+        From:         prev.status,
+        To:           now,
+        Since:        prev.since,
+        Duration:     res.Timestamp.Sub(prev.since),
+        Error:        res.Error,
+        FailureKind:  res.FailureKind,
+    }
+}
+
+// ===== Transition Audit =====
+
+const (
+    defaultAuditMaxBytes = 10 << 20
+    defaultAuditBackups  = 5
+)
+
+// auditSink appends TransitionEvents as JSON lines to a file, rotating it to
+// path.1 ... path.<backups> once it would exceed maxBytes.
+type auditSink struct {
+    path     string
+    maxBytes int64
+    backups  int
+
+    mu     sync.Mutex
+    f      *os.File
+    size   int64
+    closed bool
+}
+
+func (a *auditSink) write(ev TransitionEvent) error {
+    line, err := json.Marshal(ev)
+    if err != nil {
+        return err
+    }
+    line = append(line, '\n')
+
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if a.closed {
+        return nil
+    }
+    if a.f == nil {
+        if err := a.openLocked(); err != nil {
+            return err
+        }
+    }
+    if a.size > 0 && a.size+int64(len(line)) > a.maxBytes {
+        if err := a.rotateLocked(); err != nil {
+            return err
+        }
+    }
+    n, err := a.f.Write(line)
+    a.size += int64(n)
+    return err
+}
+
+func (a *auditSink) openLocked() error {
+    f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+    if err != nil {
+        return err
+    }
+    info, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return err
+    }
+    a.f, a.size = f, info.Size()
+    return nil
+}
+
+func (a *auditSink) rotateLocked() error {
+    if err := a.f.Close(); err != nil {
+        return err
+    }
+    a.f = nil
+    if a.backups > 0 {
+        for i := a.backups - 1; i >= 1; i-- {
+            os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+        }
+        if err := os.Rename(a.path, a.path+".1"); err != nil {
+            return err
+        }
+    } else if err := os.Truncate(a.path, 0); err != nil {
+        return err
+    }
+    return a.openLocked()
+}
+
+func (a *auditSink) close() error {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.closed = true
+    if a.f == nil {
+        return nil
+    }
+    err := a.f.Close()
+    a.f = nil
+    return err
+}
+
+// handleTransition passes the transition res caused, if any, to incident
+// tracking and the audit file, to subscribers unless the endpoint is in its
+// alert grace period, and to the notifiers, among them the failure action,
+// unless it is in its grace period or quarantined. A DOWN transition held
+// back by the grace period goes out once the period ends if the endpoint is
+// still DOWN.
+func (c *Checker) handleTransition(res Result) {
+    if c.inMaintenance(res.Endpoint, res.Timestamp) {
+        return
+    }
+    ev := c.observeTransition(res)
+    quarantined := c.observeFlaps(res, ev)
+    held, released := c.observeGrace(res, ev)
+    c.logGrace(res, held, released)
+    if released {
+        pending := c.heldTransition(res.Endpoint.ID)
+        c.publishTransition(pending)
+        if !quarantined {
+            pending.cause = &res
+            c.alert(pending)
+        }
+    }
+    if ev == nil {
+        return
+    }
+    c.recordIncident(ev)
+    grace := c.inGrace(res.Endpoint, res.Timestamp)
+    if !grace {
+        c.publishTransition(*ev)
+    }
+    if ev.From == StatusDown {
+        c.cancelEscalations(res.Endpoint.ID)
+    }
+    if !quarantined && !grace {
+        alert := *ev
+        alert.cause = &res
+        c.alert(alert)
+    }
+    if c.audit != nil && ev.From != StatusUnknown {
+        if err := c.audit.write(*ev); err != nil {
+            c.ilog(LogError, "transition_audit_failed", endpointFields(res.Endpoint, zap.Error(err))...)
+        }
+    }
+}
@@ -0,0 +1,98 @@
+package uptime
+
+import (
+    "crypto/tls"
+    "fmt"
+    "net/http"
+    "net/url"
+)
+
+// ===== Per-endpoint HTTP Transports =====
+
+// effectiveProxy returns the proxy URL for ep: its own Proxy, else the
+// global WithProxy value, else "" (use the default transport).
+func (c *Checker) effectiveProxy(ep Endpoint) string {
+    if ep.Proxy != "" {
+        return ep.Proxy
+    }
+    return c.proxy
+}
+
+// transportKey identifies the transport settings an endpoint needs beyond
+// the base client's.
+type transportKey struct {
+    proxy    string
+    insecure bool
+    source   string // local address, with WithSourceAddresses or a network path
+    network  string // "tcp4" or "tcp6" of a network path
+    resolver string // DNS server of a network path
+}
+
+// clientFor returns the HTTP client used to check ep. Endpoints with default
+// transport settings share the base transport; the others get a transport
+// cached per distinct setting (e.g. proxy URL) so connections are reused
+// across checks. path carries the local address and, for multi-path
+// checks, the network path's settings.
+func (c *Checker) clientFor(ep Endpoint, path NetworkPath) (*http.Client, error) {
+    client := *c.httpClient
+    if expectsRedirect(ep) {
+        client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+    }
+    key := transportKey{proxy: c.effectiveProxy(ep), insecure: ep.InsecureSkipVerify, source: path.Source, network: path.Network, resolver: path.Resolver}
+    if key == (transportKey{}) {
+        return &client, nil
+    }
+
+    c.transportMu.Lock()
+    defer c.transportMu.Unlock()
+    tr, ok := c.transports[key]
+    if !ok {
+        tr = c.baseTransport()
+        if key.proxy != "" {
+            u, err := parseProxyURL(key.proxy)
+            if err != nil {
+                return nil, err
+            }
+            tr.Proxy = http.ProxyURL(u)
+        }
+        if key.insecure {
+            if tr.TLSClientConfig == nil {
+                tr.TLSClientConfig = &tls.Config{}
+            }
+            tr.TLSClientConfig.InsecureSkipVerify = true
+        }
+        if key.source != "" || key.network != "" || key.resolver != "" {
+            tr.DialContext = c.pathDialContext(key)
+        }
+        c.transports[key] = tr
+    }
+    client.Transport = tr
+    return &client, nil
+}
+
+// baseTransport returns a fresh transport derived from the base client's
+// transport (or http.DefaultTransport when none was configured).
+func (c *Checker) baseTransport() *http.Transport {
+    if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+        return t.Clone()
+    }
+    return http.DefaultTransport.(*http.Transport).Clone()
+}
+
+// parseProxyURL validates a proxy URL. Supported schemes are http, https,
+// socks5 and socks5h.
+func parseProxyURL(raw string) (*url.URL, error) {
+    u, err := url.Parse(raw)
+    if err != nil {
+        return nil, fmt.Errorf("invalid proxy url %q: %w", raw, err)
+    }
+    switch u.Scheme {
+    case "http", "https", "socks5", "socks5h":
+    default:
+        return nil, fmt.Errorf("invalid proxy url %q: unsupported scheme %q", raw, u.Scheme)
+    }
+    if u.Host == "" {
+        return nil, fmt.Errorf("invalid proxy url %q: missing host", raw)
+    }
+    return u, nil
+}
@@ -0,0 +1,437 @@
+package uptime_test
+
+import (
+    "encoding/binary"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "golang.org/x/net/http2"
+    "golang.org/x/net/http2/h2c"
+
+    up "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// A per-endpoint proxy receives the check request and the result records its use.
+func TestProxy_RoutesThroughProxy(t *testing.T) {
+    hosts := make(chan string, 10)
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        hosts <- r.URL.Host
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer proxy.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs())
+    c.Start()
+    defer c.Stop()
+
+    _, err := c.AddSite(up.Endpoint{
+        ID:             "behind-proxy",
+        URL:            "http://internal.example:8080/health",
+        Frequency:      10 * time.Millisecond,
+        ExpectedStatus: http.StatusNoContent,
+        Proxy:          proxy.URL,
+    })
+    if err != nil {
+        t.Fatalf("AddSite: %v", err)
+    }
+
+    select {
+    case res := <-c.Results():
+        if !res.Success || !res.ProxyUsed {
+            t.Fatalf("expected proxied success, got success=%v proxy_used=%v error=%s", res.Success, res.ProxyUsed, res.Error)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatalf("timed out waiting for result")
+    }
+    if h := <-hosts; h != "internal.example:8080" {
+        t.Fatalf("expected proxy to see target host, got %q", h)
+    }
+}
+
+// Invalid proxy URLs are rejected when the endpoint is registered.
+func TestProxy_InvalidURLRejected(t *testing.T) {
+    c := up.New(up.DisableLogs())
+    for _, p := range []string{"ftp://proxy:21", "socks5://", "://bad"} {
+        if _, err := c.AddSite(up.Endpoint{ID: p, URL: "http://example", Proxy: p}); err == nil {
+            t.Fatalf("expected error for proxy %q", p)
+        }
+    }
+    if n := len(c.ListSites()); n != 0 {
+        t.Fatalf("expected no registered sites, got %d", n)
+    }
+
+    g := up.New(up.DisableLogs(), up.WithProxy("gopher://nope"))
+    if _, err := g.AddSite(up.Endpoint{ID: "a", URL: "http://example"}); err == nil {
+        t.Fatalf("expected error for invalid global proxy")
+    }
+}
+
+// WithResolver resolves check hosts through the configured DNS server.
+func TestWithResolver(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    dns, err := net.ListenPacket("udp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("listen: %v", err)
+    }
+    defer dns.Close()
+    go serveFakeDNS(dns, net.IPv4(127, 0, 0, 1).To4())
+
+    port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+    res := checkOnce(t, up.Endpoint{ID: "dns", URL: "http://uptime-resolver.test:" + port}, up.WithResolver([]string{dns.LocalAddr().String()}))
+    if !res.Success {
+        t.Fatalf("expected host to resolve via custom resolver, got %+v", res)
+    }
+}
+
+// serveFakeDNS answers every A query with ip and every other query with no
+// records.
+func serveFakeDNS(pc net.PacketConn, ip net.IP) {
+    buf := make([]byte, 512)
+    for {
+        n, addr, err := pc.ReadFrom(buf)
+        if err != nil {
+            return
+        }
+        q := buf[:n]
+        end := 12
+        for end < n && q[end] != 0 {
+            end += int(q[end]) + 1
+        }
+        end += 5 // root label, qtype, qclass
+        if end > n {
+            continue
+        }
+        isA := binary.BigEndian.Uint16(q[end-4:]) == 1
+        resp := append([]byte(nil), q[:end]...)
+        binary.BigEndian.PutUint16(resp[2:], 0x8180) // response, recursion available
+        binary.BigEndian.PutUint16(resp[6:], 0)      // answers
+        binary.BigEndian.PutUint16(resp[8:], 0)
+        binary.BigEndian.PutUint16(resp[10:], 0)
+        if isA {
+            binary.BigEndian.PutUint16(resp[6:], 1)
+            resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+            resp = append(resp, ip...)
+        }
+        pc.WriteTo(resp, addr)
+    }
+}
+
+// User-Agents rotate per check and are recorded in the result.
+func TestUserAgentRotation(t *testing.T) {
+    seen := make(chan string, 10)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case seen <- r.UserAgent():
+        default:
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs(), up.WithUserAgentRotation([]string{"ua-1", "ua-2"}))
+    c.Start()
+    defer c.Stop()
+    c.AddSite(up.Endpoint{ID: "a", URL: ts.URL, Frequency: 10 * time.Millisecond})
+    var got []string
+    for i := 0; i < 3; i++ {
+        res := waitResult(t, c)
+        if sent := <-seen; sent != res.UserAgent {
+            t.Fatalf("result records %q, server saw %q", res.UserAgent, sent)
+        }
+        got = append(got, res.UserAgent)
+    }
+    if strings.Join(got, ",") != "ua-1,ua-2,ua-1" {
+        t.Fatalf("unexpected rotation %v", got)
+    }
+}
+
+// Forwarded fields set the X-Forwarded-* headers and an RFC 7239 Forwarded
+// header with IPv6 and ports quoted; explicit Headers still win.
+func TestForwardedHeaders(t *testing.T) {
+    var mu sync.Mutex
+    got := map[string]http.Header{}
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        got[r.URL.Path] = r.Header.Clone()
+        mu.Unlock()
+    }))
+    defer ts.Close()
+
+    checkOnce(t, up.Endpoint{ID: "v6", URL: ts.URL + "/v6", ForwardedFor: "2001:db8::1", ForwardedProto: "https", ForwardedHost: "shop.example.com:8443"})
+    mu.Lock()
+    h := got["/v6"]
+    mu.Unlock()
+    if h.Get("X-Forwarded-For") != "2001:db8::1" || h.Get("X-Real-IP") != "2001:db8::1" ||
+        h.Get("X-Forwarded-Proto") != "https" || h.Get("X-Forwarded-Host") != "shop.example.com:8443" {
+        t.Fatalf("unexpected X-Forwarded headers %v", h)
+    }
+    if want := `for="[2001:db8::1]";proto=https;host="shop.example.com:8443"`; h.Get("Forwarded") != want {
+        t.Fatalf("Forwarded = %q, want %q", h.Get("Forwarded"), want)
+    }
+
+    checkOnce(t, up.Endpoint{ID: "v4", URL: ts.URL + "/v4", ForwardedFor: "192.0.2.7", Headers: map[string]string{"X-Real-IP": "10.0.0.1"}})
+    mu.Lock()
+    h = got["/v4"]
+    mu.Unlock()
+    if h.Get("Forwarded") != "for=192.0.2.7" || h.Get("X-Real-IP") != "10.0.0.1" {
+        t.Fatalf("unexpected headers %v", h)
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, ForwardedFor: "not-an-ip"}); err == nil {
+        t.Fatalf("expected an invalid ForwardedFor to be rejected")
+    }
+}
+
+// gRPC health checks report the serving status over plaintext HTTP/2 and
+// succeed only for SERVING.
+func TestGRPC_ServingStatus(t *testing.T) {
+    statuses := map[string]byte{"api": 1, "batch": 2, "": 1}
+    var mu sync.Mutex
+    var paths []string
+    ts := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        paths = append(paths, r.URL.Path)
+        mu.Unlock()
+        frame, _ := io.ReadAll(r.Body)
+        service := ""
+        if len(frame) > 7 {
+            service = string(frame[7:]) // prefix, tag and length of a short name
+        }
+        w.Header().Set("Content-Type", "application/grpc")
+        w.Header().Set("Trailer", "Grpc-Status")
+        st, ok := statuses[service]
+        if !ok {
+            w.Header().Set("Grpc-Status", "5") // NOT_FOUND, trailers-only
+            return
+        }
+        msg := []byte{0x08, st}
+        hdr := make([]byte, 5)
+        binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+        w.Write(append(hdr, msg...))
+        w.Header().Set("Grpc-Status", "0")
+    }), &http2.Server{}))
+    defer ts.Close()
+
+    for _, tc := range []struct {
+        service, status string
+        success         bool
+    }{
+        {"", up.GRPCServing, true},
+        {"api", up.GRPCServing, true},
+        {"batch", up.GRPCNotServing, false},
+        {"missing", up.GRPCServiceUnknown, false},
+    } {
+        res := checkOnce(t, up.Endpoint{ID: "g", URL: ts.URL, Type: up.TypeGRPC, GRPCService: tc.service})
+        if res.GRPCStatus != tc.status || res.Success != tc.success {
+            t.Fatalf("service %q: got status %q success %v (err %q), want %q %v",
+                tc.service, res.GRPCStatus, res.Success, res.Error, tc.status, tc.success)
+        }
+        if !tc.success && res.FailureKind != up.FailureStatus {
+            t.Fatalf("service %q: failure kind %q, want %q", tc.service, res.FailureKind, up.FailureStatus)
+        }
+    }
+    mu.Lock()
+    defer mu.Unlock()
+    for _, p := range paths {
+        if p != "/grpc.health.v1.Health/Check" {
+            t.Fatalf("unexpected request path %q", p)
+        }
+    }
+
+    c := up.New(up.DisableLogs())
+    if _, err := c.AddSite(up.Endpoint{ID: "bad", URL: ts.URL, Type: "ftp"}); err == nil {
+        t.Fatalf("expected an unknown endpoint type to be rejected")
+    }
+}
+
+// The request decorator runs after built-in headers and a panic in it
+// fails the check instead of crashing the worker.
+func TestRequestDecorator(t *testing.T) {
+    got := make(chan http.Header, 10)
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got <- r.Header.Clone()
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "d", URL: ts.URL, Headers: map[string]string{"X-Env": "prod"}},
+        up.WithRequestDecorator(func(r *http.Request, ep up.Endpoint) {
+            r.Header.Set("Authorization", "Token "+ep.ID)
+            r.Header.Set("X-Env", "staging")
+        }))
+    if !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    h := <-got
+    if h.Get("Authorization") != "Token d" || h.Get("X-Env") != "staging" {
+        t.Fatalf("decorator headers not applied: %v", h)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "p", URL: ts.URL},
+        up.WithRequestDecorator(func(*http.Request, up.Endpoint) { panic("boom") }))
+    if res.Success || res.FailureKind != up.FailureOther || !strings.Contains(res.Error, "boom") {
+        t.Fatalf("expected a failed check from the panicking decorator, got %+v", res)
+    }
+}
+
+// MeasureDownload reports the time to first byte separately from the full
+// download of a slowly streamed body.
+func TestMeasureDownload(t *testing.T) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("head"))
+        w.(http.Flusher).Flush()
+        time.Sleep(200 * time.Millisecond)
+        w.Write([]byte(strings.Repeat("x", 1<<16)))
+    }))
+    defer ts.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "dl", URL: ts.URL, MeasureDownload: true})
+    if !res.Success || res.TTFB <= 0 || res.TTFB > 150*time.Millisecond {
+        t.Fatalf("unexpected TTFB %v (%+v)", res.TTFB, res)
+    }
+    if res.Latency < 200*time.Millisecond {
+        t.Fatalf("expected Latency to cover the full download, got %v", res.Latency)
+    }
+
+    res = checkOnce(t, up.Endpoint{ID: "plain", URL: ts.URL})
+    if res.TTFB != 0 || res.Latency > 150*time.Millisecond {
+        t.Fatalf("expected header latency without TTFB, got latency %v ttfb %v", res.Latency, res.TTFB)
+    }
+}
+
+// WarmConnection primes the pool with a HEAD request so the measured GET
+// reuses its connection.
+func TestWarmConnection(t *testing.T) {
+    var mu sync.Mutex
+    var methods []string
+    var conns int
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        methods = append(methods, r.Method)
+        mu.Unlock()
+    }))
+    srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+        if state == http.StateNew {
+            mu.Lock()
+            conns++
+            mu.Unlock()
+        }
+    }
+    srv.Start()
+    defer srv.Close()
+
+    res := checkOnce(t, up.Endpoint{ID: "w", URL: srv.URL, WarmConnection: true})
+    if !res.Success || !res.ConnReused {
+        t.Fatalf("expected a reused connection, got %+v", res)
+    }
+    mu.Lock()
+    if strings.Join(methods, ",") != "HEAD,GET" || conns != 1 {
+        t.Fatalf("requests %v over %d connections, want HEAD,GET over 1", methods, conns)
+    }
+    mu.Unlock()
+
+    if res := checkOnce(t, up.Endpoint{ID: "c", URL: srv.URL}); !res.Success || res.ConnReused {
+        t.Fatalf("expected no reuse report without WarmConnection, got %+v", res)
+    }
+
+    // The HEAD is built like the check: headers, decorator and signature.
+    var warm http.Header
+    hsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodHead {
+            mu.Lock()
+            warm = r.Header.Clone()
+            mu.Unlock()
+        }
+    }))
+    defer hsrv.Close()
+    decorate := up.WithRequestDecorator(func(r *http.Request, _ up.Endpoint) { r.Header.Set("X-Decorated", "1") })
+    ep := up.Endpoint{ID: "h", URL: hsrv.URL, Method: http.MethodPost, Body: "x", ContentType: "text/plain",
+        Headers: map[string]string{"X-Token": "t"}, HMACSecret: "s3cret", WarmConnection: true}
+    if res := checkOnce(t, ep, decorate); !res.Success {
+        t.Fatalf("expected success, got %+v", res)
+    }
+    mu.Lock()
+    defer mu.Unlock()
+    if warm.Get("X-Token") != "t" || warm.Get("X-Decorated") != "1" || warm.Get("X-Signature") == "" || warm.Get("Content-Type") != "" {
+        t.Fatalf("unexpected warm-up headers %v", warm)
+    }
+}
+
+// WithSourceAddresses sends checks from each local address in turn and
+// records the one used; invalid entries are dropped.
+func TestSourceAddresses(t *testing.T) {
+    remotes := make(chan string, 10)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        host, _, _ := net.SplitHostPort(r.RemoteAddr)
+        remotes <- host
+    }))
+    defer srv.Close()
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs(), up.WithSourceAddresses([]string{"127.0.0.1", " 127.0.0.2", "not-an-ip"}))
+    c.AddSite(up.Endpoint{ID: "s", URL: srv.URL, Frequency: 10 * time.Millisecond})
+    c.Start()
+    defer c.Stop()
+
+    for i, want := range []string{"127.0.0.1", "127.0.0.2", "127.0.0.1", "127.0.0.2"} {
+        res := waitResult(t, c)
+        if !res.Success || res.SourceAddr != want {
+            t.Fatalf("check %d: expected success from %s, got %+v", i, want, res)
+        }
+        if got := <-remotes; got != want {
+            t.Fatalf("check %d: server saw %s, want %s", i, got, want)
+        }
+    }
+}
+
+// A multi-path check reports each network path and flags the result Partial,
+// and the endpoint PARTIAL, when only some paths reach the endpoint.
+func TestMultiPath(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer srv.Close()
+    _, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+    url := "http://localhost:" + port // the server listens on IPv4 only
+
+    c := up.New(up.WithWorkers(1), up.DisableLogs(), up.WithNetworkPaths(
+        up.NetworkPath{Name: "v4", Network: "tcp4"},
+        up.NetworkPath{Name: "v6", Network: "tcp6"},
+        up.NetworkPath{Name: "bad", Network: "udp"},
+    ))
+    if _, err := c.AddSite(up.Endpoint{ID: "mp", URL: url, Frequency: 10 * time.Millisecond, MultiPath: true}); err != nil {
+        t.Fatalf("AddSite: %v", err)
+    }
+    c.Start()
+    defer c.Stop()
+
+    res := waitResult(t, c)
+    if !res.Success || !res.Partial || len(res.SubResults) != 2 {
+        t.Fatalf("expected a partial success over 2 paths, got %+v", res)
+    }
+    if s := res.SubResults; s[0].Path != "v4" || !s[0].Success || s[1].Path != "v6" || s[1].Success {
+        t.Fatalf("expected v4 to pass and v6 to fail, got %+v", s)
+    }
+    if st := c.StatusSnapshot()[0].Status; st != up.StatusPartial {
+        t.Fatalf("expected PARTIAL, got %s", st)
+    }
+
+    // Unreachable has to hold over every path.
+    inv := checkOnce(t, up.Endpoint{ID: "blocked", URL: url, MultiPath: true, ExpectUnreachable: true},
+        up.WithNetworkPaths(up.NetworkPath{Name: "v4", Network: "tcp4"}, up.NetworkPath{Name: "v6", Network: "tcp6"}))
+    if inv.Success || inv.Partial || !inv.SubResults[1].Success {
+        t.Fatalf("expected the open v4 path to fail the inverted check, got %+v", inv)
+    }
+
+    if _, err := up.New(up.DisableLogs()).AddSite(up.Endpoint{ID: "x", URL: url, MultiPath: true}); err == nil {
+        t.Fatalf("expected multi_path without network paths to be rejected")
+    }
+}
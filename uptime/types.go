@@ -1,34 +1,555 @@
 // Package uptime defines core types for the uptime checker.
 package uptime
 
-import "time"
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+)
 
 type LogLevel int
 
 const (
-    LogNone LogLevel = iota // no logs
-    LogError                // only errors
-    LogInfo                 // info + errors
-    LogDebug                // verbose
+    LogNone  LogLevel = iota // no logs
+    LogError                 // only errors
+    LogInfo                  // info + errors
+    LogDebug                 // verbose
+)
+
+// DuplicatePolicy controls what AddSite does with an endpoint whose ID is
+// already registered.
+type DuplicatePolicy int
+
+const (
+    DuplicateReject  DuplicatePolicy = iota // return ErrDuplicateID (default)
+    DuplicateReplace                        // replace the existing endpoint and reschedule it
+)
+
+// ErrDuplicateID is returned when registering an endpoint whose ID is taken.
+var ErrDuplicateID = errors.New("duplicate endpoint id")
+
+// ErrDuplicateURL is returned, with URL normalization enabled, when
+// registering an endpoint whose method and normalized URL are taken.
+var ErrDuplicateURL = errors.New("duplicate endpoint url")
+
+// ErrUnknownEndpoint is returned when updating an endpoint that is not
+// registered.
+var ErrUnknownEndpoint = errors.New("unknown endpoint")
+
+// ErrInjectedFault is the error FaultRate injects.
+var ErrInjectedFault = errors.New("injected fault")
+
+// LoadMode selects how LoadFromFileMode treats invalid entries.
+type LoadMode int
+
+const (
+    LoadFailFast   LoadMode = iota // register nothing if any entry is invalid
+    LoadBestEffort                 // register the valid entries, report the others
 )
 
+// EntryError describes an invalid entry of an endpoints file.
+type EntryError struct {
+    Index int    // position in the JSON array
+    Line  int    // line the entry starts on
+    ID    string // endpoint ID, when it could be decoded
+    Err   error
+}
+
+func (e *EntryError) Error() string {
+    if e.ID != "" {
+        return fmt.Sprintf("entry %d (line %d, id %q): %v", e.Index, e.Line, e.ID, e.Err)
+    }
+    return fmt.Sprintf("entry %d (line %d): %v", e.Index, e.Line, e.Err)
+}
+
+func (e *EntryError) Unwrap() error { return e.Err }
+
+// TrailingSlash selects how URL normalization treats a trailing slash on a
+// non-root path.
+type TrailingSlash int
+
+const (
+    TrailingSlashKeep  TrailingSlash = iota // leave paths as given
+    TrailingSlashStrip                      // "/health/" -> "/health"
+    TrailingSlashAdd                        // "/health" -> "/health/"
+)
+
+// URLNormalization configures WithURLNormalization.
+type URLNormalization struct {
+    TrailingSlash TrailingSlash
+}
+
+// RequestSigner signs an outgoing check request, e.g. for SigV4 or HMAC
+// protected APIs. body holds the full request body (nil when there is none).
+// SignRequest is called for every request sent, so time-bound signatures are
+// always fresh.
+type RequestSigner interface {
+    SignRequest(req *http.Request, body []byte) error
+}
+
 type Endpoint struct {
-    ID             string        `json:"id"`
-    Name           string        `json:"name"`
-    URL            string        `json:"url"`
-    Method         string        `json:"method"`
-    Frequency      time.Duration `json:"frequency"`
-    ExpectedStatus int           `json:"expected_status,omitempty"`
+    ID             string            `json:"id"`
+    Name           string            `json:"name"`
+    URL            string            `json:"url"`
+    OriginalURL    string            `json:"original_url,omitempty"` // URL as supplied, when normalization changed it
+    Method         string            `json:"method"`
+    Frequency      time.Duration     `json:"frequency"`
+    ExpectedStatus int               `json:"expected_status,omitempty"`
+    Retention      int               `json:"retention,omitempty"`    // in-memory logs kept; 0 uses WithLogRetention
+    Proxy          string            `json:"proxy,omitempty"`        // http://, https://, socks5:// or socks5h:// proxy; overrides WithProxy
+    Headers        map[string]string `json:"headers,omitempty"`      // request headers; override WithDefaultHeaders
+    Body           string            `json:"body,omitempty"`         // inline request body, e.g. a JSON payload for a POST check
+    BodyFile       string            `json:"body_file,omitempty"`    // file streamed as the request body, re-read on every check
+    ContentType    string            `json:"content_type,omitempty"` // Content-Type header for the request body
+    Signer         RequestSigner     `json:"-"`                      // signs each request after headers are applied
+    Meta           map[string]string `json:"meta,omitempty"`         // user data (team, runbook URL, ...) passed through untouched
+    Tags           []string          `json:"tags,omitempty"`         // group labels, e.g. for MaintenanceWindowForTag
+
+    // HMAC request signing. With HMACSecret set, every request carries the
+    // hex HMAC of its body in HMACHeader (default "X-Signature"), computed
+    // with HMACAlgo: "sha256" (default), "sha1" or "sha512". It is computed
+    // anew for every request, before Signer runs. The secret is redacted
+    // from serialized output, and the "REDACTED" placeholder is rejected.
+    HMACSecret Secret `json:"hmac_secret,omitempty"`
+    HMACHeader string `json:"hmac_header,omitempty"`
+    HMACAlgo   string `json:"hmac_algo,omitempty"`
+
+    // AcceptableStatusCodes lists every status that passes, e.g. 200, 201
+    // and 204. When set it takes precedence over ExpectedStatus, which is
+    // then not defaulted to 200; when empty ExpectedStatus alone applies.
+    AcceptableStatusCodes []int `json:"acceptable_status_codes,omitempty"`
+
+    // Probe type: "http" (default), "grpc" or "tls". A gRPC endpoint calls
+    // the standard health service (grpc.health.v1.Health/Check) for
+    // GRPCService (default: the server as a whole) at URL, http:// for
+    // plaintext or https:// for TLS, and passes when the service reports
+    // SERVING. Headers are sent as request metadata. A TLS endpoint only
+    // completes a TLS handshake with URL, tls://host:port (default port
+    // 443), and applies the certificate options below.
+    Type        string `json:"type,omitempty"`
+    GRPCService string `json:"grpc_service,omitempty"`
+
+    // Forwarding headers, as set by a proxy in front of the target, e.g. to
+    // exercise IP-based routing or allowlists. ForwardedFor is the client IP
+    // sent in X-Forwarded-For, X-Real-IP and Forwarded "for="; ForwardedProto
+    // and ForwardedHost set X-Forwarded-Proto/-Host and Forwarded "proto="
+    // and "host=". Headers wins over them.
+    ForwardedFor   string `json:"forwarded_for,omitempty"`
+    ForwardedProto string `json:"forwarded_proto,omitempty"`
+    ForwardedHost  string `json:"forwarded_host,omitempty"`
+
+    // Redirect target assertions. When either is set, redirects are not
+    // followed and a 3xx response's Location header must match (exactly, or
+    // the regex). Combine with a redirect ExpectedStatus such as 301.
+    ExpectedLocation      string `json:"expected_location,omitempty"`
+    ExpectedLocationRegex string `json:"expected_location_regex,omitempty"`
+
+    // RequireHTTPSRedirect fails the check unless the redirects followed end
+    // on an https:// URL, e.g. for an http:// endpoint that must redirect to
+    // HTTPS. The URLs visited are recorded in Result.RedirectChain.
+    RequireHTTPSRedirect bool `json:"require_https_redirect,omitempty"`
+
+    // WarmConnection sends a discarded HEAD request before the check so the
+    // measured request reuses an open connection and its latency leaves out
+    // the TCP and TLS handshakes. The HEAD carries the same headers and
+    // signature as the check. It is skipped when the transport disables
+    // keep-alive; Result.ConnReused reports whether the connection was
+    // reused.
+    WarmConnection bool `json:"warm_connection,omitempty"`
+
+    // Cookie security. The check fails when a cookie set by the response,
+    // or by a redirect on the way, lacks the Secure or HttpOnly attribute or
+    // a SameSite mode at least as strict as ExpectCookieSameSite ("lax"
+    // accepts Lax or Strict, "strict" only Strict). CookieNames limits the
+    // assertion to the named cookies.
+    ExpectCookieSecure   bool     `json:"expect_cookie_secure,omitempty"`
+    ExpectCookieHttpOnly bool     `json:"expect_cookie_httponly,omitempty"`
+    ExpectCookieSameSite string   `json:"expect_cookie_samesite,omitempty"`
+    CookieNames          []string `json:"cookie_names,omitempty"`
+
+    // AlertGracePeriod holds back the failure action, notifications,
+    // transition subscribers and the OnStateChange hook for this long after
+    // the endpoint is registered, so a just-deployed service has time to
+    // come up. If the endpoint is still DOWN when the period ends, the DOWN
+    // transition goes out then. Endpoint files give it in seconds.
+    AlertGracePeriod time.Duration `json:"alert_grace_period,omitempty"`
+
+    // ExpectedCharset fails a text response (text/*, JSON, XML, JavaScript)
+    // whose Content-Type declares no or another charset, or whose body has
+    // bytes invalid in it, e.g. "utf-8". JSON without a charset parameter
+    // counts as UTF-8. Names are compared as WHATWG encoding labels, so
+    // "latin1" matches "windows-1252". The first 1 MiB of the body is
+    // decoded; other content types are not checked.
+    ExpectedCharset string `json:"expected_charset,omitempty"`
+
+    // Retries retries a check failing with a network error or an unexpected
+    // status up to this many more times, RetryBackoff apart, before the
+    // result is recorded; it overrides WithRetries. Endpoint files give
+    // RetryBackoff in milliseconds.
+    Retries      int           `json:"retries,omitempty"`
+    RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+    // CaptureHeadersOnFailure records the response headers in
+    // Result.Headers when the check fails, only those in CaptureHeaderNames
+    // when set (e.g. "Server", "Via", "X-Cache"), capped at 8 KiB.
+    CaptureHeadersOnFailure bool     `json:"capture_headers_on_failure,omitempty"`
+    CaptureHeaderNames      []string `json:"capture_header_names,omitempty"`
+
+    // CORS preflight. With CORSOrigin set the check sends the OPTIONS
+    // preflight a browser would send before a Method request from that
+    // origin with CORSRequestHeaders, and fails unless the response allows
+    // them (and credentials, with CORSAllowCredentials). The preflight has
+    // no body or Content-Type and is not signed. Without an ExpectedStatus
+    // any 2xx status passes.
+    CORSOrigin           string   `json:"cors_origin,omitempty"`
+    CORSRequestHeaders   []string `json:"cors_request_headers,omitempty"`
+    CORSAllowCredentials bool     `json:"cors_allow_credentials,omitempty"`
+
+    // Compression. AcceptEncoding sets the Accept-Encoding header (default:
+    // the transport's own "gzip", decoded transparently); gzip and deflate
+    // bodies are decoded for body assertions either way. ExpectCompressed
+    // fails the check when the response has no Content-Encoding.
+    AcceptEncoding   string `json:"accept_encoding,omitempty"`
+    ExpectCompressed bool   `json:"expect_compressed,omitempty"`
+
+    // Cache header assertions for CDN-fronted endpoints. ExpectCacheHit
+    // requires a cache status header (X-Cache, CF-Cache-Status, ...) reporting
+    // a HIT; CacheDirectives lists Cache-Control directives that must be
+    // present, e.g. "public"; MinMaxAge is the lowest acceptable s-maxage, or
+    // else max-age, in seconds; MaxCacheAge is the highest acceptable Age
+    // header, in seconds.
+    ExpectCacheHit  bool     `json:"expect_cache_hit,omitempty"`
+    CacheDirectives []string `json:"cache_directives,omitempty"`
+    MinMaxAge       int      `json:"min_max_age,omitempty"`
+    MaxCacheAge     int      `json:"max_cache_age,omitempty"`
+
+    // JSON Schema the response body must conform to, from a file or inline.
+    // The schema is compiled when the endpoint is registered.
+    JSONSchemaFile string          `json:"json_schema_file,omitempty"`
+    JSONSchema     json.RawMessage `json:"json_schema,omitempty"`
+
+    // JSONAssertions are checked against the JSON response body, after
+    // JSONSchema; the first that fails is reported in Result.Error.
+    JSONAssertions []JSONAssertion `json:"json_assertions,omitempty"`
+
+    // TLS options. InsecureSkipVerify accepts any server certificate.
+    // VerifyCertInfo verifies the certificate chain and hostname separately
+    // and reports problems in Result.CertErrors without failing the check,
+    // which is useful together with InsecureSkipVerify.
+    InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+    VerifyCertInfo     bool `json:"verify_cert_info,omitempty"`
+
+    // Certificate strength. The check fails (FailureTLS) when the leaf
+    // certificate has an RSA key shorter than MinKeyBits or a signature
+    // algorithm in DeniedSigAlgs, e.g. "SHA1" or "MD5-RSA". With
+    // DegradeOnWeakCert the result is flagged Degraded instead and the
+    // problem added to CertErrors. Plain HTTP endpoints are not affected.
+    MinKeyBits        int      `json:"min_key_bits,omitempty"`
+    DeniedSigAlgs     []string `json:"denied_sig_algs,omitempty"`
+    DegradeOnWeakCert bool     `json:"degrade_on_weak_cert,omitempty"`
+
+    // ExpectUnreachable inverts the check for security monitoring: it passes
+    // when the connection is refused or reset or the name does not resolve,
+    // or when the response has ExpectedStatus, which defaults to 403 for
+    // such endpoints. Timeouts and cancelled checks still fail. With
+    // MultiPath it must hold over every network path.
+    ExpectUnreachable bool `json:"expect_unreachable,omitempty"`
+
+    // Multi-URL endpoints. When URLs is set every URL is checked in parallel
+    // (URL is ignored) and the check passes when at least Quorum of them pass
+    // (default: all). A passing URL whose latency exceeds OutlierFactor times
+    // the median of the other passing URLs (default 3) is reported in
+    // Result.Outliers.
+    URLs          []string `json:"urls,omitempty"`
+    Quorum        int      `json:"quorum,omitempty"`
+    OutlierFactor float64  `json:"outlier_factor,omitempty"`
+
+    // MultiPath checks the URL over every path of WithNetworkPaths in
+    // parallel. The check passes when any path passes and is flagged
+    // Partial when some fail, surfacing split reachability (e.g. IPv6 down,
+    // or one region's DNS poisoned) that a single check hides.
+    MultiPath bool `json:"multi_path,omitempty"`
+
+    // Failures needed to mark the endpoint DOWN. The status is DOWN once the
+    // current run of failed checks contains FailureThreshold failures (default
+    // 1) of one kind; FailureThresholds overrides the count per kind, e.g.
+    // {"timeout": 3, "connection_refused": 1}. The status is computed from
+    // the retained logs, so no threshold may exceed the log retention.
+    FailureThreshold  int                 `json:"failure_threshold,omitempty"`
+    FailureThresholds map[FailureKind]int `json:"failure_thresholds,omitempty"`
+
+    // Latency baseline. A successful check slower than BaselineFactor times
+    // the baseline is flagged Degraded and the endpoint reported DEGRADED.
+    // The baseline is BaselineLatency when set (milliseconds in endpoint
+    // files), else the median latency of the first BaselineSamples (default
+    // 10) successful checks. BaselineFactor 0 disables the comparison.
+    BaselineFactor  float64       `json:"baseline_factor,omitempty"`
+    BaselineLatency time.Duration `json:"baseline_latency,omitempty"`
+    BaselineSamples int           `json:"baseline_samples,omitempty"`
+
+    // Response size stability. Once SizeSamples (default 10) successful
+    // checks have been measured, a body whose size is more than
+    // SizeDeviation standard deviations from the moving mean of about the
+    // last SizeSamples sizes (e.g. a partial page or error stub) is flagged
+    // SizeAnomaly and Degraded. The deviation is taken as at least 1% of the
+    // mean, so small changes to a static page pass. SizeDeviation 0 disables
+    // it; bodies are measured up to 10MB.
+    SizeDeviation float64 `json:"size_deviation,omitempty"`
+    SizeSamples   int     `json:"size_samples,omitempty"`
+
+    // Vars turns the endpoint into a template: URL and Name are expanded
+    // with each variable set into a separate endpoint (see ExpandTemplates).
+    Vars []map[string]string `json:"vars,omitempty"`
+
+    // Priority orders queued checks when workers are scarce: higher values
+    // are dispatched first (default 0). See WithPriorityAging.
+    Priority int `json:"priority,omitempty"`
+
+    // DeadlineFromSchedule measures the check timeout (WithTimeout) from
+    // when the check was due rather than from when a worker starts it, so
+    // time spent queued counts against it. A check already past its
+    // deadline when picked up fails with "deadline exceeded before
+    // execution" without a request.
+    DeadlineFromSchedule bool `json:"deadline_from_schedule,omitempty"`
+
+    // ParseServerTiming records the response's Server-Timing metrics in
+    // Result.ServerTiming and splits the latency into server and network
+    // time. Responses without the header are unaffected.
+    ParseServerTiming bool `json:"parse_server_timing,omitempty"`
+
+    // MeasureDownload reads the whole response body and splits the latency:
+    // Result.TTFB is the time to the first response byte and Latency the
+    // time until the body was fully downloaded.
+    MeasureDownload bool `json:"measure_download,omitempty"`
+
+    // Content-drift monitoring. The SHA-256 of each passing response body is
+    // compared with the previous one: DetectChange fails the check when the
+    // body changed, ExpectChange when it did not.
+    DetectChange bool `json:"detect_change,omitempty"`
+    ExpectChange bool `json:"expect_change,omitempty"`
+
+    // ExpectedSHA256 fails a check whose response body does not have this
+    // hex SHA-256, e.g. a static asset of a known-good build. Bodies over
+    // 10 MiB are not hashed and fail. The actual hash is in Result.BodyHash.
+    ExpectedSHA256 string `json:"expected_sha256,omitempty"`
+
+    // ExpectedBodyContains fails a check whose response body (its first
+    // MiB) lacks this substring, e.g. to catch error pages served with 200.
+    ExpectedBodyContains string `json:"expected_body_contains,omitempty"`
+
+    // DynamicBodyRegex captures a token (the first group, else the match)
+    // from the body of endpoints serving dynamic content, such as a
+    // timestamp or nonce. The check fails when the token is missing or
+    // equals the previous check's, e.g. behind a stuck cache.
+    DynamicBodyRegex string `json:"dynamic_body_regex,omitempty"`
 }
 
 // Result represents the outcome of a check
 type Result struct {
-    Endpoint   Endpoint      `json:"endpoint"`
-    Timestamp  time.Time     `json:"timestamp"`
+    Endpoint        Endpoint      `json:"endpoint"`
+    Timestamp       time.Time     `json:"timestamp"`
+    StatusCode      int           `json:"status_code"`
+    Latency         time.Duration `json:"latency"`
+    Success         bool          `json:"success"`
+    Error           string        `json:"error,omitempty"`
+    ProxyUsed       bool          `json:"proxy_used,omitempty"`
+    CertErrors      []string      `json:"cert_errors,omitempty"`      // certificate problems found by VerifyCertInfo
+    Inverted        bool          `json:"inverted,omitempty"`         // result of an ExpectUnreachable check; Success means "correctly blocked"
+    Origin          Origin        `json:"origin,omitempty"`           // code path that produced the result
+    SourceAddr      string        `json:"source_addr,omitempty"`      // local address the check was sent from, with WithSourceAddresses
+    ConnReused      bool          `json:"conn_reused,omitempty"`      // the measured request of a WarmConnection check reused a pooled connection
+    Maintenance     bool          `json:"maintenance,omitempty"`      // the check ran during a maintenance window
+    FailureKind     FailureKind   `json:"failure_kind,omitempty"`     // why the check failed; empty on success
+    Degraded        bool          `json:"degraded,omitempty"`         // successful, but slow, with a weak certificate or an anomalous size
+    Partial         bool          `json:"partial,omitempty"`          // successful on some network paths only, with MultiPath
+    UserAgent       string        `json:"user_agent,omitempty"`       // User-Agent sent, with WithUserAgentRotation
+    BodyHash        string        `json:"body_hash,omitempty"`        // hex SHA-256 of the body, with change detection or ExpectedSHA256
+    ContentEncoding string        `json:"content_encoding,omitempty"` // Content-Encoding of the response, e.g. "gzip"
+    Changed         bool          `json:"changed,omitempty"`          // body differs from the previous response's
+    Attempts        int           `json:"attempts,omitempty"`         // checks made, more than 1 with retries
+    DeclaredCharset string        `json:"declared_charset,omitempty"` // charset of the Content-Type, with ExpectedCharset
+    DetectedCharset string        `json:"detected_charset,omitempty"` // charset sniffed from the body, with ExpectedCharset
+
+    BodySize    int64 `json:"body_size,omitempty"`    // body bytes read, with SizeDeviation
+    SizeAnomaly bool  `json:"size_anomaly,omitempty"` // body size far from the endpoint's norm
+
+    DynamicToken         string `json:"dynamic_token,omitempty"`          // token captured by DynamicBodyRegex
+    PreviousDynamicToken string `json:"previous_dynamic_token,omitempty"` // the previous check's token, when it did not change
+
+    CacheHeaders map[string]string   `json:"cache_headers,omitempty"` // caching headers received, when a cache assertion failed
+    Headers      map[string][]string `json:"headers,omitempty"`       // response headers of a failed check, with CaptureHeadersOnFailure
+
+    RedirectChain  []string          `json:"redirect_chain,omitempty"`  // URLs requested, in order, with RequireHTTPSRedirect
+    CORSHeaders    map[string]string `json:"cors_headers,omitempty"`    // Access-Control-* headers of a CORS preflight response
+    InsecureCookie string            `json:"insecure_cookie,omitempty"` // name of the cookie failing a cookie assertion
+
+    GRPCStatus string `json:"grpc_status,omitempty"` // serving status from a gRPC health check, e.g. "NOT_SERVING"
+
+    // TLS endpoints only: negotiated protocol version, e.g. "TLS 1.3", and
+    // leaf certificate expiry.
+    TLSVersion string    `json:"tls_version,omitempty"`
+    CertExpiry time.Time `json:"cert_expiry,omitempty"`
+
+    // Leaf certificate details, with MinKeyBits or DeniedSigAlgs.
+    CertSignatureAlgorithm string `json:"cert_signature_algorithm,omitempty"` // e.g. "SHA256-RSA"
+    CertKeyType            string `json:"cert_key_type,omitempty"`            // "RSA", "ECDSA" or "Ed25519"
+    CertKeyBits            int    `json:"cert_key_bits,omitempty"`
+
+    TTFB time.Duration `json:"ttfb,omitempty"` // time to first byte, with MeasureDownload; Latency is then the full download
+
+    // Endpoint.ParseServerTiming only. ServerTime is the "total" metric, or
+    // the longest one; NetworkTime is the rest of Latency.
+    ServerTiming []ServerTimingMetric `json:"server_timing,omitempty"`
+    ServerTime   time.Duration        `json:"server_time,omitempty"`
+    NetworkTime  time.Duration        `json:"network_time,omitempty"`
+
+    // Multi-URL and multi-path endpoints only.
+    SubResults []SubResult `json:"sub_results,omitempty"` // one per URL or network path, in configuration order
+    FastestURL string      `json:"fastest_url,omitempty"` // passing URL with the lowest latency
+    Outliers   []string    `json:"outliers,omitempty"`    // passing URLs with outlier latency
+}
+
+// Origin identifies what triggered a check.
+type Origin string
+
+const (
+    OriginScheduled    Origin = "scheduled"    // regular ticker-driven check
+    OriginManual       Origin = "manual"       // on-demand check, e.g. RecheckFailing
+    OriginConfirmation Origin = "confirmation" // re-check confirming a state change
+    OriginBoosted      Origin = "boosted"      // extra check from temporarily raised frequency
+    OriginReplay       Origin = "replay"       // pre-recorded result fed in by Replay
+    OriginInjected     Origin = "injected"     // synthetic failure from WithFaultInjection
+)
+
+// FailureKind classifies why a check failed.
+type FailureKind string
+
+const (
+    FailureTimeout     FailureKind = "timeout"            // request timed out
+    FailureConnRefused FailureKind = "connection_refused" // nothing listening
+    FailureDNS         FailureKind = "dns"                // host name did not resolve
+    FailureTLS         FailureKind = "tls"                // handshake or certificate failure
+    FailureConnection  FailureKind = "connection"         // other network error, e.g. connection reset
+    FailureStatus      FailureKind = "status"             // unexpected status code
+    FailureAssertion   FailureKind = "assertion"          // response assertion (redirect target, schema, ...) failed
+    FailureOther       FailureKind = "other"              // request could not be built or sent
+    FailureCancelled   FailureKind = "cancelled"          // check was cancelled, e.g. by Stop; ignored by uptime and status
+)
+
+// ServerTimingMetric is one metric of a Server-Timing header.
+type ServerTimingMetric struct {
+    Name        string        `json:"name"`
+    Duration    time.Duration `json:"duration,omitempty"`
+    Description string        `json:"description,omitempty"`
+}
+
+// LatencyPoint is one check in a latency series, see LatencySeries. In JSON
+// the latency is in milliseconds.
+type LatencyPoint struct {
+    Timestamp time.Time
+    Latency   time.Duration
+    Success   bool
+}
+
+type latencyPointJSON struct {
+    Timestamp time.Time `json:"timestamp"`
+    LatencyMs float64   `json:"latency_ms"`
+    Success   bool      `json:"success"`
+}
+
+func (p LatencyPoint) MarshalJSON() ([]byte, error) {
+    return json.Marshal(latencyPointJSON{p.Timestamp, float64(p.Latency) / float64(time.Millisecond), p.Success})
+}
+
+func (p *LatencyPoint) UnmarshalJSON(b []byte) error {
+    var v latencyPointJSON
+    if err := json.Unmarshal(b, &v); err != nil {
+        return err
+    }
+    *p = LatencyPoint{v.Timestamp, time.Duration(v.LatencyMs * float64(time.Millisecond)), v.Success}
+    return nil
+}
+
+// NetworkPath is one route a multi-path check takes to the endpoint (see
+// WithNetworkPaths). Empty fields use the checker's defaults.
+type NetworkPath struct {
+    Name     string `json:"name"`               // reported in SubResult.Path
+    Network  string `json:"network,omitempty"`  // "tcp4" or "tcp6" to force an IP family
+    Resolver string `json:"resolver,omitempty"` // DNS server, port 53 when omitted
+    Source   string `json:"source,omitempty"`   // local IP address to bind
+}
+
+// SubResult is the outcome for one URL of a multi-URL endpoint, or one
+// network path of a multi-path endpoint.
+type SubResult struct {
+    Path       string        `json:"path,omitempty"` // network path name, for multi-path checks
+    URL        string        `json:"url"`
     StatusCode int           `json:"status_code"`
     Latency    time.Duration `json:"latency"`
     Success    bool          `json:"success"`
     Error      string        `json:"error,omitempty"`
+    Outlier    bool          `json:"outlier,omitempty"`
+}
+
+// Status is the aggregated state of an endpoint.
+type Status string
+
+const (
+    StatusUnknown     Status = "UNKNOWN" // no checks recorded yet
+    StatusUp          Status = "UP"
+    StatusDown        Status = "DOWN"
+    StatusDegraded    Status = "DEGRADED"    // up, but the last check was flagged Degraded
+    StatusPartial     Status = "PARTIAL"     // up, but the last check failed on some network paths
+    StatusMaintenance Status = "MAINTENANCE" // inside a maintenance window
+)
+
+// EndpointStatus is a point-in-time summary of an endpoint, as returned by StatusSnapshot.
+type EndpointStatus struct {
+    Endpoint           Endpoint      `json:"endpoint"`
+    Status             Status        `json:"status"`
+    LastCheck          time.Time     `json:"last_check,omitempty"`
+    LastResult         *Result       `json:"last_result,omitempty"`
+    Uptime             float64       `json:"uptime"`                    // percentage of retained checks that succeeded
+    Checks             int           `json:"checks"`                    // number of retained checks
+    Baseline           time.Duration `json:"baseline,omitempty"`        // latency baseline, once known (see Endpoint.BaselineFactor)
+    EffectiveFrequency time.Duration `json:"effective_frequency"`       // interval currently in force (see Checker.EffectiveFrequency)
+    Quarantined        bool          `json:"quarantined,omitempty"`     // quarantined for flapping (see WithAutoQuarantine)
+    InGracePeriod      bool          `json:"in_grace_period,omitempty"` // alerts held back after registration (see Endpoint.AlertGracePeriod)
+    LastIncident       *Incident     `json:"last_incident,omitempty"`   // most recent incident, with its notes (see Checker.Incidents)
+}
+
+// SelfMetrics describes the checker's own resource usage, as returned by
+// Checker.SelfMetrics.
+type SelfMetrics struct {
+    Goroutines    int `json:"goroutines"`     // all goroutines in the process
+    Workers       int `json:"workers"`        // configured worker pool size
+    Schedulers    int `json:"schedulers"`     // running per-endpoint scheduler goroutines
+    QueueDepth    int `json:"queue_depth"`    // jobs waiting for a worker
+    QueueCapacity int `json:"queue_capacity"` // job queue capacity
+    InFlight      int `json:"in_flight"`      // checks currently executing
+    Endpoints     int `json:"endpoints"`      // registered endpoints
+    LogEntries    int `json:"log_entries"`    // results retained in memory across all endpoints
+}
+
+// Stats holds the checker's cumulative counters, as returned by
+// Checker.Stats.
+type Stats struct {
+    Checks         int64 `json:"checks"`          // results recorded
+    Shed           int64 `json:"shed"`            // due checks skipped by load shedding
+    DroppedJobs    int64 `json:"dropped_jobs"`    // due checks dropped because the job queue was full
+    DroppedResults int64 `json:"dropped_results"` // results not delivered because the Results channel was full
+    Shedding       bool  `json:"shedding"`        // load shedding is currently active
+    EvictedLogs    int64 `json:"evicted_logs"`    // results evicted by WithMaxTotalLogEntries
+    DedupedResults int64 `json:"deduped_results"` // results dropped as duplicates by WithResultDedup
+    FaultInjection bool  `json:"fault_injection"` // WithFaultInjection is set: some results may be synthetic failures
+
+    // WithCheckBudget only.
+    BudgetLimit    int   `json:"budget_limit,omitempty"`    // checks allowed per window
+    BudgetUsed     int   `json:"budget_used,omitempty"`     // checks charged in the current window, including retries and on-demand checks
+    BudgetDeferred int64 `json:"budget_deferred,omitempty"` // checks deferred to a later window
+    BudgetPending  int   `json:"budget_pending,omitempty"`  // deferred checks waiting for the next window
 }
 
 type Job struct {
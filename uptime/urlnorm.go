@@ -0,0 +1,80 @@
+package uptime
+
+import (
+    "fmt"
+    "net/url"
+    "strings"
+)
+
+// ===== URL Normalization =====
+
+// normalizeEndpointURL rewrites ep.URL to its normalized form, recording the
+// supplied URL in OriginalURL when it changed. An endpoint without URL (one
+// with URLs) is left alone.
+func normalizeEndpointURL(ep *Endpoint, n URLNormalization) error {
+    if ep.URL == "" {
+        ep.OriginalURL = ""
+        return nil
+    }
+    orig := ep.URL
+    if ep.OriginalURL != "" {
+        // Already normalized once (e.g. re-registered from Sites) unless the
+        // caller has since changed URL.
+        if prev, err := normalizeURL(ep.OriginalURL, n); err == nil && prev == ep.URL {
+            orig = ep.OriginalURL
+        }
+    }
+    norm, err := normalizeURL(orig, n)
+    if err != nil {
+        return err
+    }
+    ep.URL = norm
+    ep.OriginalURL = ""
+    if norm != orig {
+        ep.OriginalURL = orig
+    }
+    return nil
+}
+
+func normalizeURL(raw string, n URLNormalization) (string, error) {
+    u, err := url.Parse(raw)
+    if err != nil {
+        return "", fmt.Errorf("invalid url %q: %w", raw, err)
+    }
+    u.Scheme = strings.ToLower(u.Scheme)
+    host := strings.ToLower(u.Hostname())
+    port := u.Port()
+    if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+        port = ""
+    }
+    if strings.Contains(host, ":") {
+        host = "[" + host + "]" // IPv6 literal
+    }
+    if port != "" {
+        host += ":" + port
+    }
+    u.Host = host
+
+    if u.Path == "" {
+        u.Path = "/"
+        u.RawPath = ""
+    }
+    if u.Path != "/" {
+        switch n.TrailingSlash {
+        case TrailingSlashStrip:
+            u.Path = strings.TrimRight(u.Path, "/")
+            u.RawPath = strings.TrimRight(u.RawPath, "/")
+            if u.Path == "" {
+                u.Path, u.RawPath = "/", ""
+            }
+        case TrailingSlashAdd:
+            if !strings.HasSuffix(u.Path, "/") {
+                u.Path += "/"
+                if u.RawPath != "" {
+                    u.RawPath += "/"
+                }
+            }
+        }
+    }
+    return u.String(), nil
+}
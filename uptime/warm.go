@@ -0,0 +1,59 @@
+package uptime
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptrace"
+    "sync/atomic"
+
+    "go.uber.org/zap"
+)
+
+// ===== Connection Warm-up =====
+
+// warmUp sends a discarded HEAD request for req's URL through client so the
+// measured request finds an open connection in the pool. The HEAD is a copy
+// of req, so it carries the endpoint and decorator headers, without the
+// body, and is signed by signers again. Errors are left for the measured
+// request to report.
+func (c *Checker) warmUp(req *http.Request, client *http.Client, ep Endpoint, signers []RequestSigner) {
+    warm := req.Clone(req.Context())
+    warm.Method = http.MethodHead
+    warm.Body, warm.GetBody, warm.ContentLength = nil, nil, 0
+    warm.Header.Del("Content-Type")
+    for _, signer := range signers {
+        if err := signRequest(signer, warm); err != nil {
+            c.ilog(LogDebug, "warm_up_failed", endpointFields(ep, zap.Error(err))...)
+            return
+        }
+    }
+    resp, err := client.Do(warm)
+    if err != nil {
+        c.ilog(LogDebug, "warm_up_failed", endpointFields(ep, zap.Error(err))...)
+        return
+    }
+    io.Copy(io.Discard, resp.Body)
+    resp.Body.Close()
+}
+
+// keepAlive reports whether client keeps connections open between requests,
+// so a warm-up request can prime its pool. A transport other than
+// *http.Transport, such as one wrapping it, is assumed to.
+func keepAlive(client *http.Client) bool {
+    switch tr := client.Transport.(type) {
+    case nil:
+        return !http.DefaultTransport.(*http.Transport).DisableKeepAlives
+    case *http.Transport:
+        return !tr.DisableKeepAlives
+    }
+    return true
+}
+
+// traceConnReuse returns req with a trace storing in reused whether it was
+// sent on a pooled connection.
+func traceConnReuse(req *http.Request, reused *atomic.Bool) *http.Request {
+    trace := &httptrace.ClientTrace{
+        GotConn: func(info httptrace.GotConnInfo) { reused.Store(info.Reused) },
+    }
+    return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
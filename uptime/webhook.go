@@ -0,0 +1,51 @@
+package uptime
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+)
+
+// ===== Webhook Notifier =====
+
+// WebhookNotifier is a Notifier POSTing each TransitionEvent as JSON to
+// URL, with Headers added to the request. A response outside 2xx is an
+// error. Client defaults to http.DefaultClient; the request is bounded by
+// the notify timeout.
+type WebhookNotifier struct {
+    URL     string
+    Headers map[string]string
+    Client  *http.Client
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, event TransitionEvent) error {
+    body, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    for k, v := range w.Headers {
+        req.Header.Set(k, v)
+    }
+    client := w.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("unexpected status %d", resp.StatusCode)
+    }
+    return nil
+}
@@ -1,110 +1,672 @@
 package uptime
 
 import (
+    "bytes"
+    "context"
+    "errors"
     "fmt"
+    "io"
     "net/http"
+    "os"
+    "strings"
+    "sync/atomic"
     "time"
 
     "go.uber.org/zap"
 )
 
 // ===== Workers, Scheduler, and Internals =====
+
+// Reasons saveLog does not store a result.
+var (
+    errDuplicateResult = errors.New("duplicate result")
+    errRemovedEndpoint = errors.New("endpoint removed")
+)
+
 func (c *Checker) worker(id int) {
     defer c.wg.Done()
     for {
-        select {
-        case <-c.stopCh:
+        job, ok := c.jobs.pop()
+        if !ok {
             return
-        case job, ok := <-c.jobs:
-            if !ok {
-                return
+        }
+        if c.internalEnabled(LogDebug) {
+            c.ilog(LogDebug, "job_picked", endpointFields(job.Endpoint, zap.Int("worker", id), zap.Time("run_at", job.RunAt))...)
+        }
+        result := c.runJob(job)
+        if result.Origin == "" {
+            result.Origin = OriginScheduled
+        }
+        c.handleResult(result)
+        c.busy.Delete(job.Endpoint.ID)
+        if c.internalEnabled(LogDebug) {
+            c.ilog(LogDebug, "job_finished", endpointFields(result.Endpoint, zap.Int("worker", id),
+                zap.Bool("success", result.Success), zap.Duration("latency", result.Latency))...)
+        }
+    }
+}
+
+// runJob checks the job's endpoint. With Endpoint.DeadlineFromSchedule the
+// timeout counts from job.RunAt, so time spent queued is deducted from it;
+// a job whose deadline passed while queued is not started.
+func (c *Checker) runJob(job Job) Result {
+    ctx := c.checkCtx
+    if timeout := c.httpClient.Timeout; job.Endpoint.DeadlineFromSchedule && timeout > 0 {
+        deadline := job.RunAt.Add(timeout)
+        if !time.Now().Before(deadline) {
+            c.ilog(LogInfo, "job_deadline_exceeded", endpointFields(job.Endpoint, zap.Duration("queued", time.Since(job.RunAt)))...)
+            return Result{
+                Endpoint:    job.Endpoint,
+                Timestamp:   time.Now(),
+                Latency:     time.Since(job.RunAt),
+                Success:     false,
+                Error:       "deadline exceeded before execution",
+                FailureKind: FailureTimeout,
             }
-            c.ilog("Worker %d picked job for site %s (scheduled at %s)", id, job.Endpoint.Name, job.RunAt.Format(time.RFC3339))
-            result := c.checkEndpoint(job.Endpoint)
-            c.results <- result
-            c.saveLog(result)
-            c.log(result)
-            c.ilog("Worker %d finished job for site %s (success=%v, latency=%v)", id, result.Endpoint.Name, result.Success, result.Latency)
         }
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithDeadline(ctx, deadline)
+        defer cancel()
+    }
+    if res, ok := c.injectFault(job.Endpoint); ok {
+        return res
     }
+    c.inFlight.Add(1)
+    defer c.inFlight.Add(-1)
+    return c.checkEndpoint(ctx, job.Endpoint)
 }
 
 func (c *Checker) scheduler() {
     defer c.wg.Done()
-    c.mu.Lock()
-    for _, ep := range c.endpoints {
+    c.mu.RLock()
+    eps := append([]Endpoint(nil), c.endpoints...)
+    c.mu.RUnlock()
+    for _, ep := range eps {
         c.scheduleEndpoint(ep)
     }
-    c.mu.Unlock()
     <-c.stopCh
 }
 
+// scheduleEndpoint starts the ticker goroutine for ep unless one is already
+// running for its ID.
 func (c *Checker) scheduleEndpoint(ep Endpoint) {
-    c.ilog("Scheduling site %s (%s) every %v", ep.Name, ep.URL, ep.Frequency)
-    ticker := time.NewTicker(ep.Frequency)
-    go func(e Endpoint, t *time.Ticker) {
+    c.mu.Lock()
+    if _, ok := c.siteStop[ep.ID]; ok {
+        c.mu.Unlock()
+        return
+    }
+    stop := make(chan struct{})
+    c.siteStop[ep.ID] = stop
+    now := time.Now()
+    c.scheduledAt[ep.ID] = now
+    delay := time.Duration(-1) // no run before the first tick
+    base := now
+    if at, ok := c.resumeAt[ep.ID]; ok {
+        delete(c.resumeAt, ep.ID)
+        delay = min(max(at.Sub(now), 0), ep.Frequency)
+        base = now.Add(delay)
+    }
+    c.tickBase[ep.ID] = base
+    c.mu.Unlock()
+
+    c.ilog(LogInfo, "site_scheduled", endpointFields(ep, zap.Duration("frequency", ep.Frequency))...)
+    c.wg.Add(1)
+    go func(e Endpoint) {
+        defer c.wg.Done()
+        if delay >= 0 {
+            // Resumed: run at the restored time, then tick from there.
+            timer := time.NewTimer(delay)
+            select {
+            case <-c.stopCh:
+                timer.Stop()
+                return
+            case <-stop:
+                timer.Stop()
+                return
+            case <-timer.C:
+                c.dispatch(e)
+            }
+        }
+        t := time.NewTicker(e.Frequency)
+        defer t.Stop()
         for {
             select {
             case <-c.stopCh:
-                t.Stop()
                 return
+            case <-stop:
+                return
+            case <-t.C:
+                c.dispatch(e)
+            }
+        }
+    }(ep)
+}
+
+// dispatch queues a scheduled check of e, unless this instance is passive,
+// the previous check of e is still queued or running, or the check is shed.
+func (c *Checker) dispatch(e Endpoint) {
+    if !c.leader.Load() {
+        if c.internalEnabled(LogDebug) {
+            c.ilog(LogDebug, "job_skipped_passive", endpointFields(e)...)
+        }
+        return
+    }
+    if c.backedOff(e) || !c.claim(e) {
+        return
+    }
+    if c.shed(e) || !c.withinBudget(e) {
+        c.busy.Delete(e.ID)
+        return
+    }
+    if c.internalEnabled(LogDebug) {
+        c.ilog(LogDebug, "job_scheduled", endpointFields(e)...)
+    }
+    c.enqueue(e)
+}
+
+// claim marks a scheduled check of e as outstanding until a worker finishes
+// it, so checks of a slow endpoint never overlap. It reports false, with a
+// log, when the previous check is still outstanding.
+func (c *Checker) claim(e Endpoint) bool {
+    if _, running := c.busy.LoadOrStore(e.ID, struct{}{}); running {
+        if c.internalEnabled(LogDebug) {
+            c.ilog(LogDebug, "job_skipped_running", endpointFields(e)...)
+        }
+        return false
+    }
+    return true
+}
+
+// enqueue pushes a claimed check of e to the job queue.
+func (c *Checker) enqueue(e Endpoint) {
+    if !c.jobs.push(Job{Endpoint: e, RunAt: time.Now()}) {
+        c.busy.Delete(e.ID)
+        if c.isRunning() {
+            c.dropped(e, DropJobQueueFull)
+        }
+    }
+}
+
+// unscheduleEndpoint stops the ticker goroutine for id, if any.
+func (c *Checker) unscheduleEndpoint(id string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if stop, ok := c.siteStop[id]; ok {
+        close(stop)
+        delete(c.siteStop, id)
+        delete(c.scheduledAt, id)
+        delete(c.tickBase, id)
+    }
+}
+
+// handleResult records a finished check: it is stored in the logs (and
+// Storage) first, so consumers of the Results channel always see it
+// reflected in GetLogs and StatusSnapshot, then passed to the OnResult hooks,
+// published on the channel and logged. It returns the result as recorded.
+func (c *Checker) handleResult(result Result) Result {
+    result, err := c.saveLog(result)
+    switch err {
+    case errDuplicateResult:
+        c.dedupedResults.Add(1)
+        c.ilog(LogDebug, "result_deduplicated", endpointFields(result.Endpoint)...)
+        return result
+    case errRemovedEndpoint:
+        c.ilog(LogDebug, "result_discarded_removed", endpointFields(result.Endpoint)...)
+        return result
+    }
+    c.checksDone.Add(1)
+    c.observeTimeout(result)
+    if c.storage != nil {
+        if err := c.storage.Append(result); err != nil {
+            c.ilog(LogError, "storage_append_failed", endpointFields(result.Endpoint, zap.Error(err))...)
+        }
+    }
+    c.handleTransition(result)
+    c.observeStateChange(result)
+    c.runResultHooks(result)
+    c.resultsMu.RLock()
+    if !c.resultsClosed {
+        select {
+        case c.results <- result:
+        default:
+            c.dropped(result.Endpoint, DropResultsFull)
+        }
+    }
+    c.resultsMu.RUnlock()
+    c.log(result)
+    return result
+}
+
+func (c *Checker) checkEndpoint(ctx context.Context, ep Endpoint) Result {
+    retries, backoff := c.retriesFor(ep)
+    res := c.checkAttempt(ctx, ep)
+    res.Attempts = 1
+    for attempt := 2; attempt <= retries+1 && retryable(res) && ctx.Err() == nil; attempt++ {
+        if backoff > 0 {
+            t := time.NewTimer(backoff)
+            select {
+            case <-ctx.Done():
+                t.Stop()
+                return res
             case <-t.C:
-                c.ilog("Job scheduled for site %s at %s", e.Name, time.Now().Format(time.RFC3339))
-                select {
-                case c.jobs <- Job{Endpoint: e, RunAt: time.Now()}:
-                case <-c.stopCh:
-                    t.Stop()
-                    return
-                }
             }
         }
-    }(ep, ticker)
+        c.ilog(LogDebug, "check_retry", endpointFields(ep, zap.Int("attempt", attempt), zap.String("error", res.Error))...)
+        c.chargeBudget()
+        res = c.checkAttempt(ctx, ep)
+        res.Attempts = attempt
+    }
+    return res
+}
+
+// checkAttempt performs a single check of ep, without retries.
+func (c *Checker) checkAttempt(ctx context.Context, ep Endpoint) Result {
+    if ep.Type == TypeGRPC {
+        return c.checkGRPC(ctx, ep)
+    }
+    if ep.Type == TypeTLS {
+        return c.checkTLS(ctx, ep)
+    }
+    if len(ep.URLs) > 0 {
+        return c.checkMulti(ctx, ep)
+    }
+    if ep.MultiPath {
+        return c.checkMultiPath(ctx, ep)
+    }
+    return c.checkURL(ctx, ep)
+}
+
+// checkURL performs a single request to ep.URL.
+func (c *Checker) checkURL(ctx context.Context, ep Endpoint) Result {
+    return c.checkURLVia(ctx, ep, NetworkPath{})
 }
 
-func (c *Checker) checkEndpoint(ep Endpoint) Result {
+// checkURLVia performs a single request to ep.URL over the network path p.
+func (c *Checker) checkURLVia(ctx context.Context, ep Endpoint, p NetworkPath) (res Result) {
     start := time.Now()
     currentTime := time.Now()
 
-    req, err := http.NewRequest(ep.Method, ep.URL, nil)
+    var signers []RequestSigner
+    if !isPreflight(ep) {
+        signers = requestSigners(ep)
+    }
+    body, size, err := openBody(ep, len(signers) > 0)
     if err != nil {
         return Result{
-            Endpoint:  ep,
-            Timestamp: currentTime,
-            Latency:   time.Since(start),
-            Success:   false,
-            Error:     fmt.Sprintf("Error creating request: %v", err),
+            Endpoint:    ep,
+            Timestamp:   currentTime,
+            Latency:     time.Since(start),
+            Success:     false,
+            Error:       fmt.Sprintf("Error opening request body: %v", err),
+            FailureKind: FailureOther,
         }
     }
-    resp, err := c.httpClient.Do(req)
+    req, err := http.NewRequestWithContext(ctx, ep.Method, ep.URL, body)
     if err != nil {
+        if body != nil {
+            body.Close()
+        }
         return Result{
-            Endpoint:  ep,
-            Timestamp: currentTime,
-            Latency:   time.Since(start),
-            Success:   false,
-            Error:     err.Error(),
+            Endpoint:    ep,
+            Timestamp:   currentTime,
+            Latency:     time.Since(start),
+            Success:     false,
+            Error:       fmt.Sprintf("Error creating request: %v", err),
+            FailureKind: FailureOther,
+        }
+    }
+    if body != nil {
+        req.ContentLength = size
+        req.GetBody = func() (io.ReadCloser, error) { return reopenBody(ep) }
+    }
+    if ua := c.applyHeaders(req, ep); ua != "" {
+        defer func() { res.UserAgent = ua }()
+    }
+    if isPreflight(ep) {
+        setPreflight(req, ep)
+    }
+    if c.requestDecorator != nil {
+        if err := c.decorateRequest(req, ep); err != nil {
+            closeRequestBody(req)
+            return Result{
+                Endpoint:    ep,
+                Timestamp:   currentTime,
+                Latency:     time.Since(start),
+                Success:     false,
+                Error:       err.Error(),
+                FailureKind: FailureOther,
+            }
+        }
+    }
+    for _, signer := range signers {
+        if err := signRequest(signer, req); err != nil {
+            closeRequestBody(req)
+            return Result{
+                Endpoint:    ep,
+                Timestamp:   currentTime,
+                Latency:     time.Since(start),
+                Success:     false,
+                Error:       fmt.Sprintf("Error signing request: %v", err),
+                FailureKind: FailureOther,
+            }
+        }
+    }
+    if p.Source == "" {
+        p.Source = c.nextSourceAddr()
+    }
+    if p.Source != "" {
+        defer func() { res.SourceAddr = p.Source }()
+    }
+    client, err := c.clientFor(ep, p)
+    if err != nil {
+        closeRequestBody(req)
+        return Result{
+            Endpoint:    ep,
+            Timestamp:   currentTime,
+            Latency:     time.Since(start),
+            Success:     false,
+            Error:       err.Error(),
+            FailureKind: FailureOther,
+        }
+    }
+    var reused *atomic.Bool
+    if ep.WarmConnection && keepAlive(client) {
+        c.warmUp(req, client, ep, signers)
+        start = time.Now()
+        reused = new(atomic.Bool)
+        req = traceConnReuse(req, reused)
+    }
+    var timer *firstByteTimer
+    if ep.MeasureDownload {
+        timer = &firstByteTimer{start: start}
+        req = timer.trace(req)
+    }
+    proxyUsed := c.effectiveProxy(ep) != ""
+    resp, err := client.Do(req)
+    if err != nil {
+        if ep.ExpectUnreachable && blocked(classifyError(err)) {
+            // The request failing is exactly what was expected.
+            return Result{
+                Endpoint:  ep,
+                Timestamp: currentTime,
+                Latency:   time.Since(start),
+                Success:   true,
+                ProxyUsed: proxyUsed,
+                Inverted:  true,
+            }
+        }
+        return Result{
+            Endpoint:    ep,
+            Timestamp:   currentTime,
+            Latency:     time.Since(start),
+            Success:     false,
+            Error:       err.Error(),
+            ProxyUsed:   proxyUsed,
+            FailureKind: classifyError(err),
+            Inverted:    ep.ExpectUnreachable,
         }
     }
     defer resp.Body.Close()
+    if ep.CaptureHeadersOnFailure {
+        defer func() {
+            if !res.Success {
+                res.Headers = failureHeaders(ep, resp.Header)
+            }
+        }()
+    }
+    encoding := decodeBody(resp)
+    captured := captureBody(ep, resp)
 
-    success := resp.StatusCode == ep.ExpectedStatus
-    return Result{
-        Endpoint:   ep,
-        Timestamp:  currentTime,
-        StatusCode: resp.StatusCode,
-        Latency:    time.Since(start),
-        Success:    success,
+    res = Result{
+        Endpoint:        ep,
+        Timestamp:       currentTime,
+        StatusCode:      resp.StatusCode,
+        Latency:         time.Since(start),
+        Success:         statusOK(ep, resp.StatusCode),
+        ProxyUsed:       proxyUsed,
+        ContentEncoding: encoding,
+    }
+    if ep.RequireHTTPSRedirect {
+        res.RedirectChain = redirectChain(resp)
+    }
+    if reused != nil {
+        res.ConnReused = reused.Load()
+    }
+    if isPreflight(ep) {
+        res.CORSHeaders = corsHeaders(resp)
+    }
+    if ep.ParseServerTiming {
+        defer applyServerTiming(&res, resp)
+    }
+    if timer != nil {
+        defer timer.finish(&res, resp)
+    }
+    if resp.TLS != nil {
+        if msg := certPosture(ep, resp.TLS, req.URL.Hostname(), &res); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureTLS
+            return res
+        }
+    }
+    if ep.ExpectUnreachable {
+        res.Inverted = true
+        if !res.Success {
+            res.Error = fmt.Sprintf("expected endpoint to be unreachable, got status %d", resp.StatusCode)
+            res.FailureKind = FailureStatus
+        }
+        return res
+    }
+    if !res.Success {
+        if isPreflight(ep) {
+            res.Error = fmt.Sprintf("CORS preflight: unexpected status %d", resp.StatusCode)
+        }
+        res.FailureKind = FailureStatus
+        return res
+    }
+    if msg := c.checkLocation(ep, resp); msg != "" {
+        res.Success = false
+        res.Error = msg
+        res.FailureKind = FailureAssertion
+        return res
+    }
+    if msg := checkHTTPSRedirect(ep, resp); msg != "" {
+        res.Success = false
+        res.Error = msg
+        res.FailureKind = FailureAssertion
+        return res
+    }
+    if isPreflight(ep) {
+        if msg := checkCORS(ep, resp); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureAssertion
+            return res
+        }
+    }
+    if checksCookies(ep) {
+        if msg := checkCookies(ep, resp, &res); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureAssertion
+            return res
+        }
+    }
+    if msg := checkCompressed(ep, encoding); msg != "" {
+        res.Success = false
+        res.Error = msg
+        res.FailureKind = FailureAssertion
+        return res
+    }
+    if expectsCache(ep) {
+        if msg := checkCache(ep, resp, &res); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureAssertion
+            return res
+        }
+    }
+    if hasSchema(ep) || len(ep.JSONAssertions) > 0 {
+        if msg := c.checkJSON(ep, resp); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureAssertion
+            return res
+        }
     }
+    if captured != nil {
+        if msg := c.checkCaptured(ep, p, &res, resp, captured); msg != "" {
+            res.Success = false
+            res.Error = msg
+            res.FailureKind = FailureAssertion
+        }
+    }
+    return res
 }
 
-func (c *Checker) saveLog(res Result) {
+// openBody returns the request body for ep, or nil when it has none, as a
+// CORS preflight never does. BodyFile is re-opened for every request, so
+// each attempt streams the current file contents. When buffered is set the
+// body is read into memory so it can be signed.
+func openBody(ep Endpoint, buffered bool) (io.ReadCloser, int64, error) {
+    if isPreflight(ep) {
+        return nil, 0, nil
+    }
+    if ep.Body != "" {
+        return io.NopCloser(strings.NewReader(ep.Body)), int64(len(ep.Body)), nil
+    }
+    if ep.BodyFile == "" {
+        return nil, 0, nil
+    }
+    if buffered {
+        b, err := os.ReadFile(ep.BodyFile)
+        if err != nil {
+            return nil, 0, err
+        }
+        return io.NopCloser(bytes.NewReader(b)), int64(len(b)), nil
+    }
+    f, err := os.Open(ep.BodyFile)
+    if err != nil {
+        return nil, 0, err
+    }
+    info, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return nil, 0, err
+    }
+    return f, info.Size(), nil
+}
+
+// reopenBody returns a fresh copy of ep's body for http.Request.GetBody,
+// which the client uses to resend it on a redirect or a retried connection.
+func reopenBody(ep Endpoint) (io.ReadCloser, error) {
+    if ep.Body != "" {
+        return io.NopCloser(strings.NewReader(ep.Body)), nil
+    }
+    f, err := os.Open(ep.BodyFile)
+    if err != nil {
+        return nil, err
+    }
+    return f, nil
+}
+
+// signRequest hands the buffered body to signer and restores it on req, so
+// a resent body is the one that was signed.
+func signRequest(signer RequestSigner, req *http.Request) error {
+    var body []byte
+    if req.Body != nil {
+        b, err := io.ReadAll(req.Body)
+        req.Body.Close()
+        if err != nil {
+            return err
+        }
+        body = b
+        req.Body = io.NopCloser(bytes.NewReader(b))
+        req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+    }
+    return signer.SignRequest(req, body)
+}
+
+// closeRequestBody closes the body of a request that is never sent, such
+// as an open BodyFile, which client.Do would otherwise close.
+func closeRequestBody(req *http.Request) {
+    if req.Body != nil {
+        req.Body.Close()
+    }
+}
+
+// decorateRequest runs the WithRequestDecorator hook on req, turning a
+// panic into an error so one bad decorator cannot crash a worker.
+func (c *Checker) decorateRequest(req *http.Request, ep Endpoint) (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.ilog(LogError, "request_decorator_panic", endpointFields(ep, zap.Any("panic", r))...)
+            err = fmt.Errorf("request decorator panicked: %v", r)
+        }
+    }()
+    c.requestDecorator(req, ep)
+    return nil
+}
+
+// applyHeaders sets the global default headers, the rotated User-Agent, the
+// endpoint's Content-Type, Accept-Encoding and forwarding headers, then the
+// endpoint's own headers. It returns the User-Agent sent when rotation is
+// enabled.
+func (c *Checker) applyHeaders(req *http.Request, ep Endpoint) string {
+    for k, v := range c.defaultHeaders {
+        req.Header.Set(k, v)
+    }
+    if n := len(c.userAgents); n > 0 {
+        req.Header.Set("User-Agent", c.userAgents[(c.uaNext.Add(1)-1)%uint64(n)])
+    }
+    if ep.ContentType != "" {
+        req.Header.Set("Content-Type", ep.ContentType)
+    }
+    if ep.AcceptEncoding != "" {
+        req.Header.Set("Accept-Encoding", ep.AcceptEncoding)
+    }
+    setForwarded(req.Header, ep)
+    for k, v := range ep.Headers {
+        req.Header.Set(k, v)
+    }
+    if len(c.userAgents) == 0 {
+        return ""
+    }
+    return req.Header.Get("User-Agent")
+}
+
+// saveLog stores res and updates the endpoint's latency and size
+// statistics. It returns res with the Degraded flag evaluated. Results of
+// endpoints removed since the check started, and duplicates under
+// WithResultDedup, are not stored and reported as an error.
+func (c *Checker) saveLog(res Result) (Result, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
+    if _, ok := c.endpointLocked(res.Endpoint.ID); !ok {
+        return res, errRemovedEndpoint
+    }
+    c.applyBaselineLocked(&res)
+    c.applySizeLocked(&res)
+    res.Maintenance = c.inMaintenanceLocked(res.Endpoint, res.Timestamp)
+    if c.duplicateLocked(res) {
+        return res, errDuplicateResult
+    }
     id := res.Endpoint.ID
     c.logs[id] = append(c.logs[id], res)
-    if len(c.logs[id]) > c.logRetention {
-        c.logs[id] = c.logs[id][len(c.logs[id])-c.logRetention:]
+    if len(c.logs[id]) == 1 {
+        c.noteHeadLocked(id)
     }
+    c.trimLogsLocked(id, c.retentionFor(res.Endpoint))
+    c.addLogsLocked(1)
+    if res.Success {
+        s, ok := c.latency[id]
+        if !ok {
+            s = &latencyStats{}
+            c.latency[id] = s
+        }
+        s.add(res.Latency)
+    }
+    return res, nil
 }
 
 func (c *Checker) isRunning() bool {
@@ -136,9 +698,30 @@ func (c *Checker) log(res Result) {
     }
 }
 
-// ===== Internal Logging Helper =====
-func (c *Checker) ilog(format string, args ...interface{}) {
-    if c.enableInternalLogs {
-        c.logger.Info(fmt.Sprintf("[INTERNAL] "+format, args...))
+// ===== Internal Logging Helpers =====
+
+// internalEnabled reports whether internal events at level are emitted. Hot
+// paths call it before building fields so disabled logging costs nothing.
+func (c *Checker) internalEnabled(level LogLevel) bool {
+    return c.enableInternalLogs && level != LogNone && level <= c.internalLogLevel
+}
+
+// ilog emits a structured internal (lifecycle) event. Internal events carry
+// log="internal" and an event name so they can be filtered apart from
+// site-check logs.
+func (c *Checker) ilog(level LogLevel, event string, fields ...zap.Field) {
+    if !c.internalEnabled(level) {
+        return
+    }
+    fields = append(fields, zap.String("log", "internal"), zap.String("event", event))
+    if level == LogError {
+        c.logger.Error(event, fields...)
+        return
     }
+    c.logger.Info(event, fields...)
+}
+
+// endpointFields returns the standard endpoint identification fields followed by extra.
+func endpointFields(ep Endpoint, extra ...zap.Field) []zap.Field {
+    return append([]zap.Field{zap.String("endpoint_id", ep.ID), zap.String("endpoint_name", ep.Name)}, extra...)
 }
@@ -0,0 +1,300 @@
+// Package zipkin exports one span per check result to a Zipkin collector,
+// or to Jaeger through its Zipkin-compatible endpoint, for distributed
+// tracing of checks without running an OpenTelemetry collector.
+//
+// Spans are encoded as Zipkin v2 JSON, which keeps the module free of the
+// tracing SDK dependencies. Each span covers the check's latency and is
+// tagged with the endpoint, status code and error.
+//
+//	x, err := zipkin.New(zipkin.Config{URL: "http://zipkin:9411/api/v2/spans"})
+//	if err != nil { ... }
+//	x.Attach(checker) // flushed when the checker shuts down
+package zipkin
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+)
+
+// Config configures an Exporter. Zero values select the defaults.
+type Config struct {
+    URL           string            // span endpoint, e.g. http://zipkin:9411/api/v2/spans (required)
+    ServiceName   string            // local service name of the spans (default "uptime-checker")
+    FlushInterval time.Duration     // how often pending spans are sent (default 5s)
+    BatchSize     int               // spans per request; a full batch is sent early (default 500)
+    MaxPending    int               // spans buffered before Add drops new ones (default 10000)
+    Headers       map[string]string // extra request headers, e.g. Authorization
+    Client        *http.Client      // default: 30s timeout
+}
+
+// Exporter batches check results as spans and sends them to a collector.
+type Exporter struct {
+    cfg Config
+
+    mu      sync.Mutex
+    pending []span
+
+    kick      chan struct{}
+    stop      chan struct{}
+    done      chan struct{}
+    started   bool
+    closeOnce sync.Once
+
+    sent    atomic.Int64
+    dropped atomic.Int64
+    failed  atomic.Int64
+}
+
+// New returns an Exporter for cfg.
+func New(cfg Config) (*Exporter, error) {
+    u, err := url.Parse(cfg.URL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return nil, fmt.Errorf("zipkin: invalid url %q", cfg.URL)
+    }
+    if cfg.ServiceName == "" {
+        cfg.ServiceName = "uptime-checker"
+    }
+    if cfg.FlushInterval <= 0 {
+        cfg.FlushInterval = 5 * time.Second
+    }
+    if cfg.BatchSize <= 0 {
+        cfg.BatchSize = 500
+    }
+    if cfg.MaxPending <= 0 {
+        cfg.MaxPending = 10000
+    }
+    if cfg.Client == nil {
+        cfg.Client = &http.Client{Timeout: 30 * time.Second}
+    }
+    return &Exporter{
+        cfg:  cfg,
+        kick: make(chan struct{}, 1),
+        stop: make(chan struct{}),
+        done: make(chan struct{}),
+    }, nil
+}
+
+// Add queues a span for res. It never blocks: when MaxPending spans are
+// already waiting (the collector is slow or down) the span is dropped and
+// counted in Stats.
+func (x *Exporter) Add(res uptime.Result) {
+    s := x.toSpan(res)
+    x.mu.Lock()
+    if len(x.pending) >= x.cfg.MaxPending {
+        x.mu.Unlock()
+        x.dropped.Add(1)
+        return
+    }
+    x.pending = append(x.pending, s)
+    full := len(x.pending) >= x.cfg.BatchSize
+    x.mu.Unlock()
+    if full {
+        select {
+        case x.kick <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// Attach exports every result recorded by c, starting the flush loop, and
+// closes the exporter, flushing what is pending, once c has shut down.
+func (x *Exporter) Attach(c *uptime.Checker) {
+    c.OnResult(x.Add)
+    x.Start()
+    go func() {
+        <-c.Done()
+        _ = x.Close()
+    }()
+}
+
+// Start runs the background flush loop until Close.
+func (x *Exporter) Start() {
+    x.mu.Lock()
+    if x.started {
+        x.mu.Unlock()
+        return
+    }
+    x.started = true
+    x.mu.Unlock()
+    go x.loop()
+}
+
+// Close stops the flush loop and sends whatever is still pending.
+func (x *Exporter) Close() error {
+    var err error
+    x.closeOnce.Do(func() {
+        close(x.stop)
+        x.mu.Lock()
+        started := x.started
+        x.mu.Unlock()
+        if started {
+            <-x.done
+        }
+        ctx, cancel := context.WithTimeout(context.Background(), x.cfg.Client.Timeout+time.Second)
+        defer cancel()
+        err = x.Flush(ctx)
+    })
+    return err
+}
+
+// Stats reports spans sent, spans dropped because the buffer was full, and
+// spans discarded after a request failed.
+func (x *Exporter) Stats() (sent, dropped, failed int64) {
+    return x.sent.Load(), x.dropped.Load(), x.failed.Load()
+}
+
+func (x *Exporter) loop() {
+    defer close(x.done)
+    t := time.NewTicker(x.cfg.FlushInterval)
+    defer t.Stop()
+    for {
+        select {
+        case <-x.stop:
+            return
+        case <-t.C:
+        case <-x.kick:
+        }
+        ctx, cancel := context.WithCancel(context.Background())
+        go func() {
+            select {
+            case <-x.stop:
+                cancel()
+            case <-ctx.Done():
+            }
+        }()
+        _ = x.Flush(ctx)
+        cancel()
+    }
+}
+
+// Flush sends all pending spans in batches of BatchSize. Spans are
+// best-effort: a batch the collector rejects is discarded and counted, and
+// Flush returns the last such error.
+func (x *Exporter) Flush(ctx context.Context) error {
+    var lastErr error
+    for {
+        x.mu.Lock()
+        n := min(len(x.pending), x.cfg.BatchSize)
+        batch := append([]span(nil), x.pending[:n]...)
+        x.pending = x.pending[n:]
+        x.mu.Unlock()
+        if len(batch) == 0 {
+            return lastErr
+        }
+        if err := x.post(ctx, batch); err != nil {
+            x.failed.Add(int64(len(batch)))
+            lastErr = err
+            if ctx.Err() != nil {
+                return lastErr
+            }
+            continue
+        }
+        x.sent.Add(int64(len(batch)))
+    }
+}
+
+func (x *Exporter) post(ctx context.Context, batch []span) error {
+    payload, err := json.Marshal(batch)
+    if err != nil {
+        return err
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, x.cfg.URL, bytes.NewReader(payload))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    for k, v := range x.cfg.Headers {
+        req.Header.Set(k, v)
+    }
+    resp, err := x.cfg.Client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    if resp.StatusCode/100 != 2 {
+        return fmt.Errorf("zipkin: status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+    }
+    return nil
+}
+
+// ===== Span Encoding =====
+
+// span is a Zipkin v2 span.
+type span struct {
+    TraceID        string            `json:"traceId"`
+    ID             string            `json:"id"`
+    Name           string            `json:"name"`
+    Kind           string            `json:"kind"`
+    Timestamp      int64             `json:"timestamp"` // microseconds since the epoch
+    Duration       int64             `json:"duration"`  // microseconds
+    LocalEndpoint  endpoint          `json:"localEndpoint"`
+    RemoteEndpoint *endpoint         `json:"remoteEndpoint,omitempty"`
+    Tags           map[string]string `json:"tags"`
+}
+
+type endpoint struct {
+    ServiceName string `json:"serviceName"`
+}
+
+func (x *Exporter) toSpan(res uptime.Result) span {
+    ep := res.Endpoint
+    s := span{
+        TraceID:       randomID(16),
+        ID:            randomID(8),
+        Name:          "check " + ep.ID,
+        Kind:          "CLIENT",
+        Timestamp:     res.Timestamp.UnixMicro(),
+        Duration:      max(res.Latency.Microseconds(), 1),
+        LocalEndpoint: endpoint{ServiceName: x.cfg.ServiceName},
+        Tags: map[string]string{
+            "uptime.endpoint_id": ep.ID,
+            "uptime.success":     strconv.FormatBool(res.Success),
+        },
+    }
+    if ep.Name != "" {
+        s.RemoteEndpoint = &endpoint{ServiceName: ep.Name}
+    }
+    if ep.Method != "" {
+        s.Tags["http.method"] = ep.Method
+    }
+    if ep.URL != "" {
+        s.Tags["http.url"] = ep.URL
+    }
+    if res.StatusCode != 0 {
+        s.Tags["http.status_code"] = strconv.Itoa(res.StatusCode)
+    }
+    if res.FailureKind != "" {
+        s.Tags["uptime.failure_kind"] = string(res.FailureKind)
+    }
+    if !res.Success {
+        // Zipkin marks spans with an "error" tag as failed.
+        s.Tags["error"] = res.Error
+        if res.Error == "" {
+            s.Tags["error"] = "check failed"
+        }
+    }
+    return s
+}
+
+// randomID returns n random bytes as lowercase hex, as Zipkin IDs are.
+func randomID(n int) string {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        panic("zipkin: no randomness: " + err.Error())
+    }
+    return hex.EncodeToString(b)
+}
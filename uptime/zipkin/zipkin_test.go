@@ -0,0 +1,111 @@
+package zipkin_test
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/amartya2002/uptime-checker-core/uptime"
+    "github.com/amartya2002/uptime-checker-core/uptime/zipkin"
+)
+
+// Results become Zipkin v2 spans covering the check latency; failed checks
+// carry the error tag, and Close flushes what is still pending.
+func TestExporterSendsSpans(t *testing.T) {
+    var mu sync.Mutex
+    var got []map[string]any
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/api/v2/spans" || r.Header.Get("Content-Type") != "application/json" {
+            t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+        }
+        var spans []map[string]any
+        if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+            t.Errorf("decode: %v", err)
+        }
+        mu.Lock()
+        got = append(got, spans...)
+        mu.Unlock()
+        w.WriteHeader(http.StatusAccepted)
+    }))
+    defer ts.Close()
+
+    x, err := zipkin.New(zipkin.Config{URL: ts.URL + "/api/v2/spans", ServiceName: "probe", FlushInterval: time.Hour})
+    if err != nil {
+        t.Fatalf("New: %v", err)
+    }
+    x.Start()
+    at := time.UnixMicro(1_700_000_000_000_000)
+    ep := uptime.Endpoint{ID: "api", Name: "API", URL: "https://api.example.com", Method: "GET"}
+    x.Add(uptime.Result{Endpoint: ep, Timestamp: at, Latency: 120 * time.Millisecond, Success: true, StatusCode: 200})
+    x.Add(uptime.Result{Endpoint: ep, Timestamp: at, Latency: time.Second, StatusCode: 503, Error: "unexpected status 503"})
+    if err := x.Close(); err != nil {
+        t.Fatalf("Close: %v", err)
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    if len(got) != 2 {
+        t.Fatalf("expected 2 spans, got %d", len(got))
+    }
+    ok, failed := got[0], got[1]
+    tags := ok["tags"].(map[string]any)
+    if ok["name"] != "check api" || ok["kind"] != "CLIENT" || ok["timestamp"] != float64(at.UnixMicro()) ||
+        ok["duration"] != float64(120_000) || tags["http.status_code"] != "200" || tags["error"] != nil {
+        t.Fatalf("unexpected span %v", ok)
+    }
+    if ok["localEndpoint"].(map[string]any)["serviceName"] != "probe" || len(ok["traceId"].(string)) != 32 || len(ok["id"].(string)) != 16 {
+        t.Fatalf("unexpected span ids or service %v", ok)
+    }
+    if failed["tags"].(map[string]any)["error"] != "unexpected status 503" {
+        t.Fatalf("expected an error tag, got %v", failed["tags"])
+    }
+    if sent, dropped, failedN := x.Stats(); sent != 2 || dropped != 0 || failedN != 0 {
+        t.Fatalf("unexpected stats %d/%d/%d", sent, dropped, failedN)
+    }
+
+    if _, err := zipkin.New(zipkin.Config{URL: "zipkin:9411"}); err == nil {
+        t.Fatalf("expected an invalid url to be rejected")
+    }
+}
+
+// An attached exporter gets the checker's results and is flushed when the
+// checker shuts down.
+func TestExporterAttach(t *testing.T) {
+    var mu sync.Mutex
+    var got []map[string]any
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var spans []map[string]any
+        json.NewDecoder(r.Body).Decode(&spans)
+        mu.Lock()
+        got = append(got, spans...)
+        mu.Unlock()
+    }))
+    defer ts.Close()
+
+    x, err := zipkin.New(zipkin.Config{URL: ts.URL, FlushInterval: time.Hour})
+    if err != nil {
+        t.Fatalf("New: %v", err)
+    }
+    c := uptime.New(uptime.DisableLogs())
+    x.Attach(c)
+    c.AddSite(uptime.Endpoint{ID: "api", URL: "http://203.0.113.1", Frequency: time.Minute})
+    c.Replay("api", []uptime.Result{{Success: true, StatusCode: 200}, {StatusCode: 503}})
+    c.Stop()
+
+    deadline := time.Now().Add(2 * time.Second)
+    for {
+        mu.Lock()
+        n := len(got)
+        mu.Unlock()
+        if n == 2 {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("expected 2 spans flushed at shutdown, got %d", n)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}